@@ -16,18 +16,28 @@ limitations under the License.
 package main
 
 import (
+	"SLALite/assessment"
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/monitor/push"
+	"SLALite/assessment/monitor/remotewrite"
 	"SLALite/generator"
 	"SLALite/model"
+	"SLALite/reports"
+	"SLALite/telemetry"
 	"SLALite/utils"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/spf13/viper"
 )
@@ -42,6 +52,15 @@ const (
 	enableSslPropertyName   = "enableSsl"
 	sslCertPathPropertyName = "sslCertPath"
 	sslKeyPathPropertyName  = "sslKeyPath"
+
+	// defaultReplayStep is the evaluation period of a replay if not specified
+	defaultReplayStep = time.Minute
+
+	// defaultHistoryInterval is the interval of the evaluation history returned if not specified
+	defaultHistoryInterval = 24 * time.Hour
+
+	// shutdownTimeout is the maximum time to wait for the active requests on shutdown
+	shutdownTimeout = 30 * time.Second
 )
 
 // App is a main application "object", to be built by main and testmain
@@ -55,6 +74,10 @@ type App struct {
 	SslKeyPath  string
 	externalIDs bool
 	validator   model.Validator
+	metrics     *push.Buffer
+	checks      map[string]func() error
+	scheduler   *assessment.Scheduler
+	replays     *sync.WaitGroup
 }
 
 // ApiError is the struct sent to client on errors
@@ -84,6 +107,25 @@ var api = map[string]endpoint{
 	"providers":  endpoint{"GET", "/providers", "Providers"},
 	"agreements": endpoint{"GET", "/agreements", "Agreements"},
 	"templates":  endpoint{"GET", "/templates", "Templates"},
+	"incidents":  endpoint{"GET", "/agreements/{id}/incidents", "Incidents of an agreement"},
+	"assess":     endpoint{"POST", "/agreements/{id}/assess", "Assesses an agreement immediately"},
+	"replay":     endpoint{"POST", "/agreements/{id}/replay", "Evaluates an agreement over a past period"},
+	"replays":    endpoint{"GET", "/agreements/{id}/replays", "Replays of an agreement"},
+	"history":    endpoint{"GET", "/agreements/{id}/guarantees/{name}/history", "Evaluation history of a guarantee term"},
+	"windows":    endpoint{"GET", "/maintenance-windows", "Maintenance windows"},
+	"calendars":  endpoint{"GET", "/calendars", "Calendars of the guarantee terms"},
+	"report":     endpoint{"GET", "/agreements/{id}/report", "Compliance report of an agreement"},
+	"preport":    endpoint{"GET", "/providers/{id}/report", "Compliance report of the agreements of a provider"},
+	"statement":  endpoint{"GET", "/agreements/{id}/statement", "SLA statement of an agreement in HTML or PDF"},
+	"metrics":    endpoint{"POST", "/metrics", "Push metric values"},
+	"write":      endpoint{"POST", "/api/v1/write", "Prometheus remote write"},
+	"telemetry":  endpoint{"GET", "/metrics", "Metrics of SLALite in Prometheus format"},
+	"health":     endpoint{"GET", "/healthz", "Liveness probe"},
+	"ready":      endpoint{"GET", "/readyz", "Readiness probe"},
+	"scheduler":  endpoint{"GET", "/admin/scheduler", "Status of the assessment scheduler"},
+	"pause":      endpoint{"POST", "/admin/scheduler/pause", "Pauses the assessment scheduler"},
+	"resume":     endpoint{"POST", "/admin/scheduler/resume", "Resumes the assessment scheduler"},
+	"run":        endpoint{"POST", "/admin/scheduler/run", "Triggers an assessment run"},
 }
 
 func NewApp(config *viper.Viper, repository model.IRepository, validator model.Validator) (App, error) {
@@ -98,6 +140,9 @@ func NewApp(config *viper.Viper, repository model.IRepository, validator model.V
 		SslKeyPath:  config.GetString(sslKeyPathPropertyName),
 		externalIDs: config.GetBool(utils.ExternalIDsPropertyName),
 		validator:   validator,
+		metrics:     push.Default(),
+		checks:      make(map[string]func() error),
+		replays:     &sync.WaitGroup{},
 	}
 
 	a.initialize(repository)
@@ -138,11 +183,20 @@ func (a *App) initialize(repository model.IRepository) {
 
 	a.Router.HandleFunc("/", a.Index).Methods("GET")
 
+	a.Router.Methods("GET").Path("/healthz").HandlerFunc(a.Health)
+	a.Router.Methods("GET").Path("/readyz").HandlerFunc(a.Ready)
+	a.Router.Methods("GET").Path("/metrics").Handler(telemetry.Default())
+	a.AddReadinessCheck("repository", func() error {
+		_, err := a.Repository.GetAllProviders()
+		return err
+	})
+
 	a.Router.Methods("GET").Path("/providers").Handler(logger(a.GetAllProviders))
 
 	a.Router.Methods("GET").Path("/providers/{id}").Handler(logger(a.GetProvider))
 	a.Router.Methods("POST").Path("/providers").Handler(logger(a.CreateProvider))
 	a.Router.Methods("DELETE").Path("/providers/{id}").Handler(logger(a.DeleteProvider))
+	a.Router.Methods("GET").Path("/providers/{id}/report").Handler(logger(a.GetProviderReport))
 
 	a.Router.Methods("GET").Path("/agreements").Handler(logger(a.GetAgreements))
 	a.Router.Methods("GET").Path("/agreements/{id}").Handler(logger(a.GetAgreement))
@@ -157,6 +211,21 @@ func (a *App) initialize(repository model.IRepository) {
 
 	a.Router.Methods("DELETE").Path("/agreements/{id}").Handler(logger(a.DeleteAgreement))
 	a.Router.Methods("GET").Path("/agreements/{id}/details").Handler(logger(a.GetAgreementDetails))
+	a.Router.Methods("GET").Path("/agreements/{id}/incidents").Handler(logger(a.GetAgreementIncidents))
+	a.Router.Methods("GET").Path("/agreements/{id}/guarantees/{name}/history").Handler(logger(a.GetGuaranteeHistory))
+
+	a.Router.Methods("POST").Path("/agreements/{id}/metrics").Handler(logger(a.PushAgreementMetrics))
+
+	a.Router.Methods("GET").Path("/incidents/{id}").Handler(logger(a.GetIncident))
+
+	a.Router.Methods("GET").Path("/agreements/{id}/replays").Handler(logger(a.GetAgreementReplays))
+	a.Router.Methods("GET").Path("/replays/{id}").Handler(logger(a.GetReplay))
+
+	a.Router.Methods("GET").Path("/agreements/{id}/report").Handler(logger(a.GetAgreementReport))
+	a.Router.Methods("GET").Path("/agreements/{id}/statement").Handler(logger(a.GetAgreementStatement))
+
+	a.Router.Methods("POST").Path("/metrics").Handler(logger(a.PushMetrics))
+	a.Router.Methods("POST").Path("/api/v1/write").Handler(loggerDecorator(remotewrite.Default()))
 
 	a.Router.Methods("GET").Path("/templates").Handler(logger(a.GetTemplates))
 	a.Router.Methods("GET").Path("/templates/{id}").Handler(logger(a.GetTemplate))
@@ -164,18 +233,595 @@ func (a *App) initialize(repository model.IRepository) {
 
 	a.Router.Methods("POST").Path("/create-agreement").Handler(logger(a.CreateAgreementFromTemplate))
 
+	a.Router.Methods("GET").Path("/maintenance-windows").Handler(logger(a.GetMaintenanceWindows))
+	a.Router.Methods("GET").Path("/maintenance-windows/{id}").Handler(logger(a.GetMaintenanceWindow))
+	a.Router.Methods("POST").Path("/maintenance-windows").Handler(logger(a.CreateMaintenanceWindow))
+	a.Router.Methods("PUT").Path("/maintenance-windows/{id}").Handler(logger(a.UpdateMaintenanceWindow))
+	a.Router.Methods("DELETE").Path("/maintenance-windows/{id}").Handler(logger(a.DeleteMaintenanceWindow))
+
+	a.Router.Methods("GET").Path("/calendars").Handler(logger(a.GetCalendars))
+	a.Router.Methods("GET").Path("/calendars/{id}").Handler(logger(a.GetCalendar))
+
 	a.Router.Methods("POST").Path("/notifications").Handler(logger(a.ReceiveNotification))
 }
 
-// Run starts the REST API
-func (a *App) Run() {
-	addr := ":" + a.Port
+// Run serves the REST API until ctx is done. Then, it stops accepting
+// connections and waits for the active requests to finish (up to shutdownTimeout).
+func (a *App) Run(ctx context.Context) error {
+	server := &http.Server{
+		Addr:    ":" + a.Port,
+		Handler: a.Router,
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		log.Info("Shutting down REST API")
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdown <- server.Shutdown(sctx)
+	}()
 
+	var err error
 	if a.SslEnabled {
-		log.Fatal(http.ListenAndServeTLS(addr, a.SslCertPath, a.SslKeyPath, a.Router))
+		err = server.ListenAndServeTLS(a.SslCertPath, a.SslKeyPath)
 	} else {
-		log.Fatal(http.ListenAndServe(addr, a.Router))
+		err = server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+	err = <-shutdown
+	log.Info("Waiting for the replays in progress")
+	a.replays.Wait()
+	return err
+}
+
+// assessmentResult is the result of an on-demand assessment
+type assessmentResult struct {
+	amodel.Result
+	Violations []model.Violation `json:"violations"`
+	Assessment model.Assessment  `json:"assessment"`
+	DryRun     bool              `json:"dry_run"`
+}
+
+// AddAssessment adds the endpoint that assesses an agreement on demand with cfg
+func (a *App) AddAssessment(cfg assessment.Config) {
+	a.Router.Methods("POST").Path("/agreements/{id}/assess").Handler(logger(
+		func(w http.ResponseWriter, r *http.Request) {
+			a.AssessAgreement(w, r, cfg)
+		}))
+	a.Router.Methods("POST").Path("/agreements/{id}/replay").Handler(logger(
+		func(w http.ResponseWriter, r *http.Request) {
+			a.ReplayAgreement(w, r, cfg)
+		}))
+}
+
+// AssessAgreement assesses an agreement immediately
+// swagger:operation POST /agreements/{id}/assess assessAgreement
+//
+// Assesses an agreement immediately, outside the periodic assessment. The
+// agreement is persisted and the violations are notified, unless dryRun is set.
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// - name: dryRun
+//   in: query
+//   description: If true, the result is neither persisted nor notified
+//   required: false
+//   type: boolean
+// responses:
+//   '200':
+//     description: The result of the assessment
+//   '400' :
+//     description: Invalid dryRun value
+//   '404' :
+//     description: Agreement not found
+func (a *App) AssessAgreement(w http.ResponseWriter, r *http.Request, cfg assessment.Config) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	dryRun, err := getBoolParam(r, "dryRun")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var agreement *model.Agreement
+	var result amodel.Result
+	if dryRun {
+		agreement, result, err = a.assessDryRun(id, cfg)
+	} else if a.scheduler != nil {
+		/* serialized with the periodic assessment */
+		agreement, result, err = a.scheduler.AssessAgreement(id)
+	} else {
+		agreement, err = a.Repository.GetAgreement(id)
+		if err == nil {
+			result = assessment.AssessSingleAgreement(cfg, agreement)
+		}
+	}
+	if err != nil {
+		manageError(err, w)
+		return
+	}
+	respondSuccessJSON(w, assessmentResult{
+		Result:     result,
+		Violations: result.GetViolations(),
+		Assessment: agreement.Assessment,
+		DryRun:     dryRun,
+	})
+}
+
+// assessDryRun assesses the agreement identified by id without persisting nor notifying the results
+func (a *App) assessDryRun(id string, cfg assessment.Config) (*model.Agreement, amodel.Result, error) {
+	agreement, err := a.Repository.GetAgreement(id)
+	if err != nil {
+		return nil, amodel.Result{}, err
+	}
+	windows, err := a.Repository.GetAllMaintenanceWindows()
+	if err != nil {
+		return nil, amodel.Result{}, err
+	}
+	now := cfg.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return agreement, cfg.AssessAgreement(agreement, now, windows...), nil
+}
+
+// replayRequest is the optional body of a replay
+type replayRequest struct {
+	// Guarantees replace the guarantee terms of the agreement in the replay
+	Guarantees []model.Guarantee `json:"guarantees"`
+}
+
+// ReplayAgreement evaluates an agreement over a past period
+// swagger:operation POST /agreements/{id}/replay replayAgreement
+//
+// Evaluates an agreement over a past period with the values in monitoring, as
+// the periodic assessment would have done every step. The result is stored as
+// a replay; the agreement is not modified and the violations are not notified.
+//
+// The replay is evaluated in background: the response is the replay in running
+// status, whose results are available in GET /replays/{id} when it is done.
+//
+// ---
+// consumes:
+// - application/json
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// - name: from
+//   in: query
+//   description: Start of the period (RFC3339)
+//   required: true
+//   type: string
+// - name: to
+//   in: query
+//   description: End of the period (RFC3339). Defaults to now
+//   required: false
+//   type: string
+// - name: step
+//   in: query
+//   description: Evaluation period (e.g. 5m). Defaults to 1m
+//   required: false
+//   type: string
+// - name: replay
+//   in: body
+//   description: Guarantee terms to evaluate instead of the agreement ones
+//   required: false
+//   schema:
+//     type: object
+// responses:
+//   '202':
+//     description: The created replay, in running status
+//     schema:
+//       "$ref": "#/definitions/Replay"
+//   '400' :
+//     description: Invalid period or guarantee terms
+//   '404' :
+//     description: Agreement not found
+func (a *App) ReplayAgreement(w http.ResponseWriter, r *http.Request, cfg assessment.Config) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	from, to, step, err := getReplayParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var input replayRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	agreement, err := a.Repository.GetAgreement(id)
+	if err != nil {
+		manageError(err, w)
+		return
+	}
+	if len(input.Guarantees) > 0 {
+		for i := range input.Guarantees {
+			if errs := a.validator.ValidateGuarantee(&input.Guarantees[i], model.CREATE); len(errs) > 0 {
+				respondWithError(w, http.StatusBadRequest, errs[0].Error())
+				return
+			}
+		}
+		agreement.Details.Guarantees = input.Guarantees
+	}
+
+	windows, err := a.Repository.GetAllMaintenanceWindows()
+	if err != nil {
+		manageError(err, w)
+		return
+	}
+	replay, err := assessment.NewReplay(*agreement, from, to, step)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+	replay, err = a.Repository.CreateReplay(replay)
+	if err != nil {
+		manageError(err, w)
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, replay)
+
+	a.replays.Add(1)
+	go func(replay model.Replay) {
+		defer a.replays.Done()
+		assessment.RunReplay(&replay, *agreement, cfg.Adapter, windows...)
+		if _, err := a.Repository.UpdateReplay(&replay); err != nil {
+			log.Errorf("Error saving replay %s: %s", replay.Id, err.Error())
+		}
+	}(*replay)
+}
+
+func getReplayParams(r *http.Request) (from time.Time, to time.Time, step time.Duration, err error) {
+	query := r.URL.Query()
+	if from, err = time.Parse(time.RFC3339, query.Get("from")); err != nil {
+		return from, to, step, fmt.Errorf("invalid value of from: %s", query.Get("from"))
+	}
+	to = time.Now()
+	if value := query.Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, step, fmt.Errorf("invalid value of to: %s", value)
+		}
+	}
+	step = defaultReplayStep
+	if value := query.Get("step"); value != "" {
+		if step, err = time.ParseDuration(value); err != nil {
+			return from, to, step, fmt.Errorf("invalid value of step: %s", value)
+		}
+	}
+	return from, to, step, nil
+}
+
+// GetAgreementReplays gets the replays of an agreement
+// swagger:operation GET /agreements/{id}/replays getAgreementReplays
+//
+// Returns the replays of an agreement, sorted by creation time
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// responses:
+//   '200':
+//     description: The list of replays of the agreement
+//     schema:
+//       "$ref": "#/definitions/Replays"
+//   '404' :
+//     description: Agreement not found
+func (a *App) GetAgreementReplays(w http.ResponseWriter, r *http.Request) {
+	a.get(w, r, func(id string) (interface{}, error) {
+		if _, err := a.Repository.GetAgreement(id); err != nil {
+			return nil, err
+		}
+		return a.Repository.GetReplaysByAgreement(id)
+	})
+}
+
+// GetReplay gets a replay by REST ID
+// swagger:operation GET /replays/{id} getReplay
+//
+// Returns a replay given its ID
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the replay
+//   required: true
+//   type: string
+// responses:
+//   '200':
+//     description: The replay with the ID
+//     schema:
+//       "$ref": "#/definitions/Replay"
+//   '404' :
+//     description: Replay not found
+func (a *App) GetReplay(w http.ResponseWriter, r *http.Request) {
+	a.get(w, r, func(id string) (interface{}, error) {
+		return a.Repository.GetReplay(id)
+	})
+}
+
+// GetAgreementReport gets the compliance report of an agreement
+// swagger:operation GET /agreements/{id}/report getAgreementReport
+//
+// Returns the compliance report of an agreement in a calendar period
+//
+// ---
+// produces:
+// - application/json
+// - text/csv
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// - name: period
+//   in: query
+//   description: Year (2026), month (2026-09) or day (2026-09-15). Defaults to the current month
+//   required: false
+//   type: string
+// - name: format
+//   in: query
+//   description: json (default) or csv
+//   required: false
+//   type: string
+// responses:
+//   '200':
+//     description: The report of the agreement
+//   '400' :
+//     description: Invalid period
+//   '404' :
+//     description: Agreement not found
+func (a *App) GetAgreementReport(w http.ResponseWriter, r *http.Request) {
+	a.report(w, r, func(id string, period reports.Period, now time.Time) (interface{}, []reports.AgreementReport, error) {
+		agreement, err := a.Repository.GetAgreement(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		report, err := reports.BuildAgreementReport(a.Repository, agreement, period, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		return report, []reports.AgreementReport{*report}, nil
+	})
+}
+
+// GetProviderReport gets the compliance report of the agreements of a provider
+// swagger:operation GET /providers/{id}/report getProviderReport
+//
+// Returns the compliance report of the agreements of a provider in a calendar period
+//
+// ---
+// produces:
+// - application/json
+// - text/csv
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the provider
+//   required: true
+//   type: string
+// - name: period
+//   in: query
+//   description: Year (2026), month (2026-09) or day (2026-09-15). Defaults to the current month
+//   required: false
+//   type: string
+// - name: format
+//   in: query
+//   description: json (default) or csv
+//   required: false
+//   type: string
+// responses:
+//   '200':
+//     description: The report of the provider
+//   '400' :
+//     description: Invalid period
+//   '404' :
+//     description: Provider not found
+func (a *App) GetProviderReport(w http.ResponseWriter, r *http.Request) {
+	a.report(w, r, func(id string, period reports.Period, now time.Time) (interface{}, []reports.AgreementReport, error) {
+		provider, err := a.Repository.GetProvider(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		report, err := reports.BuildProviderReport(a.Repository, provider, period, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		return report, report.Agreements, nil
+	})
+}
+
+// GetAgreementStatement gets the SLA statement of an agreement
+// swagger:operation GET /agreements/{id}/statement getAgreementStatement
+//
+// Returns the SLA statement of an agreement in a calendar period: agreement
+// details, compliance per guarantee, penalties and violations
+//
+// ---
+// produces:
+// - text/html
+// - application/pdf
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// - name: period
+//   in: query
+//   description: Year (2026), month (2026-09) or day (2026-09-15). Defaults to the current month
+//   required: false
+//   type: string
+// - name: format
+//   in: query
+//   description: html (default) or pdf
+//   required: false
+//   type: string
+// responses:
+//   '200':
+//     description: The statement of the agreement
+//   '400' :
+//     description: Invalid period or format
+//   '404' :
+//     description: Agreement not found
+func (a *App) GetAgreementStatement(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	now := time.Now()
+	period, err := getPeriodParam(r, now)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" && format != "pdf" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid format '%s'", format))
+		return
+	}
+
+	agreement, err := a.Repository.GetAgreement(id)
+	if err != nil {
+		manageError(err, w)
+		return
+	}
+	statement, err := reports.BuildStatement(a.Repository, agreement, period, now)
+	if err != nil {
+		manageError(err, w)
+		return
+	}
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-%s.pdf\"", id, period.Name))
+		err = reports.WritePDF(w, statement)
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = reports.WriteHTML(w, statement)
+	}
+	if err != nil {
+		log.Errorf("Error writing statement: %s", err.Error())
+	}
+}
+
+// getPeriodParam returns the period in the query parameter "period", or the month of now if not set
+func getPeriodParam(r *http.Request, now time.Time) (reports.Period, error) {
+	if name := r.URL.Query().Get("period"); name != "" {
+		return reports.ParsePeriod(name)
+	}
+	return reports.MonthOf(now), nil
+}
+
+// report writes the report returned by build, in JSON or CSV
+func (a *App) report(w http.ResponseWriter, r *http.Request,
+	build func(id string, period reports.Period, now time.Time) (interface{}, []reports.AgreementReport, error)) {
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	query := r.URL.Query()
+
+	now := time.Now()
+	period, err := getPeriodParam(r, now)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid format '%s'", format))
+		return
+	}
+
+	report, agreements, err := build(id, period, now)
+	if err != nil {
+		manageError(err, w)
+		return
+	}
+	if format != "csv" {
+		respondSuccessJSON(w, report)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-%s.csv\"", id, period.Name))
+	if err := reports.WriteCSV(w, agreements...); err != nil {
+		log.Errorf("Error writing report: %s", err.Error())
+	}
+}
+
+// getBoolParam returns the value of a boolean query parameter. A parameter
+// without value (e.g. "?dryRun") is true.
+func getBoolParam(r *http.Request, name string) (bool, error) {
+	values, ok := r.URL.Query()[name]
+	if !ok {
+		return false, nil
+	}
+	if values[0] == "" {
+		return true, nil
+	}
+	result, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, fmt.Errorf("invalid value of %s: %s", name, values[0])
+	}
+	return result, nil
+}
+
+// AddScheduler adds the admin endpoints that control the assessment scheduler s.
+// The on-demand assessments (see AddAssessment) are done through s afterwards.
+func (a *App) AddScheduler(s *assessment.Scheduler) {
+	a.scheduler = s
+	a.Router.Methods("GET").Path("/admin/scheduler").Handler(logger(
+		func(w http.ResponseWriter, r *http.Request) {
+			respondSuccessJSON(w, s.Status())
+		}))
+	a.Router.Methods("POST").Path("/admin/scheduler/pause").Handler(logger(
+		func(w http.ResponseWriter, r *http.Request) {
+			s.Pause()
+			log.Info("Assessment scheduler paused")
+			respondSuccessJSON(w, s.Status())
+		}))
+	a.Router.Methods("POST").Path("/admin/scheduler/resume").Handler(logger(
+		func(w http.ResponseWriter, r *http.Request) {
+			s.Resume()
+			log.Info("Assessment scheduler resumed")
+			respondSuccessJSON(w, s.Status())
+		}))
+	a.Router.Methods("POST").Path("/admin/scheduler/run").Handler(logger(
+		func(w http.ResponseWriter, r *http.Request) {
+			if s.Trigger() {
+				log.Info("Assessment run triggered")
+			}
+			respondWithJSON(w, http.StatusAccepted, s.Status())
+		}))
 }
 
 // Index is the API index
@@ -197,15 +843,68 @@ func (a *App) Index(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(api)
 }
 
+// AddReadinessCheck adds a check of a dependency (e.g. the monitoring) to the readiness probe
+func (a *App) AddReadinessCheck(name string, check func() error) {
+	a.checks[name] = check
+}
+
+// Health is the liveness probe. It always returns 200 while the API is served.
+func (a *App) Health(w http.ResponseWriter, r *http.Request) {
+	respondSuccessJSON(w, map[string]string{"status": "ok"})
+}
+
+// Ready is the readiness probe. It returns 200 if all the readiness checks
+// succeed, and 503 otherwise. The body contains the result of each check.
+func (a *App) Ready(w http.ResponseWriter, r *http.Request) {
+	code := http.StatusOK
+	status := "ready"
+	checks := make(map[string]string, len(a.checks))
+	for name, check := range a.checks {
+		if err := check(); err != nil {
+			log.Warnf("Readiness check %s failed: %s", name, err.Error())
+			checks[name] = err.Error()
+			code = http.StatusServiceUnavailable
+			status = "not ready"
+		} else {
+			checks[name] = "ok"
+		}
+	}
+	respondWithJSON(w, code, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
 func logger(f func(w http.ResponseWriter, r *http.Request)) http.Handler {
 	return loggerDecorator(http.HandlerFunc(f))
 }
 
+// statusRecorder keeps the status code written to a ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.code = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
 func loggerDecorator(inner http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		inner.ServeHTTP(w, r)
+		sr := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		inner.ServeHTTP(sr, r)
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		telemetry.HTTPRequests.Inc(r.Method, route, strconv.Itoa(sr.code))
+		telemetry.HTTPRequestDuration.ObserveSince(start, r.Method, route)
 
 		log.Printf(
 			"%s\t%s\t\t%s",
@@ -476,6 +1175,256 @@ func (a *App) GetAgreementDetails(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetAgreementIncidents gets the incidents of an agreement
+// swagger:operation GET /agreements/{id}/incidents getAgreementIncidents
+//
+// Returns the incidents of an agreement, sorted by start time
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// - name: state
+//   in: query
+//   description: If set, only the incidents in this state (open/closed) are returned
+//   required: false
+//   type: string
+// responses:
+//   '200':
+//     description: The list of incidents of the agreement
+//     schema:
+//       "$ref": "#/definitions/Incidents"
+//   '404' :
+//     description: Agreement not found
+func (a *App) GetAgreementIncidents(w http.ResponseWriter, r *http.Request) {
+	states := make([]model.IncidentState, 0, 1)
+	if state := r.URL.Query().Get("state"); state != "" {
+		states = append(states, model.IncidentState(state))
+	}
+
+	a.get(w, r, func(id string) (interface{}, error) {
+		if _, err := a.Repository.GetAgreement(id); err != nil {
+			return nil, err
+		}
+		return a.Repository.GetIncidentsByAgreement(id, states...)
+	})
+}
+
+// GetGuaranteeHistory gets the evaluation history of a guarantee term
+// swagger:operation GET /agreements/{id}/guarantees/{name}/history getGuaranteeHistory
+//
+// Returns the evaluation records of a guarantee term, sorted by time
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// - name: name
+//   in: path
+//   description: The name of the guarantee term
+//   required: true
+//   type: string
+// - name: from
+//   in: query
+//   description: Start of the interval (RFC3339). Defaults to 24 hours before to
+//   required: false
+//   type: string
+// - name: to
+//   in: query
+//   description: End of the interval (RFC3339). Defaults to now
+//   required: false
+//   type: string
+// responses:
+//   '200':
+//     description: The evaluation records of the guarantee term
+//   '400' :
+//     description: Invalid interval
+//   '404' :
+//     description: Agreement or guarantee term not found
+func (a *App) GetGuaranteeHistory(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	from, to, err := getIntervalParams(r, defaultHistoryInterval)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	a.get(w, r, func(id string) (interface{}, error) {
+		agreement, err := a.Repository.GetAgreement(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, gt := range agreement.Details.Guarantees {
+			if gt.Name == name {
+				return a.Repository.GetEvaluationHistory(id, name, from, to)
+			}
+		}
+		return nil, model.ErrNotFound
+	})
+}
+
+// getIntervalParams returns the from and to query parameters. If not set, to is
+// now and from is the given duration before to.
+func getIntervalParams(r *http.Request, def time.Duration) (from time.Time, to time.Time, err error) {
+	query := r.URL.Query()
+	to = time.Now()
+	if value := query.Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, fmt.Errorf("invalid value of to: %s", value)
+		}
+	}
+	from = to.Add(-def)
+	if value := query.Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, fmt.Errorf("invalid value of from: %s", value)
+		}
+	}
+	return from, to, nil
+}
+
+// GetIncident gets an incident by REST ID
+// swagger:operation GET /incidents/{id} getIncident
+//
+// Returns an incident given its ID
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the incident
+//   required: true
+//   type: string
+// responses:
+//   '200':
+//     description: The incident with the ID
+//     schema:
+//       "$ref": "#/definitions/Incident"
+//   '404' :
+//     description: Incident not found
+func (a *App) GetIncident(w http.ResponseWriter, r *http.Request) {
+	a.get(w, r, func(id string) (interface{}, error) {
+		return a.Repository.GetIncident(id)
+	})
+}
+
+// PushAgreementMetrics stores metric values of an agreement
+// swagger:operation POST /agreements/{id}/metrics pushAgreementMetrics
+//
+// Stores metric values of an agreement, to be retrieved by the push retriever
+//
+// ---
+// consumes:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the agreement
+//   required: true
+//   type: string
+// - name: values
+//   in: body
+//   description: The values to store. The key is the metric name; if datetime is not set, the current time is used
+//   required: true
+//   schema:
+//     type: array
+//     items:
+//       "$ref": "#/definitions/MetricValue"
+// responses:
+//   '204':
+//     description: The values have been stored
+//   '400':
+//     description: Invalid values
+//   '404' :
+//     description: Agreement not found
+//   '507' :
+//     description: Too many series in the push buffer
+func (a *App) PushAgreementMetrics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var values []model.MetricValue
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	samples := make([]push.Sample, 0, len(values))
+	for _, v := range values {
+		samples = append(samples, push.Sample{AgreementID: id, MetricValue: v})
+	}
+	a.pushMetrics(w, samples)
+}
+
+// PushMetrics stores metric values of several agreements
+// swagger:operation POST /metrics pushMetrics
+//
+// Stores metric values, to be retrieved by the push retriever. The values
+// without agreement_id are used by all the agreements
+//
+// ---
+// consumes:
+// - application/json
+// parameters:
+// - name: values
+//   in: body
+//   description: The values to store. The key is the metric name; if datetime is not set, the current time is used
+//   required: true
+//   schema:
+//     type: array
+//     items:
+//       "$ref": "#/definitions/Sample"
+// responses:
+//   '204':
+//     description: The values have been stored
+//   '400':
+//     description: Invalid values
+//   '404' :
+//     description: Agreement not found
+//   '507' :
+//     description: Too many series in the push buffer
+func (a *App) PushMetrics(w http.ResponseWriter, r *http.Request) {
+	var samples []push.Sample
+	if err := json.NewDecoder(r.Body).Decode(&samples); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	a.pushMetrics(w, samples)
+}
+
+func (a *App) pushMetrics(w http.ResponseWriter, samples []push.Sample) {
+	if err := push.Validate(samples); err != nil {
+		manageError(err, w)
+		return
+	}
+	checked := make(map[string]bool)
+	for _, s := range samples {
+		if s.AgreementID == "" || checked[s.AgreementID] {
+			continue
+		}
+		if _, err := a.Repository.GetAgreement(s.AgreementID); err != nil {
+			manageError(err, w)
+			return
+		}
+		checked[s.AgreementID] = true
+	}
+	if err := a.metrics.Add(time.Now(), samples...); err != nil {
+		respondWithError(w, http.StatusInsufficientStorage, err.Error())
+		return
+	}
+	respondNoContent(w)
+}
+
 // CreateAgreement creates a agreement passed by REST params
 // swagger:operation POST /agreements createAgreement
 //
@@ -769,6 +1718,213 @@ func (a *App) CreateAgreementFromTemplate(w http.ResponseWriter, r *http.Request
 		})
 }
 
+// GetMaintenanceWindows return all maintenance windows
+// swagger:operation GET /maintenance-windows getMaintenanceWindows
+//
+// Returns all maintenance windows
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     description: The complete list of maintenance windows
+//     schema:
+//       "$ref": "#/definitions/MaintenanceWindows"
+func (a *App) GetMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	a.getAll(w, r, func() (interface{}, error) {
+		return a.Repository.GetAllMaintenanceWindows()
+	})
+}
+
+// GetMaintenanceWindow gets a maintenance window by REST ID
+// swagger:operation GET /maintenance-windows/{id} getMaintenanceWindow
+//
+// Returns a maintenance window given its ID
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the maintenance window
+//   required: true
+//   type: string
+// responses:
+//   '200':
+//     description: The maintenance window with the ID
+//     schema:
+//       "$ref": "#/definitions/MaintenanceWindow"
+//   '404' :
+//     description: Maintenance window not found
+func (a *App) GetMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	a.get(w, r, func(id string) (interface{}, error) {
+		return a.Repository.GetMaintenanceWindow(id)
+	})
+}
+
+// CreateMaintenanceWindow creates a maintenance window passed by REST params
+// swagger:operation POST /maintenance-windows createMaintenanceWindow
+//
+// Creates a maintenance window with the information passed in the request body.
+// If the id is empty and the repository does not assign ids, an UUID is assigned.
+//
+// ---
+// produces:
+// - application/json
+// consumes:
+// - application/json
+// parameters:
+// - name: window
+//   in: body
+//   description: The maintenance window to create
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/MaintenanceWindow"
+// responses:
+//   '201':
+//     description: The new maintenance window that has been created
+//     schema:
+//       "$ref": "#/definitions/MaintenanceWindow"
+//   '400' :
+//     description: Invalid maintenance window
+//   '409' :
+//     description: The maintenance window already exists
+func (a *App) CreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var window model.MaintenanceWindow
+
+	a.create(w, r,
+		func() error {
+			return json.NewDecoder(r.Body).Decode(&window)
+		},
+		func() (model.Identity, error) {
+			if window.Id == "" && !a.externalIDs {
+				window.Id = uuid.New().String()
+			}
+			return a.Repository.CreateMaintenanceWindow(&window)
+		})
+}
+
+// UpdateMaintenanceWindow replaces a maintenance window
+// swagger:operation PUT /maintenance-windows/{id} updateMaintenanceWindow
+//
+// Replaces a maintenance window with the information passed in the request body
+//
+// ---
+// produces:
+// - application/json
+// consumes:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the maintenance window
+//   required: true
+//   type: string
+// - name: window
+//   in: body
+//   description: The new maintenance window
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/MaintenanceWindow"
+// responses:
+//   '200':
+//     description: The updated maintenance window
+//     schema:
+//       "$ref": "#/definitions/MaintenanceWindow"
+//   '400' :
+//     description: Invalid maintenance window
+//   '404' :
+//     description: Maintenance window not found
+func (a *App) UpdateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var window model.MaintenanceWindow
+
+	a.updateEntity(w, r,
+		func() error {
+			return json.NewDecoder(r.Body).Decode(&window)
+		},
+		func(id string) (model.Identity, error) {
+			window.Id = id
+			return a.Repository.UpdateMaintenanceWindow(&window)
+		})
+}
+
+// DeleteMaintenanceWindow deletes /maintenance-windows/id
+// swagger:operation DELETE /maintenance-windows/{id} deleteMaintenanceWindow
+//
+// Deletes a maintenance window given its ID
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The identifier of the maintenance window
+//   required: true
+//   type: string
+// responses:
+//   '204':
+//     description: The maintenance window has been successfully deleted
+//   '404' :
+//     description: Maintenance window not found
+func (a *App) DeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	a.update(w, r, func(id string) error {
+		return a.Repository.DeleteMaintenanceWindow(&model.MaintenanceWindow{Id: id})
+	})
+}
+
+// GetCalendars return all calendars
+// swagger:operation GET /calendars getCalendars
+//
+// Returns the calendars defined in the configuration
+//
+// ---
+// produces:
+// - application/json
+// responses:
+//   '200':
+//     description: The list of calendars
+//     schema:
+//       type: array
+//       items:
+//         "$ref": "#/definitions/Calendar"
+func (a *App) GetCalendars(w http.ResponseWriter, r *http.Request) {
+	respondSuccessJSON(w, model.GetCalendars())
+}
+
+// GetCalendar gets a calendar by name
+// swagger:operation GET /calendars/{id} getCalendar
+//
+// Returns a calendar given its name
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+// - name: id
+//   in: path
+//   description: The name of the calendar
+//   required: true
+//   type: string
+// responses:
+//   '200':
+//     description: The calendar with the name
+//     schema:
+//       "$ref": "#/definitions/Calendar"
+//   '404' :
+//     description: Calendar not found
+func (a *App) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	a.get(w, r, func(id string) (interface{}, error) {
+		calendar, ok := model.GetCalendar(id)
+		if !ok {
+			return nil, model.ErrNotFound
+		}
+		return calendar, nil
+	})
+}
+
 // ReceiveNotification is an endpoint to test the sending of notifications to
 // external endpoints
 func (a *App) ReceiveNotification(w http.ResponseWriter, r *http.Request) {
@@ -18,11 +18,17 @@ package assessment
 
 import (
 	assessment_model "SLALite/assessment/model"
+	"SLALite/assessment/monitor"
 	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/events"
 	"SLALite/model"
+	"SLALite/repositories/lifecycle"
+	"SLALite/telemetry"
 	"SLALite/utils"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -90,15 +96,90 @@ func TestAssessActiveAgreements(t *testing.T) {
 		},
 	}
 
-	AssessActiveAgreements(repo, simpleadapter.New(m1), ValidationNotifier{Expected: map[string]map[string]int{
-		"aa01": map[string]int{
-			"TestGuarantee": 2,
-		},
-		"aa02": map[string]int{
-			"g1": 3,
-			"g2": 1,
-		},
-	}, T: t})
+	AssessActiveAgreements(Config{
+		Repo:    repo,
+		Adapter: simpleadapter.New(m1),
+		Notifier: ValidationNotifier{Expected: map[string]map[string]int{
+			"aa01": map[string]int{
+				"TestGuarantee": 2,
+			},
+			"aa02": map[string]int{
+				"g1": 3,
+				"g2": 1,
+			},
+		}, T: t},
+	})
+}
+
+type earlyAdapter struct {
+	monitor.MonitoringAdapter
+	batch []monitor.AgreementItems
+}
+
+func (ea *earlyAdapter) RetrieveAllValues(batch []monitor.AgreementItems) monitor.MonitoringAdapter {
+	ea.batch = batch
+	return ea.MonitoringAdapter
+}
+
+func TestRetrieveEarly(t *testing.T) {
+	expiration := t0.Add(-time.Hour)
+	started := createAgreementFull("ae01", p1, c2, "Agreement ae01",
+		map[string]string{"g1": "m >= 20", "g2": "m < n"}, nil)
+	started.State = model.STARTED
+	stopped := createAgreement("ae02", p1, c2, "Agreement ae02", "m >= 0")
+	expired := createAgreementFull("ae03", p1, c2, "Agreement ae03", map[string]string{"g1": "m >= 0"}, &expiration)
+	expired.State = model.STARTED
+
+	ea := &earlyAdapter{MonitoringAdapter: simpleadapter.New(nil)}
+	ma := RetrieveEarly(ea, model.Agreements{started, stopped, expired}, t0)
+	if ma != ea.MonitoringAdapter {
+		t.Errorf("Unexpected adapter: %v", ma)
+	}
+	if len(ea.batch) != 1 || ea.batch[0].Agreement.Id != "ae01" {
+		t.Fatalf("Unexpected batch: %v", ea.batch)
+	}
+	if items := ea.batch[0].Items; len(items) != 3 || !items[0].To.Equal(t0) {
+		t.Errorf("Unexpected items: %v", items)
+	}
+
+	if ma := RetrieveEarly(ea.MonitoringAdapter, model.Agreements{started}, t0); ma != ea.MonitoringAdapter {
+		t.Errorf("Adapter must be returned if it is not an EarlyRetriever: %v", ma)
+	}
+}
+
+func TestAssessActiveAgreementsExpired(t *testing.T) {
+	expiration := t_(-1)
+	ae1 := createAgreementFull("ae01", p1, c2, "Agreement ae01", map[string]string{"g1": "m >= 0"}, &expiration)
+	ae1.State = model.STARTED
+	repo.CreateAgreement(&ae1)
+	defer repo.DeleteAgreement(&ae1)
+
+	received := make([]events.Event, 0)
+	bus := events.NewBus()
+	bus.Subscribe(events.ListenerFunc(func(e events.Event) {
+		if e.AgreementID == ae1.Id {
+			received = append(received, e)
+		}
+	}))
+
+	/* as in main, the transitions are published by the decorated repository */
+	decorated, _ := lifecycle.New(repo, bus)
+	AssessActiveAgreements(Config{
+		Now:     t0,
+		Repo:    decorated,
+		Adapter: simpleadapter.New(nil),
+		Events:  bus,
+	})
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 event. Actual: %v", received)
+	}
+	if e := received[0]; e.Type != events.AgreementExpired || e.ToState != model.TERMINATED || !e.Datetime.Equal(t0) {
+		t.Errorf("Unexpected event: %v", e)
+	}
+	if updated, _ := repo.GetAgreement(ae1.Id); updated.State != model.TERMINATED {
+		t.Errorf("Expected terminated agreement. Actual: %v", updated.State)
+	}
 }
 
 func TestAssessAgreement(t *testing.T) {
@@ -203,6 +284,76 @@ func TestAssessExpiredAgreement(t *testing.T) {
 	}
 }
 
+func TestAssessAgreementStart(t *testing.T) {
+	values := assessment_model.GuaranteeData{
+		{"m": model.MetricValue{Key: "m", Value: -1, DateTime: t_(0)}},
+	}
+	ma := simpleadapter.New(values)
+	start := t_(10)
+
+	t.Run("AutoStart", func(t *testing.T) {
+		a := createAgreement("a02", p1, c2, "Agreement 02", "m >= 0")
+		a.State = model.STOPPED
+		a.Details.Start = &start
+		a.Details.Duration = "P1M"
+
+		result := AssessAgreement(&a, ma, t0)
+		checkAssessmentResult(t, &a, result, model.STOPPED, map[string]int{}, nil)
+		if a.Assessment.Activation != nil || a.Details.Expiration != nil {
+			t.Errorf("Unexpected activation before start: %v", a.Assessment.Activation)
+		}
+
+		result = AssessAgreement(&a, ma, t_(20))
+		checkAssessmentResult(t, &a, result, model.STARTED, map[string]int{"TestGuarantee": 1}, nil)
+		if a.Assessment.Activation == nil || !a.Assessment.Activation.Equal(start) {
+			t.Errorf("Unexpected activation. Expected: %v; Actual: %v", start, a.Assessment.Activation)
+		}
+		if expected := start.AddDate(0, 1, 0); a.Details.Expiration == nil || !a.Details.Expiration.Equal(expected) {
+			t.Errorf("Unexpected expiration. Expected: %v; Actual: %v", expected, a.Details.Expiration)
+		}
+
+		/* once activated, a stopped agreement is not started again */
+		a.State = model.STOPPED
+		AssessAgreement(&a, ma, t_(30))
+		if a.State != model.STOPPED {
+			t.Errorf("Agreement in unexpected state. Expected: stopped. Actual: %v", a.State)
+		}
+	})
+
+	t.Run("StartedBeforeStart", func(t *testing.T) {
+		a := createAgreement("a02", p1, c2, "Agreement 02", "m >= 0")
+		a.State = model.STARTED
+		a.Details.Start = &start
+
+		result := AssessAgreement(&a, ma, t0)
+		if result.LastExecution != nil || !a.Assessment.LastExecution.IsZero() {
+			t.Errorf("Unexpected evaluation before start: %v", result)
+		}
+		if from := getDefaultFrom(&a, a.Details.Guarantees[0]); !from.Equal(start) {
+			t.Errorf("Unexpected default from. Expected: %v; Actual: %v", start, from)
+		}
+	})
+
+	t.Run("NoStart", func(t *testing.T) {
+		a := createAgreement("a02", p1, c2, "Agreement 02", "m >= 0")
+		a.State = model.STOPPED
+		AssessAgreement(&a, ma, t0)
+		if a.State != model.STOPPED || a.Assessment.Activation != nil {
+			t.Errorf("Unexpected activation of stopped agreement: %v", a.Assessment.Activation)
+		}
+
+		a.State = model.STARTED
+		a.Details.Duration = "PT1H"
+		AssessAgreement(&a, ma, t0)
+		if a.Assessment.Activation == nil || !a.Assessment.Activation.Equal(t0) {
+			t.Errorf("Unexpected activation. Expected: %v; Actual: %v", t0, a.Assessment.Activation)
+		}
+		if expected := t0.Add(time.Hour); a.Details.Expiration == nil || !a.Details.Expiration.Equal(expected) {
+			t.Errorf("Unexpected expiration. Expected: %v; Actual: %v", expected, a.Details.Expiration)
+		}
+	})
+}
+
 func TestEvaluateAgreement(t *testing.T) {
 	values := assessment_model.GuaranteeData{
 		{"m": model.MetricValue{Key: "m", Value: 1, DateTime: t_(0)}},
@@ -250,6 +401,265 @@ func TestEvaluateAgreementWithWrongValues(t *testing.T) {
 	}
 }
 
+func TestEvaluateAgreementMissingData(t *testing.T) {
+	ma := simpleadapter.New(nil)
+	now := t_(10)
+
+	a := createAgreement("amd01", p1, c2, "Agreement amd01", "m >= 0")
+	result, err := EvaluateAgreement(&a, ma, now)
+	if err != nil || !result.NoData["TestGuarantee"] || len(result.Violated) != 0 {
+		t.Errorf("Unexpected result of default policy: %v. err=%v", result, err)
+	}
+
+	a.Details.Guarantees[0].OnMissingData = &model.MissingData{Policy: model.VIOLATE}
+	result, _ = EvaluateAgreement(&a, ma, now)
+	vs := result.Violated["TestGuarantee"].Violations
+	if len(vs) != 1 || !vs[0].NoData || !vs[0].Datetime.Equal(now) {
+		t.Errorf("Unexpected violations of violation policy: %v", vs)
+	}
+
+	/* last value policy without last values */
+	a.Details.Guarantees[0].OnMissingData = &model.MissingData{Policy: model.LASTVALUE, MaxAge: 5}
+	result, _ = EvaluateAgreement(&a, ma, now)
+	if vs := result.Violated["TestGuarantee"].Violations; len(vs) != 1 || !vs[0].NoData {
+		t.Errorf("Unexpected violations of last value policy without values: %v", vs)
+	}
+
+	a.Assessment.SetGuarantee("TestGuarantee", model.AssessmentGuarantee{
+		LastValues: model.LastValues{"m": {Key: "m", Value: -1, DateTime: t_(7)}},
+	})
+	result, _ = EvaluateAgreement(&a, ma, now)
+	vs = result.Violated["TestGuarantee"].Violations
+	if len(vs) != 1 || vs[0].NoData || len(vs[0].Values) != 1 || !vs[0].Datetime.Equal(now) {
+		t.Errorf("Unexpected violations of last value policy: %v", vs)
+	}
+	if len(result.LastValues["TestGuarantee"]) != 0 {
+		t.Errorf("Last values must not be updated: %v", result.LastValues)
+	}
+
+	/* last values older than max age */
+	result, _ = EvaluateAgreement(&a, ma, t_(13))
+	if vs := result.Violated["TestGuarantee"].Violations; len(vs) != 1 || !vs[0].NoData {
+		t.Errorf("Unexpected violations of last value policy with old values: %v", vs)
+	}
+}
+
+func TestEvaluateAgreementCalendar(t *testing.T) {
+	model.RegisterCalendar(model.Calendar{
+		Name:    "business",
+		Windows: []model.WeeklyWindow{{Days: []string{"mon-fri"}, Start: "08:00", End: "18:00"}},
+	})
+	/* Wednesday */
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	values := assessment_model.GuaranteeData{
+		{"m": model.MetricValue{Key: "m", Value: -1, DateTime: day.Add(17*time.Hour + 59*time.Minute)}},
+		{"m": model.MetricValue{Key: "m", Value: -2, DateTime: day.Add(18*time.Hour + time.Minute)}},
+	}
+	a := createAgreement("acal01", p1, c2, "Agreement acal01", "m >= 0")
+	a.Details.Guarantees[0].Calendar = "business"
+	a.Details.Guarantees[0].OnMissingData = &model.MissingData{Policy: model.VIOLATE}
+
+	result, err := EvaluateAgreement(&a, simpleadapter.New(values), day.Add(18*time.Hour+2*time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Evaluated["TestGuarantee"]) != 1 || len(result.Violated["TestGuarantee"].Violations) != 1 {
+		t.Errorf("Expected only the point in business hours evaluated: %v", result)
+	}
+
+	/* no missing data out of business hours */
+	result, _ = EvaluateAgreement(&a, simpleadapter.New(nil), day.Add(20*time.Hour))
+	if result.NoData["TestGuarantee"] || len(result.Violated) != 0 {
+		t.Errorf("Unexpected result out of business hours: %v", result)
+	}
+	result, _ = EvaluateAgreement(&a, simpleadapter.New(nil), day.Add(12*time.Hour))
+	if !result.NoData["TestGuarantee"] || len(result.Violated) != 1 {
+		t.Errorf("Expected missing data violation in business hours: %v", result)
+	}
+
+	/* the term with an undefined calendar is not evaluated, but the others are */
+	a.Details.Guarantees[0].Calendar = "notdefined"
+	a.Details.Guarantees = append(a.Details.Guarantees, model.Guarantee{Name: "Other", Constraint: "m >= 0"})
+	result, err = EvaluateAgreement(&a, simpleadapter.New(values), day.Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := result.Errors["TestGuarantee"].(*CalendarError); !ok || result.NoData["TestGuarantee"] ||
+		len(result.Violated["TestGuarantee"].Violations) != 0 || len(result.Evaluated["TestGuarantee"]) != 0 {
+		t.Errorf("Expected calendar error of the term: %v", result)
+	}
+	if len(result.Evaluated["Other"]) == 0 {
+		t.Errorf("Expected the other term evaluated: %v", result)
+	}
+	updateAssessment(&a, result, day.Add(12*time.Hour))
+	if ag := a.Assessment.GetGuarantee("TestGuarantee"); ag.Status != model.ERROR || ag.Error == "" {
+		t.Errorf("Unexpected assessment of the term: %+v", ag)
+	}
+}
+
+func TestAssessActiveAgreementsNoData(t *testing.T) {
+	a := createAgreement("amd02", p1, c2, "Agreement amd02", "m >= 0")
+	a.State = model.STARTED
+	a.Details.Guarantees[0].OnMissingData = &model.MissingData{Policy: model.WARN}
+	repo.CreateAgreement(&a)
+	defer repo.DeleteAgreement(&a)
+
+	received := make([]events.Event, 0)
+	bus := events.NewBus()
+	bus.Subscribe(events.ListenerFunc(func(e events.Event) {
+		if e.AgreementID == a.Id {
+			received = append(received, e)
+		}
+	}))
+
+	cfg := Config{
+		Now:     t_(1),
+		Repo:    repo,
+		Adapter: simpleadapter.New(nil),
+		Events:  bus,
+	}
+	evaluated := telemetry.AgreementsEvaluated.Value()
+	AssessActiveAgreements(cfg)
+	cfg.Now = t_(2)
+	AssessActiveAgreements(cfg)
+
+	if n := telemetry.AgreementsEvaluated.Value() - evaluated; n < 2 {
+		t.Errorf("Expected at least 2 evaluated agreements. Actual: %v", n)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 event. Actual: %v", received)
+	}
+	if e := received[0]; e.Type != events.GuaranteeNoData || e.Guarantee != "TestGuarantee" || !e.Datetime.Equal(t_(1)) {
+		t.Errorf("Unexpected event: %v", e)
+	}
+	updated, _ := repo.GetAgreement(a.Id)
+	if since := updated.Assessment.GetGuarantee("TestGuarantee").NoDataSince; since == nil || !since.Equal(t_(1)) {
+		t.Errorf("Unexpected no data since: %v", since)
+	}
+}
+
+type failingAdapter struct {
+	monitor.MonitoringAdapter
+}
+
+func (fa failingAdapter) Initialize(a *model.Agreement) monitor.MonitoringAdapter {
+	return fa
+}
+
+func (fa failingAdapter) GetValues(gt model.Guarantee, vars []string, now time.Time) (assessment_model.GuaranteeData, error) {
+	return nil, errors.New("monitoring unavailable")
+}
+
+func TestAssessAgreementStatus(t *testing.T) {
+	a := createAgreement("ast01", p1, c2, "Agreement ast01", "m >= 0")
+	a.State = model.STARTED
+	a.Details.Guarantees[0].OnMissingData = &model.MissingData{Policy: model.VIOLATE}
+
+	result := AssessAgreement(&a, failingAdapter{}, t_(1))
+	if err := result.Errors["TestGuarantee"]; err == nil {
+		t.Errorf("Expected monitoring error in result: %v", result)
+	}
+	if vs := result.Violated["TestGuarantee"].Violations; len(vs) != 1 || !vs[0].NoData {
+		t.Errorf("Expected missing data policy applied on monitoring errors: %v", result)
+	}
+	ag := a.Assessment.GetGuarantee("TestGuarantee")
+	if ag.Status != model.MONITORINGERROR || ag.Error != "monitoring unavailable" {
+		t.Errorf("Unexpected status: %s (%s)", ag.Status, ag.Error)
+	}
+
+	AssessAgreement(&a, simpleadapter.New(nil), t_(2))
+	if ag := a.Assessment.GetGuarantee("TestGuarantee"); ag.Status != model.NODATA || ag.Error != "" {
+		t.Errorf("Unexpected status: %s (%s)", ag.Status, ag.Error)
+	}
+
+	/* an outage keeps the time without values */
+	AssessAgreement(&a, failingAdapter{}, t_(3))
+	if since := a.Assessment.GetGuarantee("TestGuarantee").NoDataSince; since == nil || !since.Equal(t_(2)) {
+		t.Errorf("Unexpected no data since: %v", since)
+	}
+
+	values := assessment_model.GuaranteeData{
+		{"m": model.MetricValue{Key: "m", Value: 1, DateTime: t_(3)}},
+	}
+	AssessAgreement(&a, simpleadapter.New(values), t_(4))
+	if ag := a.Assessment.GetGuarantee("TestGuarantee"); ag.Status != model.OK || ag.NoDataSince != nil {
+		t.Errorf("Unexpected status: %s. No data since: %v", ag.Status, ag.NoDataSince)
+	}
+}
+
+func TestAssessAgreementMonitoringErrorPolicy(t *testing.T) {
+	tests := []struct {
+		policy     MonitoringErrorPolicy
+		onMissing  model.MissingDataPolicy
+		violations int
+	}{
+		{MissingDataOnError, model.IGNORE, 0},
+		{MissingDataOnError, model.VIOLATE, 1},
+		{ViolateOnError, model.IGNORE, 1},
+		{SkipOnError, model.VIOLATE, 0},
+	}
+	for _, test := range tests {
+		a := createAgreement("amep01", p1, c2, "Agreement amep01", "m >= 0")
+		a.State = model.STARTED
+		a.Details.Guarantees[0].OnMissingData = &model.MissingData{Policy: test.onMissing}
+
+		cfg := Config{Adapter: failingAdapter{}, OnMonitoringError: test.policy}
+		result := cfg.AssessAgreement(&a, t_(1))
+		if vs := result.Violated["TestGuarantee"].Violations; len(vs) != test.violations {
+			t.Errorf("%s/%s: unexpected violations: %v", test.policy, test.onMissing, vs)
+		}
+		if result.Errors["TestGuarantee"] == nil {
+			t.Errorf("%s/%s: expected monitoring error in result", test.policy, test.onMissing)
+		}
+	}
+
+	if _, err := ParseMonitoringErrorPolicy("retry"); err == nil {
+		t.Errorf("Expected error parsing unknown policy")
+	}
+	if p, _ := ParseMonitoringErrorPolicy(""); p != MissingDataOnError {
+		t.Errorf("Unexpected default policy: %s", p)
+	}
+}
+
+// rangeAdapter records the start of the retrieval interval of every evaluation
+type rangeAdapter struct {
+	monitor.MonitoringAdapter
+	a     *model.Agreement
+	froms *[]time.Time
+	fail  bool
+}
+
+func (ra rangeAdapter) Initialize(a *model.Agreement) monitor.MonitoringAdapter {
+	ra.a = a
+	return ra
+}
+
+func (ra rangeAdapter) GetValues(gt model.Guarantee, vars []string, now time.Time) (assessment_model.GuaranteeData, error) {
+	*ra.froms = append(*ra.froms, BuildRetrievalItems(ra.a, gt, vars, now)[0].From)
+	if ra.fail {
+		return nil, errors.New("monitoring unavailable")
+	}
+	return nil, nil
+}
+
+func TestAssessAgreementRereadsOutage(t *testing.T) {
+	a := createAgreement("aro01", p1, c2, "Agreement aro01", "m >= 0")
+	a.State = model.STARTED
+	a.Details.Creation = t_(0)
+
+	froms := make([]time.Time, 0)
+	AssessAgreement(&a, rangeAdapter{froms: &froms, fail: true}, t_(1))
+	AssessAgreement(&a, rangeAdapter{froms: &froms, fail: true}, t_(2))
+	AssessAgreement(&a, rangeAdapter{froms: &froms}, t_(3))
+	AssessAgreement(&a, rangeAdapter{froms: &froms}, t_(4))
+
+	expected := []time.Time{t_(0), t_(0), t_(0), t_(3)}
+	if !reflect.DeepEqual(froms, expected) {
+		t.Errorf("Expected the outage interval retrieved again. Expected: %v. Actual: %v", expected, froms)
+	}
+}
+
 func TestEvaluateGuarantee(t *testing.T) {
 	values := assessment_model.GuaranteeData{
 		{"m": model.MetricValue{Key: "m", Value: 1, DateTime: t_(0)}},
@@ -21,7 +21,11 @@ import (
 	amodel "SLALite/assessment/model"
 	"SLALite/assessment/monitor"
 	"SLALite/assessment/notifier"
+	"SLALite/events"
 	"SLALite/model"
+	"SLALite/telemetry"
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/Knetic/govaluate"
@@ -32,20 +36,207 @@ func init() {
 	log.SetLevel(log.DebugLevel)
 }
 
-//AssessActiveAgreements will get the active agreements from the provided repository and assess them, notifying about violations with the provided notifier.
-func AssessActiveAgreements(repo model.IRepository, ma monitor.MonitoringAdapter, not notifier.ViolationNotifier) {
+// MonitoringErrorPolicy is what the assessment does with a guarantee term whose
+// values cannot be retrieved from the monitoring
+type MonitoringErrorPolicy string
+
+const (
+	// MissingDataOnError applies the missing data policy of the term, as if
+	// the monitoring had returned no values. It is the default.
+	MissingDataOnError MonitoringErrorPolicy = "missing_data"
+	// ViolateOnError raises a violation without values, whatever the missing data policy
+	ViolateOnError MonitoringErrorPolicy = "violate"
+	// SkipOnError does not evaluate the term
+	SkipOnError MonitoringErrorPolicy = "skip"
+)
+
+// ParseMonitoringErrorPolicy returns the MonitoringErrorPolicy of a name.
+// An empty name is the default policy.
+func ParseMonitoringErrorPolicy(name string) (MonitoringErrorPolicy, error) {
+	switch p := MonitoringErrorPolicy(name); p {
+	case "":
+		return MissingDataOnError, nil
+	case MissingDataOnError, ViolateOnError, SkipOnError:
+		return p, nil
+	}
+	return "", fmt.Errorf("monitoring error policy '%s' is not valid", name)
+}
+
+// Config contains the configuration of an assessment process
+type Config struct {
+	// Now is the time considered as the current time. If zero, time.Now() is used.
+	Now time.Time
+
+	// Repo is the repository where the agreements are read from and saved to
+	Repo model.IRepository
+
+	// Adapter is the monitoring adapter used to retrieve the metric values
+	Adapter monitor.MonitoringAdapter
+
+	// Notifier is notified about the violations. May be nil.
+	Notifier notifier.ViolationNotifier
+
+	// Events receives the lifecycle events raised by the assessment. May be nil.
+	Events events.Publisher
+
+	// Incidents enables the grouping of violations into incidents (see UpdateIncidents).
+	// When enabled, opened and closed incidents are published as events instead of
+	// calling the Notifier, unless the Notifier is not an events.Listener.
+	Incidents bool
+
+	// HistoryResolution downsamples the evaluation history: the point sets of a guarantee
+	// term are summarized in a record per time slot of this duration. If zero, there is
+	// a record per point set.
+	HistoryResolution time.Duration
+
+	// HistoryRetention is how long the evaluation history is kept. If zero, it is kept forever.
+	HistoryRetention time.Duration
+
+	// OnMonitoringError is what is done with a guarantee term whose values cannot
+	// be retrieved. If empty, MissingDataOnError.
+	OnMonitoringError MonitoringErrorPolicy
+
+	// Context cancels the assessment. If it is done, the agreements not evaluated yet
+	// are skipped; the agreement being evaluated is completed and saved. May be nil.
+	Context context.Context
+}
+
+// AssessActiveAgreements will get the active agreements from the provided repository and assess them,
+// notifying about violations with the provided notifier.
+func AssessActiveAgreements(cfg Config) {
+	repo := cfg.Repo
+	now := cfg.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	defer telemetry.AssessmentDuration.ObserveSince(time.Now())
+
 	agreements, err := repo.GetAgreementsByState(model.STARTED, model.STOPPED)
 	if err != nil {
 		log.Errorf("Error getting active agreements: %s", err.Error())
 	} else {
 		log.Printf("AssessActiveAgreements(). %d agreements to evaluate", len(agreements))
-		for _, agreement := range agreements {
-			result := AssessAgreement(&agreement, ma, time.Now())
-			repo.UpdateAgreement(&agreement)
-			if not != nil && len(result.Violated) > 0 {
-				not.NotifyViolations(&agreement, &result)
+		for i := range agreements {
+			activate(&agreements[i], now)
+		}
+		adapter := RetrieveEarly(cfg.Adapter, agreements, now)
+		windows := cfg.maintenanceWindows()
+		for i, agreement := range agreements {
+			if cfg.Context != nil && cfg.Context.Err() != nil {
+				log.Infof("Assessment cancelled. %d agreements not evaluated", len(agreements)-i)
+				break
+			}
+			cfg.assess(&agreement, adapter, now, windows)
+		}
+	}
+}
+
+// AssessSingleAgreement assesses an agreement at cfg.Now (or the current time if not set),
+// outside the periodic assessment. As AssessActiveAgreements, the agreement is
+// persisted and the violations are notified.
+func AssessSingleAgreement(cfg Config, a *model.Agreement) amodel.Result {
+	now := cfg.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return cfg.assess(a, cfg.Adapter, now, cfg.maintenanceWindows())
+}
+
+// maintenanceWindows returns the maintenance windows in the repository. On error,
+// the assessment goes on without them.
+func (cfg Config) maintenanceWindows() model.MaintenanceWindows {
+	windows, err := cfg.Repo.GetAllMaintenanceWindows()
+	if err != nil {
+		log.Errorf("Error getting maintenance windows: %s", err.Error())
+	}
+	return windows
+}
+
+// assess assesses an agreement, persists it and notifies the results
+func (cfg Config) assess(a *model.Agreement, ma monitor.MonitoringAdapter, now time.Time,
+	windows model.MaintenanceWindows) amodel.Result {
+
+	result := assessAgreement(a, ma, now, cfg.OnMonitoringError, windows...)
+	cfg.saveResults(a, result, now)
+	/* the state transitions are published by the repository (see repositories/lifecycle) */
+	cfg.Repo.UpdateAgreement(a)
+	recordTelemetry(a, result)
+	cfg.publishNoData(a, result, now)
+	notify := cfg.Notifier != nil
+	if cfg.Incidents {
+		UpdateIncidents(cfg, a, result)
+		/* a notifier that does not receive events is still told about the violations */
+		_, listener := cfg.Notifier.(events.Listener)
+		notify = notify && !listener
+	}
+	if notify && len(result.Violated) > 0 {
+		cfg.Notifier.NotifyViolations(a, &result)
+	}
+	return result
+}
+
+// recordTelemetry records the evaluation of an agreement in the metrics of SLALite
+func recordTelemetry(a *model.Agreement, result amodel.Result) {
+	if result.LastExecution == nil {
+		/* not evaluated */
+		return
+	}
+	telemetry.AgreementsEvaluated.Inc()
+	for gt, gtResult := range result.Violated {
+		telemetry.ViolationsRaised.Add(float64(len(gtResult.Violations)), gt)
+	}
+}
+
+// RetrieveEarly retrieves at once the values of the agreements to be evaluated at now,
+// if the adapter is a monitor.EarlyRetriever. It returns the adapter to use in the
+// evaluation of the agreements.
+func RetrieveEarly(ma monitor.MonitoringAdapter, agreements model.Agreements, now time.Time) monitor.MonitoringAdapter {
+	er, ok := ma.(monitor.EarlyRetriever)
+	if !ok {
+		return ma
+	}
+	batch := make([]monitor.AgreementItems, 0, len(agreements))
+	for i := range agreements {
+		a := &agreements[i]
+		if a.State != model.STARTED || !a.IsEffective(now) || (a.Details.Expiration != nil && a.Details.Expiration.Before(now)) {
+			continue
+		}
+		items := make([]monitor.RetrievalItem, 0)
+		for _, gt := range a.Details.Guarantees {
+			expression, err := govaluate.NewEvaluableExpression(gt.Constraint)
+			if err != nil {
+				/* reported on evaluation */
+				continue
 			}
+			items = append(items, BuildRetrievalItems(a, gt, expression.Vars(), now)...)
 		}
+		batch = append(batch, monitor.AgreementItems{Agreement: a, Items: items})
+	}
+	return er.RetrieveAllValues(batch)
+}
+
+// publishNoData publishes a GuaranteeNoData event for each guarantee term with
+// the WARN missing data policy that has started to have no values at now.
+func (cfg Config) publishNoData(a *model.Agreement, result amodel.Result, now time.Time) {
+	for _, gt := range a.Details.Guarantees {
+		if !result.NoData[gt.Name] || gt.OnMissingData == nil || gt.OnMissingData.Policy != model.WARN {
+			continue
+		}
+		if since := a.Assessment.GetGuarantee(gt.Name).NoDataSince; since != nil && since.Equal(now) {
+			cfg.publish(events.Event{
+				Type:        events.GuaranteeNoData,
+				AgreementID: a.Id,
+				Datetime:    now,
+				Agreement:   a,
+				Guarantee:   gt.Name,
+			})
+		}
+	}
+}
+
+func (cfg Config) publish(e events.Event) {
+	if cfg.Events != nil {
+		cfg.Events.Publish(e)
 	}
 }
 
@@ -62,18 +253,36 @@ func AssessActiveAgreements(repo model.IRepository, ma monitor.MonitoringAdapter
 // The function results are not persisted. The output must be persisted/handled accordingly.
 // E.g.: agreement and violations must be persisted to DB. Violations must be notified to
 // observers
-func AssessAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now time.Time) amodel.Result {
+//
+// The failing point sets in the maintenance windows are excluded (see EvaluateAgreement).
+func AssessAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now time.Time,
+	windows ...model.MaintenanceWindow) amodel.Result {
+	return assessAgreement(a, ma, now, MissingDataOnError, windows...)
+}
+
+// AssessAgreement assesses an agreement as the function AssessAgreement, with the
+// adapter and the monitoring error policy of the configuration. The results are
+// not persisted.
+func (cfg Config) AssessAgreement(a *model.Agreement, now time.Time,
+	windows ...model.MaintenanceWindow) amodel.Result {
+	return assessAgreement(a, cfg.Adapter, now, cfg.OnMonitoringError, windows...)
+}
+
+func assessAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now time.Time,
+	onError MonitoringErrorPolicy, windows ...model.MaintenanceWindow) amodel.Result {
 	var result amodel.Result
 	var err error
 
 	log.Debugf("AssessAgreement(%s)", a.Id)
-	if a.Details.Expiration != nil && a.Details.Expiration.Before(now) {
+	activate(a, now)
+	if a.Details.Expiration != nil && a.Details.Expiration.Before(now) && a.State != model.TERMINATED {
 		// agreement has expired
 		a.State = model.TERMINATED
+		a.Assessment.Expired = &now
 	}
 
-	if a.State == model.STARTED {
-		result, err = EvaluateAgreement(a, ma, now)
+	if a.State == model.STARTED && a.IsEffective(now) {
+		result, err = evaluateAgreement(a, ma, now, onError, windows...)
 		if err != nil {
 			log.Warn("Error evaluating agreement " + a.Id + ": " + err.Error())
 			return result
@@ -83,12 +292,34 @@ func AssessAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now time.
 	return result
 }
 
-func updateAssessment(a *model.Agreement, result amodel.Result, now time.Time) {
-	if a.Assessment.FirstExecution.IsZero() {
-		a.Assessment.FirstExecution = now
+// activate activates an agreement that has become effective at now and has not
+// been activated yet. A STOPPED agreement with a Start is started; a STARTED
+// agreement is activated at its Start, its first assessment or now.
+func activate(a *model.Agreement, now time.Time) {
+	if a.Assessment.Activation != nil || !a.IsEffective(now) {
+		return
 	}
-	a.Assessment.LastExecution = now
+	if a.State == model.STOPPED && a.Details.Start != nil {
+		log.Infof("Agreement %s starts at %s", a.Id, a.Details.Start)
+		a.State = model.STARTED
+	}
+	if a.State != model.STARTED {
+		return
+	}
+
+	activation := now
+	if a.Details.Start != nil {
+		activation = *a.Details.Start
+	} else if !a.Assessment.FirstExecution.IsZero() {
+		activation = a.Assessment.FirstExecution
+	}
+	if err := a.Activate(activation); err != nil {
+		log.Warnf("Error activating agreement %s: %s", a.Id, err.Error())
+	}
+}
 
+func updateAssessment(a *model.Agreement, result amodel.Result, now time.Time) {
+	/* the terms are updated first, as their retrieval interval may start at the agreement last execution */
 	for _, gt := range a.Details.Guarantees {
 		gtname := gt.Name
 		last := result.LastValues[gtname]
@@ -97,18 +328,48 @@ func updateAssessment(a *model.Agreement, result amodel.Result, now time.Time) {
 		if violated, ok := result.Violated[gtname]; ok {
 			violations = violated.Violations
 		}
-		updateAssessmentGuarantee(a, gtname, last, violations, now)
+		updateAssessmentGuarantee(a, gt, last, violations, result.NoData[gtname], result.Errors[gtname], now)
+	}
+	if a.Assessment.FirstExecution.IsZero() {
+		a.Assessment.FirstExecution = now
 	}
+	a.Assessment.LastExecution = now
 }
 
-func updateAssessmentGuarantee(a *model.Agreement, gtname string, last amodel.ExpressionData,
-	violations []model.Violation, now time.Time) {
+func updateAssessmentGuarantee(a *model.Agreement, gt model.Guarantee, last amodel.ExpressionData,
+	violations []model.Violation, noData bool, termErr error, now time.Time) {
 
+	gtname := gt.Name
 	ag := a.Assessment.GetGuarantee(gtname)
-	ag.LastExecution = now
+	if termErr != nil {
+		/* the next evaluation retrieves the values of the interval not evaluated */
+		ag.LastExecution = getDefaultFrom(a, gt)
+	} else {
+		ag.LastExecution = now
+	}
 	if ag.FirstExecution.IsZero() {
 		ag.FirstExecution = now
 	}
+	if termErr != nil {
+		/* unknown if there are values */
+	} else if !noData {
+		ag.NoDataSince = nil
+	} else if ag.NoDataSince == nil {
+		ag.NoDataSince = &now
+	}
+	ag.Error = ""
+	switch {
+	case termErr != nil:
+		ag.Status = model.MONITORINGERROR
+		if _, ok := termErr.(*CalendarError); ok {
+			ag.Status = model.ERROR
+		}
+		ag.Error = termErr.Error()
+	case noData:
+		ag.Status = model.NODATA
+	default:
+		ag.Status = model.OK
+	}
 	for _, v := range last {
 		ag.LastValues[v.Key] = v
 	}
@@ -123,7 +384,23 @@ func updateAssessmentGuarantee(a *model.Agreement, gtname string, last amodel.Ex
 // The MonitoringAdapter must feed the process correctly
 // (e.g. if the constraint of a guarantee term is of the type "A>B && C>D", the
 // MonitoringAdapter must supply pairs of values).
-func EvaluateAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now time.Time) (amodel.Result, error) {
+//
+// The point sets out of the active time of the calendar of a guarantee term are
+// not evaluated; out of it, the term has no missing values either.
+//
+// The point sets that fail the constraint in one of the maintenance windows that
+// apply to the agreement are marked as excluded and do not raise violations, nor
+// do the missing values in a window.
+//
+// When the values of a term cannot be retrieved, the error is in the result and
+// the missing data policy of the term is applied (see MissingDataOnError).
+func EvaluateAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now time.Time,
+	windows ...model.MaintenanceWindow) (amodel.Result, error) {
+	return evaluateAgreement(a, ma, now, MissingDataOnError, windows...)
+}
+
+func evaluateAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now time.Time,
+	onError MonitoringErrorPolicy, windows ...model.MaintenanceWindow) (amodel.Result, error) {
 	ma = ma.Initialize(a)
 
 	log.Debugf("EvaluateAgreement(%s)", a.Id)
@@ -131,6 +408,9 @@ func EvaluateAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now tim
 		Violated:      map[string]amodel.EvaluationGtResult{},
 		LastValues:    map[string]amodel.ExpressionData{},
 		LastExecution: map[string]time.Time{},
+		Evaluated:     map[string][]amodel.EvaluatedPoint{},
+		NoData:        map[string]bool{},
+		Errors:        map[string]error{},
 	}
 	gts := a.Details.Guarantees
 
@@ -138,21 +418,61 @@ func EvaluateAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now tim
 		/*
 		 * TODO Evaluate if gt has to be evaluated according to schedule
 		 */
-		failed, lastvalues, err := EvaluateGuarantee(a, gt, ma, now)
-		if err != nil {
+		points, err := evaluateGuarantee(a, gt, ma, now)
+		merr, monitoringErr := err.(*MonitoringError)
+		if monitoringErr {
+			/* the term is handled according to onError */
+			log.Warnf("Error retrieving values of guarantee %s of agreement %s: %s", gt.Name, a.Id, merr.Error())
+			result.Errors[gt.Name] = merr.Err
+			points = nil
+		} else if cerr, ok := err.(*CalendarError); ok {
+			/* the term is not evaluated, but the other terms are */
+			log.Warnf("Error evaluating guarantee %s of agreement %s: %s", gt.Name, a.Id, cerr.Error())
+			result.Errors[gt.Name] = cerr
+			points = nil
+		} else if err != nil {
 			log.Warn("Error evaluating expression " + gt.Constraint + ": " + err.Error())
 			return amodel.Result{}, err
 		}
+		var violations []model.Violation
+		noData := len(points) == 0 && activeAt(gt, now)
+		if result.Errors[gt.Name] != nil {
+			noData = noData && monitoringErr && onError != SkipOnError
+		}
+		if noData {
+			result.NoData[gt.Name] = true
+			if monitoringErr && onError == ViolateOnError {
+				points, violations, err = nil, []model.Violation{noDataViolation(a, gt, now)}, nil
+			} else {
+				points, violations, err = evaluateMissingData(a, gt, now)
+			}
+			if err != nil {
+				log.Warn("Error evaluating expression " + gt.Constraint + ": " + err.Error())
+				return amodel.Result{}, err
+			}
+			if model.MaintenanceWindows(windows).Excludes(a, now) {
+				violations = nil
+			}
+		}
+		excludePoints(a, points, windows)
+		failed, lastvalues := splitPoints(points)
 		if len(failed) > 0 {
-			violations := EvaluateGtViolations(a, gt, failed)
+			violations = append(violations, EvaluateGtViolations(a, gt, failed)...)
+		}
+		if len(violations) > 0 {
 			gtResult := amodel.EvaluationGtResult{
 				Metrics:    failed,
 				Violations: violations,
 			}
 			result.Violated[gt.Name] = gtResult
 		}
+		if noData {
+			/* the last values are kept, so their age keeps growing */
+			lastvalues = nil
+		}
 		result.LastValues[gt.Name] = lastvalues
 		result.LastExecution[gt.Name] = now
+		result.Evaluated[gt.Name] = points
 	}
 	return result, nil
 }
@@ -160,36 +480,187 @@ func EvaluateAgreement(a *model.Agreement, ma monitor.MonitoringAdapter, now tim
 // EvaluateGuarantee evaluates a guarantee term of an Agreement
 // (see EvaluateAgreement)
 //
-// Returns the metrics that failed the GT constraint.
+// Returns the metrics that failed the GT constraint, ignoring those in the
+// maintenance windows.
 func EvaluateGuarantee(a *model.Agreement,
 	gt model.Guarantee,
 	ma monitor.MonitoringAdapter,
-	now time.Time) (
+	now time.Time,
+	windows ...model.MaintenanceWindow) (
 	failed []amodel.ExpressionData, last amodel.ExpressionData, err error) {
 
+	points, err := evaluateGuarantee(a, gt, ma, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	excludePoints(a, points, windows)
+	failed, last = splitPoints(points)
+	return failed, last, nil
+}
+
+// MonitoringError is the error returned when the values of a guarantee term
+// cannot be retrieved from the monitoring
+type MonitoringError struct {
+	Err error
+}
+
+func (e *MonitoringError) Error() string {
+	return "monitoring error: " + e.Err.Error()
+}
+
+// CalendarError is the error returned when the calendar of a guarantee term
+// is not defined
+type CalendarError struct {
+	Guarantee string
+	Calendar  string
+}
+
+func (e *CalendarError) Error() string {
+	return fmt.Sprintf("calendar '%s' of guarantee %s is not defined", e.Calendar, e.Guarantee)
+}
+
+// evaluateGuarantee evaluates a guarantee term of an Agreement, returning every
+// evaluated point set in the active time of its calendar, in time order. If the values cannot be retrieved, the error
+// is a *MonitoringError; if the calendar is not defined, a *CalendarError.
+func evaluateGuarantee(a *model.Agreement,
+	gt model.Guarantee,
+	ma monitor.MonitoringAdapter,
+	now time.Time) ([]amodel.EvaluatedPoint, error) {
+
 	log.Debugf("EvaluateGuarantee(%s, %s)", a.Id, gt.Name)
-	failed = make(amodel.GuaranteeData, 0, 1)
 
 	expression, err := govaluate.NewEvaluableExpression(gt.Constraint)
 	if err != nil {
 		log.Warnf("Error parsing expression '%s'", gt.Constraint)
-		return nil, nil, err
+		return nil, err
+	}
+	calendar, err := calendarOf(gt)
+	if err != nil {
+		return nil, err
 	}
-	values := ma.GetValues(gt, expression.Vars(), now)
+	values, err := ma.GetValues(gt, expression.Vars(), now)
+	if err != nil {
+		return nil, &MonitoringError{Err: err}
+	}
+	points := make([]amodel.EvaluatedPoint, 0, len(values))
 	for _, value := range values {
+		if calendar != nil && !calendar.IsActive(value.Datetime()) {
+			continue
+		}
 		aux, err := evaluateExpression(expression, value)
 		if err != nil {
 			log.Warn("Error evaluating expression " + gt.Constraint + ": " + err.Error())
+			return nil, err
+		}
+		points = append(points, amodel.EvaluatedPoint{
+			Values: value,
+			Failed: aux != nil,
+		})
+	}
+	return points, nil
+}
+
+// calendarOf returns the calendar of a guarantee term, or nil if it has no calendar
+func calendarOf(gt model.Guarantee) (*model.Calendar, error) {
+	if gt.Calendar == "" {
+		return nil, nil
+	}
+	calendar, ok := model.GetCalendar(gt.Calendar)
+	if !ok {
+		return nil, &CalendarError{Guarantee: gt.Name, Calendar: gt.Calendar}
+	}
+	return calendar, nil
+}
+
+// activeAt returns if a guarantee term applies at t according to its calendar.
+// A term with an undefined calendar is not evaluated, so it never applies.
+func activeAt(gt model.Guarantee, t time.Time) bool {
+	calendar, err := calendarOf(gt)
+	return err == nil && (calendar == nil || calendar.IsActive(t))
+}
+
+/*
+evaluateMissingData evaluates a guarantee term without values, according to its
+missing data policy (see model.MissingData). It returns the evaluated points and
+the violations that are not the result of evaluating a point.
+
+  - IGNORE and WARN: no points nor violations.
+  - VIOLATE: a violation without values.
+  - LASTVALUE: the last known values, stamped at now, are evaluated again.
+    If there are no last values or they are older than MaxAge, a violation without values.
+*/
+func evaluateMissingData(a *model.Agreement, gt model.Guarantee, now time.Time) (
+	[]amodel.EvaluatedPoint, []model.Violation, error) {
+
+	policy := model.IGNORE
+	if gt.OnMissingData != nil {
+		policy = gt.OnMissingData.Policy
+	}
+	log.Debugf("No values of guarantee %s of agreement %s. Policy: %s", gt.Name, a.Id, policy)
+
+	switch policy {
+	case model.VIOLATE:
+		return nil, []model.Violation{noDataViolation(a, gt, now)}, nil
+	case model.LASTVALUE:
+		last := amodel.ExpressionData(a.Assessment.GetGuarantee(gt.Name).LastValues)
+		maxAge := time.Duration(gt.OnMissingData.MaxAge) * time.Second
+		if len(last) == 0 || (maxAge > 0 && now.Sub(last.Datetime()) > maxAge) {
+			return nil, []model.Violation{noDataViolation(a, gt, now)}, nil
+		}
+		expression, err := govaluate.NewEvaluableExpression(gt.Constraint)
+		if err != nil {
 			return nil, nil, err
 		}
-		if aux != nil {
-			failed = append(failed, aux)
+		values := make(amodel.ExpressionData, len(last))
+		for k, v := range last {
+			v.DateTime = now
+			values[k] = v
 		}
+		aux, err := evaluateExpression(expression, values)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []amodel.EvaluatedPoint{{Values: values, Failed: aux != nil}}, nil, nil
 	}
-	if len(values) > 0 {
-		last = values[len(values)-1]
+	return nil, nil, nil
+}
+
+func noDataViolation(a *model.Agreement, gt model.Guarantee, now time.Time) model.Violation {
+	return model.Violation{
+		AgreementId: a.Id,
+		Guarantee:   gt.Name,
+		Datetime:    now,
+		Constraint:  gt.Constraint,
+		Values:      []model.MetricValue{},
+		NoData:      true,
+	}
+}
+
+// excludePoints marks as excluded the failed points in a maintenance window
+func excludePoints(a *model.Agreement, points []amodel.EvaluatedPoint, windows model.MaintenanceWindows) {
+	if len(windows) == 0 {
+		return
+	}
+	for i := range points {
+		if p := &points[i]; p.Failed && windows.Excludes(a, p.Values.Datetime()) {
+			p.Failed = false
+			p.Excluded = true
+		}
 	}
-	return failed, last, nil
+}
+
+// splitPoints returns the failed point sets and the last point set of a list of evaluated points
+func splitPoints(points []amodel.EvaluatedPoint) (failed amodel.GuaranteeData, last amodel.ExpressionData) {
+	failed = make(amodel.GuaranteeData, 0, 1)
+	for _, p := range points {
+		if p.Failed {
+			failed = append(failed, p.Values)
+		}
+	}
+	if len(points) > 0 {
+		last = points[len(points)-1].Values
+	}
+	return failed, last
 }
 
 // EvaluateGtViolations creates violations for the detected violated metrics in EvaluateGuarantee
@@ -268,6 +739,10 @@ func getDefaultFrom(a *model.Agreement, gt model.Guarantee) time.Time {
 	if defaultFrom.IsZero() {
 		defaultFrom = a.Details.Creation
 	}
+	if a.Details.Start != nil && defaultFrom.Before(*a.Details.Start) {
+		/* values before the agreement is effective are not evaluated */
+		defaultFrom = *a.Details.Start
+	}
 	return defaultFrom
 }
 
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assessment
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/model"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// saveHistory adds the evaluated point sets of an assessment to the evaluation
+// history of each guarantee term, and removes the records older than the retention.
+func (cfg Config) saveHistory(a *model.Agreement, result amodel.Result, now time.Time) {
+	for _, gt := range a.Details.Guarantees {
+		records := make([]*model.EvaluationRecord, 0)
+		byID := make(map[string]*model.EvaluationRecord)
+
+		for _, p := range result.Evaluated[gt.Name] {
+			e := model.NewEvaluationRecord(a.Id, gt.Name, p.Values.Datetime(), cfg.HistoryResolution)
+			current, ok := byID[e.Id]
+			if !ok {
+				current = &e
+				byID[e.Id] = current
+				records = append(records, current)
+			}
+			addToRecord(current, p)
+		}
+		for _, e := range records {
+			if err := cfg.addEvaluationRecord(e); err != nil {
+				log.Errorf("Error saving history of agreement %s: %s", a.Id, err.Error())
+			}
+		}
+	}
+
+	if cfg.HistoryRetention > 0 {
+		if err := cfg.Repo.DeleteEvaluationHistory(a.Id, now.Add(-cfg.HistoryRetention)); err != nil {
+			log.Errorf("Error removing history of agreement %s: %s", a.Id, err.Error())
+		}
+	}
+}
+
+func addToRecord(e *model.EvaluationRecord, p amodel.EvaluatedPoint) {
+	e.Evaluated++
+	if p.Failed {
+		e.Failed++
+		e.Passed = false
+	} else if p.Excluded {
+		e.Excluded++
+	}
+	for key, value := range p.Values {
+		e.Values[key] = value.Value
+	}
+}
+
+// addEvaluationRecord adds e to the stored record of the same slot, if the
+// history is downsampled
+func (cfg Config) addEvaluationRecord(e *model.EvaluationRecord) error {
+	if cfg.HistoryResolution > 0 {
+		stored, err := cfg.Repo.GetEvaluationHistory(e.AgreementId, e.Guarantee, e.Datetime, e.Datetime.Add(cfg.HistoryResolution))
+		if err != nil {
+			return err
+		}
+		for _, current := range stored {
+			if current.Id == e.Id {
+				e.Evaluated += current.Evaluated
+				e.Failed += current.Failed
+				e.Excluded += current.Excluded
+				e.Passed = e.Passed && current.Passed
+			}
+		}
+	}
+	_, err := cfg.Repo.SaveEvaluationRecord(e)
+	return err
+}
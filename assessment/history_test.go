@@ -0,0 +1,84 @@
+package assessment
+
+import (
+	assessment_model "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/model"
+	"testing"
+	"time"
+)
+
+func TestSaveHistory(t *testing.T) {
+	now := time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)
+	point := func(value int, t time.Time) assessment_model.ExpressionData {
+		return assessment_model.ExpressionData{
+			"m": model.MetricValue{Key: "m", Value: value, DateTime: t},
+		}
+	}
+	values := assessment_model.GuaranteeData{
+		point(5, now.Add(-150*time.Minute)),
+		point(15, now.Add(-50*time.Minute)),
+		point(5, now.Add(-20*time.Minute)),
+	}
+	history := func(a *model.Agreement) []model.EvaluationRecord {
+		records, err := repo.GetEvaluationHistory(a.Id, "TestGuarantee", now.Add(-24*time.Hour), now)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return records
+	}
+
+	t.Run("NotDownsampled", func(t *testing.T) {
+		a := createAgreement("ahist01", p1, c2, "Agreement ahist01", "m >= 10")
+		a.State = model.STARTED
+		repo.CreateAgreement(&a)
+		defer repo.DeleteAgreement(&a)
+
+		cfg := Config{
+			Repo:    repo,
+			Adapter: simpleadapter.New(values),
+			Now:     now,
+		}
+		AssessSingleAgreement(cfg, &a)
+
+		records := history(&a)
+		if len(records) != 3 {
+			t.Fatalf("Expected 3 records. Actual: %v", records)
+		}
+		if e := records[1]; !e.Passed || e.Evaluated != 1 || e.Values["m"] != 15 {
+			t.Errorf("Unexpected record: %+v", e)
+		}
+		if e := records[2]; e.Passed || e.Failed != 1 {
+			t.Errorf("Unexpected record: %+v", e)
+		}
+	})
+
+	t.Run("Downsampled", func(t *testing.T) {
+		a := createAgreement("ahist02", p1, c2, "Agreement ahist02", "m >= 10")
+		a.State = model.STARTED
+		repo.CreateAgreement(&a)
+		defer repo.DeleteAgreement(&a)
+
+		cfg := Config{
+			Repo:              repo,
+			Adapter:           simpleadapter.New(values),
+			Now:               now,
+			HistoryResolution: time.Hour,
+			HistoryRetention:  2 * time.Hour,
+		}
+		AssessSingleAgreement(cfg, &a)
+		AssessSingleAgreement(cfg, &a)
+
+		records := history(&a)
+		if len(records) != 1 {
+			t.Fatalf("Expected 1 record. Actual: %v", records)
+		}
+		e := records[0]
+		if !e.Datetime.Equal(now.Add(-time.Hour)) || e.Passed || e.Evaluated != 4 || e.Failed != 2 {
+			t.Errorf("Unexpected record: %+v", e)
+		}
+		if e.Values["m"] != 5 {
+			t.Errorf("Expected values of the last point set. Actual: %v", e.Values)
+		}
+	})
+}
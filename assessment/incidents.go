@@ -0,0 +1,149 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assessment
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/events"
+	"SLALite/model"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+UpdateIncidents groups the violations found in an assessment result into incidents.
+
+The violations of each guarantee term (including the ones raised by the missing
+data policy) and its fulfilling point sets are traversed in time order:
+a violation opens an incident if there is no open incident for the
+guarantee term, or it is added to the open incident otherwise; a point set that
+fulfills the guarantee term closes the open incident, if any.
+
+Incidents are persisted in cfg.Repo, and an IncidentOpened or IncidentClosed event
+is published to cfg.Events every time an incident is opened or closed.
+*/
+func UpdateIncidents(cfg Config, a *model.Agreement, result amodel.Result) {
+	for _, gt := range a.Details.Guarantees {
+		steps := incidentSteps(result.Violated[gt.Name].Violations, result.Evaluated[gt.Name])
+		if len(steps) == 0 {
+			continue
+		}
+		if err := updateGtIncidents(cfg, a, gt, steps); err != nil {
+			log.Errorf("Error updating incidents of guarantee %s in agreement %s: %s",
+				gt.Name, a.Id, err.Error())
+		}
+	}
+}
+
+// incidentStep is a violation of a guarantee term or, if violation is nil, a
+// point set that fulfills it
+type incidentStep struct {
+	t         time.Time
+	violation *model.Violation
+}
+
+// incidentSteps returns the violations and the fulfilling point sets in time order
+func incidentSteps(violations []model.Violation, points []amodel.EvaluatedPoint) []incidentStep {
+	steps := make([]incidentStep, 0, len(violations)+len(points))
+	for i := range violations {
+		steps = append(steps, incidentStep{t: violations[i].Datetime, violation: &violations[i]})
+	}
+	for _, p := range points {
+		if !p.Failed {
+			steps = append(steps, incidentStep{t: p.Values.Datetime()})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].t.Before(steps[j].t) })
+	return steps
+}
+
+func updateGtIncidents(cfg Config, a *model.Agreement, gt model.Guarantee, steps []incidentStep) error {
+	open, err := getOpenIncident(cfg.Repo, a.Id, gt.Name)
+	if err != nil {
+		return err
+	}
+	modified := false
+
+	for _, step := range steps {
+		if step.violation != nil {
+			v := *step.violation
+			if open != nil {
+				open.AddViolation(v)
+				modified = true
+				continue
+			}
+			open = newIncident(a, gt)
+			open.AddViolation(v)
+			if open, err = cfg.Repo.CreateIncident(open); err != nil {
+				return err
+			}
+			modified = false
+			cfg.publish(events.NewIncidentEvent(events.IncidentOpened, a, copyIncident(open)))
+		} else if open != nil {
+			open.Close(step.t)
+			if open, err = cfg.Repo.UpdateIncident(open); err != nil {
+				return err
+			}
+			cfg.publish(events.NewIncidentEvent(events.IncidentClosed, a, copyIncident(open)))
+			open = nil
+			modified = false
+		}
+	}
+	if open != nil && modified {
+		_, err = cfg.Repo.UpdateIncident(open)
+	}
+	return err
+}
+
+func getOpenIncident(repo model.IRepository, agreementID string, gtname string) (*model.Incident, error) {
+	incidents, err := repo.GetIncidentsByAgreement(agreementID, model.OPEN)
+	if err != nil {
+		return nil, err
+	}
+	for i := range incidents {
+		if incidents[i].Guarantee == gtname {
+			return &incidents[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func newIncident(a *model.Agreement, gt model.Guarantee) *model.Incident {
+	return &model.Incident{
+		Id:          uuid.New().String(),
+		AgreementId: a.Id,
+		Guarantee:   gt.Name,
+		Constraint:  gt.Constraint,
+		State:       model.OPEN,
+	}
+}
+
+/*
+copyIncident returns a copy of the incident to be sent to listeners, so that
+they are not affected by further modifications
+*/
+func copyIncident(i *model.Incident) *model.Incident {
+	result := *i
+	result.Peak = make(map[string]model.IncidentPeak, len(i.Peak))
+	for k, v := range i.Peak {
+		result.Peak[k] = v
+	}
+	return &result
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assessment
+
+import (
+	assessment_model "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"fmt"
+	"testing"
+)
+
+func TestUpdateIncidents(t *testing.T) {
+	ai := createAgreement("ai01", p1, c2, "Agreement ai01", "m >= 0")
+	ai.State = model.STARTED
+	repo.CreateAgreement(&ai)
+
+	received := make([]events.Event, 0)
+	bus := events.NewBus()
+	bus.Subscribe(events.ListenerFunc(func(e events.Event) {
+		received = append(received, e)
+	}))
+	cfg := Config{
+		Repo:      repo,
+		Events:    bus,
+		Incidents: true,
+	}
+
+	values := assessment_model.GuaranteeData{
+		{"m": model.MetricValue{Key: "m", Value: -1, DateTime: t_(0)}},
+		{"m": model.MetricValue{Key: "m", Value: -2, DateTime: t_(1)}},
+		{"m": model.MetricValue{Key: "m", Value: 1, DateTime: t_(2)}},
+		{"m": model.MetricValue{Key: "m", Value: -3, DateTime: t_(3)}},
+	}
+	result, _ := EvaluateAgreement(&ai, simpleadapter.New(values), t_(3))
+	UpdateIncidents(cfg, &ai, result)
+
+	checkEventTypes(t, received, events.IncidentOpened, events.IncidentClosed, events.IncidentOpened)
+	closed := received[1].Incident
+	if closed.Count != 2 || closed.Start != t_(0) || *closed.End != t_(2) {
+		t.Errorf("Unexpected closed incident: %v", closed)
+	}
+	if peak := closed.Peak["m"]; peak.Min.Value != -2 || peak.Max.Value != -1 {
+		t.Errorf("Unexpected peak: %v", peak)
+	}
+
+	open, _ := repo.GetIncidentsByAgreement(ai.Id, model.OPEN)
+	if len(open) != 1 || open[0].Start != t_(3) {
+		t.Fatalf("Unexpected open incidents: %v", open)
+	}
+
+	/* the open incident continues in the next assessment, and then it is closed */
+	received = received[:0]
+	values = assessment_model.GuaranteeData{
+		{"m": model.MetricValue{Key: "m", Value: -4, DateTime: t_(4)}},
+		{"m": model.MetricValue{Key: "m", Value: 5, DateTime: t_(5)}},
+	}
+	result, _ = EvaluateAgreement(&ai, simpleadapter.New(values), t_(5))
+	UpdateIncidents(cfg, &ai, result)
+
+	checkEventTypes(t, received, events.IncidentClosed)
+	if closed := received[0].Incident; closed.Id != open[0].Id || closed.Count != 2 {
+		t.Errorf("Unexpected closed incident: %v", closed)
+	}
+	all, _ := repo.GetIncidentsByAgreement(ai.Id)
+	if len(all) != 2 {
+		t.Errorf("Expected 2 incidents. Actual: %v", all)
+	}
+}
+
+func checkEventTypes(t *testing.T, received []events.Event, expected ...events.Type) {
+	if len(received) != len(expected) {
+		t.Fatalf("Unexpected events. Expected: %v; Actual: %v", expected, received)
+	}
+	for i := range expected {
+		if received[i].Type != expected[i] {
+			t.Errorf("Unexpected event[%d]. Expected: %v; Actual: %v", i, expected[i], received[i].Type)
+		}
+	}
+}
+
+func TestUpdateIncidentsNoData(t *testing.T) {
+	ai := createAgreement("ai02", p1, c2, "Agreement ai02", "m >= 0")
+	ai.State = model.STARTED
+	ai.Details.Guarantees[0].OnMissingData = &model.MissingData{Policy: model.VIOLATE}
+	repo.CreateAgreement(&ai)
+
+	received := make([]events.Event, 0)
+	bus := events.NewBus()
+	bus.Subscribe(events.ListenerFunc(func(e events.Event) {
+		received = append(received, e)
+	}))
+	cfg := Config{Repo: repo, Events: bus, Incidents: true}
+
+	result, _ := EvaluateAgreement(&ai, simpleadapter.New(nil), t_(0))
+	UpdateIncidents(cfg, &ai, result)
+	checkEventTypes(t, received, events.IncidentOpened)
+
+	values := assessment_model.GuaranteeData{
+		{"m": model.MetricValue{Key: "m", Value: 1, DateTime: t_(1)}},
+	}
+	result, _ = EvaluateAgreement(&ai, simpleadapter.New(values), t_(1))
+	UpdateIncidents(cfg, &ai, result)
+	checkEventTypes(t, received, events.IncidentOpened, events.IncidentClosed)
+}
+
+type countingNotifier struct {
+	count int
+}
+
+func (n *countingNotifier) NotifyViolations(agreement *model.Agreement, result *assessment_model.Result) {
+	n.count++
+}
+
+type countingListener struct {
+	countingNotifier
+}
+
+func (n *countingListener) NotifyEvent(e events.Event) {}
+
+func TestAssessIncidentsNotifier(t *testing.T) {
+	values := assessment_model.GuaranteeData{
+		{"m": model.MetricValue{Key: "m", Value: -1, DateTime: t_(0)}},
+	}
+	plain := &countingNotifier{}
+	listener := &countingListener{}
+	for i, n := range []notifier.ViolationNotifier{plain, listener} {
+		ai := createAgreement(fmt.Sprintf("ai1%d", i), p1, c2, "Agreement", "m >= 0")
+		ai.State = model.STARTED
+		repo.CreateAgreement(&ai)
+		cfg := Config{Repo: repo, Adapter: simpleadapter.New(values), Notifier: n, Incidents: true, Now: t_(0)}
+		AssessSingleAgreement(cfg, &ai)
+	}
+	if plain.count != 1 {
+		t.Errorf("Expected violations notified to a notifier that is not a listener")
+	}
+	if listener.count != 0 {
+		t.Errorf("Expected violations not notified to a listener")
+	}
+}
@@ -29,19 +29,43 @@ type ExpressionData map[string]model.MetricValue
 // in time
 type GuaranteeData []ExpressionData
 
+// Datetime returns the time of a point set, i.e., the time of its newest value
+func (d ExpressionData) Datetime() time.Time {
+	var result time.Time
+	for _, m := range d {
+		if m.DateTime.After(result) {
+			result = m.DateTime
+		}
+	}
+	return result
+}
+
+// EvaluatedPoint is a point set of a guarantee term together with the
+// result of its evaluation
+type EvaluatedPoint struct {
+	Values ExpressionData `json:"values"`
+	Failed bool           `json:"failed"`
+	// Excluded is true if the point set failed the constraint in a maintenance
+	// window. Then, Failed is false.
+	Excluded bool `json:"excluded,omitempty"`
+}
+
 // EvaluationGtResult is the result of the evaluation of a guarantee term
 //
 // It contains the failed metrics and associated violations if any.
 type EvaluationGtResult struct {
-	Metrics    GuaranteeData     // violent metrics
-	Violations []model.Violation // violations occurred as of violated metrics
+	Metrics    GuaranteeData     `json:"metrics"`    // violent metrics
+	Violations []model.Violation `json:"violations"` // violations occurred as of violated metrics
 }
 
 // Result is the result of the agreement assessment
 type Result struct {
-	Violated      map[string]EvaluationGtResult // terms that were violated
-	LastValues    map[string]ExpressionData     // last value of variables in the term
-	LastExecution map[string]time.Time          // last execution of a guarantee
+	Violated      map[string]EvaluationGtResult `json:"violated,omitempty"`       // terms that were violated
+	LastValues    map[string]ExpressionData     `json:"last_values,omitempty"`    // last value of variables in the term
+	LastExecution map[string]time.Time          `json:"last_execution,omitempty"` // last execution of a guarantee
+	Evaluated     map[string][]EvaluatedPoint   `json:"evaluated,omitempty"`      // evaluated point sets of a guarantee, in time order
+	NoData        map[string]bool               `json:"no_data,omitempty"`        // terms that had no values to be evaluated
+	Errors        map[string]error              `json:"-"`                        // monitoring and calendar errors of a term (see Assessment.Guarantees)
 }
 
 // GetViolations return the violations contained in a Result
@@ -42,7 +42,10 @@ func TestDummyAdapter(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Invalid expression %s", gt.Constraint)
 	}
-	values := ma.GetValues(gt, exp.Vars(), time.Now())
+	values, err := ma.GetValues(gt, exp.Vars(), time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 	if values == nil {
 		t.Fatalf("GetValues(). Expected: []map[string]monitor.MetricValue. Actual: nil")
 	}
@@ -0,0 +1,212 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genericadapter
+
+/*
+This file contains the batch retrieval of the values of all the agreements of
+an assessment cycle (see monitor.EarlyRetriever).
+
+Many agreements (e.g. the ones created from the same template) query the same
+metrics over the same windows. The items of all the agreements are
+de-duplicated by query, and each query is retrieved once per cycle. A query
+is identified by the monitoring source and monitoring URL of the agreement, and
+the metric, aggregation and interval of the item. The queries of scoped
+retrievers (see RegisterScopedRetriever) also depend on the agreement, so
+they are never shared.
+*/
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"SLALite/registry"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// scoped contains the names of the retrievers registered with RegisterScopedRetriever
+var scoped = make(map[string]bool)
+
+// prefetched contains the values of an item retrieved in a batch
+type prefetched struct {
+	from   time.Time
+	to     time.Time
+	values []model.MetricValue
+	ok     bool
+	err    error
+}
+
+// prefetchKey identifies an item of an agreement
+type prefetchKey struct {
+	agreementID string
+	guarantee   string
+	variable    string
+}
+
+// query is a de-duplicated retrieval
+type query struct {
+	agreement *model.Agreement
+	item      monitor.RetrievalItem
+	values    []model.MetricValue
+	ok        bool
+	err       error
+}
+
+// queryGroup are queries retrieved together, with one query per variable at most
+type queryGroup struct {
+	agreement *model.Agreement
+	queries   []*query
+	vars      map[model.Variable]bool
+}
+
+// RegisterScopedRetriever registers a retriever whose values depend on the
+// agreement, and not only on the metric and the interval (e.g. the push
+// retriever). Its queries are not shared between agreements.
+func RegisterScopedRetriever(name string, f RetrieverFactory, schema ...registry.Property) {
+	RegisterRetriever(name, f, schema...)
+	scoped[name] = true
+}
+
+// IsScoped returns if a retriever was registered with RegisterScopedRetriever
+func IsScoped(name string) bool {
+	return scoped[name]
+}
+
+// RetrieveAllValues implements monitor.EarlyRetriever
+func (ga *Adapter) RetrieveAllValues(batch []monitor.AgreementItems) monitor.MonitoringAdapter {
+
+	queries := make(map[string]*query)
+	order := make([]string, 0)
+	keys := make(map[prefetchKey]string)
+	for _, ai := range batch {
+		for _, item := range ai.Items {
+			qk := ga.queryKey(ai.Agreement, item)
+			if _, ok := queries[qk]; !ok {
+				queries[qk] = &query{agreement: ai.Agreement, item: item}
+				order = append(order, qk)
+			}
+			keys[prefetchKey{ai.Agreement.Id, item.Guarantee.Name, item.Var.Name}] = qk
+		}
+	}
+
+	/*
+	 * retrieve the queries, grouped by the agreement that first contains them.
+	 * The values are returned by variable, so the queries of a variable with
+	 * different intervals (e.g. of two guarantee terms) go in different groups.
+	 */
+	groups := make([]*queryGroup, 0)
+	byAgreement := make(map[*model.Agreement][]*queryGroup)
+	for _, qk := range order {
+		q := queries[qk]
+		var g *queryGroup
+		for _, candidate := range byAgreement[q.agreement] {
+			if !candidate.vars[q.item.Var] {
+				g = candidate
+				break
+			}
+		}
+		if g == nil {
+			g = &queryGroup{agreement: q.agreement, vars: make(map[model.Variable]bool)}
+			groups = append(groups, g)
+			byAgreement[q.agreement] = append(byAgreement[q.agreement], g)
+		}
+		g.queries = append(g.queries, q)
+		g.vars[q.item.Var] = true
+	}
+	for _, g := range groups {
+		items := make([]monitor.RetrievalItem, 0, len(g.queries))
+		for _, q := range g.queries {
+			items = append(items, q.item)
+		}
+		retrieved, err := ga.Retrieve(*g.agreement, items)
+		for _, q := range g.queries {
+			q.values, q.ok = retrieved[q.item.Var]
+			if !q.ok {
+				/* the error is of the items that were not retrieved */
+				q.err = err
+			}
+		}
+	}
+
+	result := *ga
+	result.prefetched = make(map[prefetchKey]prefetched, len(keys))
+	for pk, qk := range keys {
+		q := queries[qk]
+		result.prefetched[pk] = prefetched{
+			from:   q.item.From,
+			to:     q.item.To,
+			values: rekey(q.values, q.item.Var.Name, pk.variable),
+			ok:     q.ok,
+			err:    q.err,
+		}
+	}
+	return &result
+}
+
+// queryKey returns the identifier of the query of an item
+func (ga *Adapter) queryKey(a *model.Agreement, item monitor.RetrievalItem) string {
+	source := SourceOf(*a, item.Var)
+	scope := ""
+	if ga.scoped[source] {
+		scope = a.Id
+	}
+	aggregation := model.Aggregation{}
+	if item.Var.Aggregation != nil {
+		aggregation = *item.Var.Aggregation
+	}
+	return fmt.Sprintf("%s|%s|%s|%q|%s|%d|%d|%d",
+		scope, source, a.Assessment.MonitoringURL, item.Var.Metric,
+		aggregation.Type, aggregation.Window, item.From.UnixNano(), item.To.UnixNano())
+}
+
+// retrievePrefetched returns the values of the items that were retrieved in a
+// batch, the items that were not, and the errors of the batch retrieval.
+func (ga *Adapter) retrievePrefetched(a *model.Agreement,
+	items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, []monitor.RetrievalItem, Errors) {
+
+	result := make(map[model.Variable][]model.MetricValue)
+	pending := make([]monitor.RetrievalItem, 0)
+	errs := Errors{}
+	for _, item := range items {
+		p, ok := ga.prefetched[prefetchKey{a.Id, item.Guarantee.Name, item.Var.Name}]
+		if !ok || !p.from.Equal(item.From) || !p.to.Equal(item.To) {
+			pending = append(pending, item)
+			continue
+		}
+		if p.ok {
+			result[item.Var] = p.values
+		} else if p.err != nil {
+			errs = append(errs, p.err)
+		}
+	}
+	return result, pending, errs
+}
+
+// rekey returns a copy of values with the variable name in the keys changed from one name to other
+func rekey(values []model.MetricValue, from, to string) []model.MetricValue {
+	if values == nil || from == to {
+		return values
+	}
+	result := make([]model.MetricValue, 0, len(values))
+	for _, v := range values {
+		if strings.HasPrefix(v.Key, from) {
+			v.Key = to + v.Key[len(from):]
+		}
+		result = append(result, v)
+	}
+	return result
+}
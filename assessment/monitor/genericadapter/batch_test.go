@@ -0,0 +1,183 @@
+package genericadapter
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"errors"
+	"testing"
+	"time"
+)
+
+/* countingRetriever wraps constRetriever, counting the retrieved items */
+func countingRetriever(count *int) Retrieve {
+	retrieve := constRetriever(1)
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		*count += len(items)
+		return retrieve(agreement, items)
+	}
+}
+
+func batchAgreement(id string, varname string, metric string) *model.Agreement {
+	return &model.Agreement{
+		Id: id,
+		Details: model.Details{
+			Variables: []model.Variable{{Name: varname, Metric: metric}},
+			Guarantees: []model.Guarantee{
+				{Name: "gt", Constraint: varname + " > 0"},
+			},
+		},
+	}
+}
+
+func batchItems(a *model.Agreement, from, to time.Time) monitor.AgreementItems {
+	v := a.Details.Variables[0]
+	return monitor.AgreementItems{
+		Agreement: a,
+		Items: []monitor.RetrievalItem{
+			{Guarantee: a.Details.Guarantees[0], Var: v, From: from, To: to},
+		},
+	}
+}
+
+func TestRetrieveAllValues(t *testing.T) {
+	t0 := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	a1 := batchAgreement("a1", "m", "metric")
+	a2 := batchAgreement("a2", "other", "metric")
+	a3 := batchAgreement("a3", "m", "metric")
+	a3.Assessment.MonitoringURL = "http://other"
+	a4 := batchAgreement("a4", "m", "metric")
+
+	count := 0
+	ga := &Adapter{Retrieve: countingRetriever(&count), Process: Identity}
+	ma := ga.RetrieveAllValues([]monitor.AgreementItems{
+		batchItems(a1, t0, t1),
+		batchItems(a2, t0, t1),
+		batchItems(a3, t0, t1),
+		batchItems(a4, t0.Add(time.Second), t1),
+	})
+	/* a1 and a2 share the query */
+	if count != 3 {
+		t.Errorf("Expected 3 retrieved items. Actual: %d", count)
+	}
+
+	values, pending, _ := ma.(*Adapter).retrievePrefetched(a2, batchItems(a2, t0, t1).Items)
+	v := a2.Details.Variables[0]
+	if len(pending) != 0 || len(values[v]) != 1 || values[v][0].Key != "other" {
+		t.Errorf("Unexpected prefetched values: %v. Pending: %v", values, pending)
+	}
+
+	_, pending, _ = ma.(*Adapter).retrievePrefetched(a2, batchItems(a2, t0, t1.Add(time.Second)).Items)
+	if len(pending) != 1 {
+		t.Errorf("Items with other interval must be pending: %v", pending)
+	}
+}
+
+/* fromRetriever returns a value stamped at the start of the interval of each item */
+func fromRetriever(agreement model.Agreement,
+	items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+	result := make(map[model.Variable][]model.MetricValue)
+	for _, item := range items {
+		result[item.Var] = []model.MetricValue{{Key: item.Var.Name, Value: 1, DateTime: item.From}}
+	}
+	return result, nil
+}
+
+func TestRetrieveAllValuesSharedVariable(t *testing.T) {
+	t0 := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	a := batchAgreement("a1", "m", "metric")
+	a.Details.Guarantees = append(a.Details.Guarantees, model.Guarantee{Name: "gt2", Constraint: "m > 1"})
+	v := a.Details.Variables[0]
+
+	/* the terms were last evaluated at different times */
+	from1, from2 := t0, t0.Add(-time.Hour)
+	ga := &Adapter{Retrieve: fromRetriever, Process: Identity}
+	ma := ga.RetrieveAllValues([]monitor.AgreementItems{{
+		Agreement: a,
+		Items: []monitor.RetrievalItem{
+			{Guarantee: a.Details.Guarantees[0], Var: v, From: from1, To: t1},
+			{Guarantee: a.Details.Guarantees[1], Var: v, From: from2, To: t1},
+		},
+	}})
+
+	for _, item := range []monitor.RetrievalItem{
+		{Guarantee: a.Details.Guarantees[0], Var: v, From: from1, To: t1},
+		{Guarantee: a.Details.Guarantees[1], Var: v, From: from2, To: t1},
+	} {
+		values, pending, _ := ma.(*Adapter).retrievePrefetched(a, []monitor.RetrievalItem{item})
+		if len(pending) != 0 || len(values[v]) != 1 || !values[v][0].DateTime.Equal(item.From) {
+			t.Errorf("Unexpected prefetched values of %s: %v. Pending: %v", item.Guarantee.Name, values, pending)
+		}
+	}
+}
+
+func TestRetrieveAllValuesScoped(t *testing.T) {
+	t0 := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	a1 := batchAgreement("a1", "m", "metric")
+	a2 := batchAgreement("a2", "m", "metric")
+
+	count := 0
+	ga := &Adapter{Retrieve: countingRetriever(&count), Process: Identity, scoped: map[string]bool{"": true}}
+	ga.RetrieveAllValues([]monitor.AgreementItems{
+		batchItems(a1, t0, t1),
+		batchItems(a2, t0, t1),
+	})
+	if count != 2 {
+		t.Errorf("Expected 2 retrieved items. Actual: %d", count)
+	}
+}
+
+func TestGetValuesPrefetched(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	a1 := batchAgreement("a1", "m", "metric")
+	a1.Details.Creation = now.Add(-time.Minute)
+
+	count := 0
+	ga := &Adapter{Retrieve: countingRetriever(&count), Process: Identity}
+	ma := ga.RetrieveAllValues([]monitor.AgreementItems{
+		batchItems(a1, a1.Details.Creation, now),
+	})
+
+	ma = ma.Initialize(a1)
+	data, err := ma.GetValues(a1.Details.Guarantees[0], []string{"m"}, now)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Values must not be retrieved again. Retrieved items: %d", count)
+	}
+	if len(data) != 1 || data[0]["m"].Value != 1.0 {
+		t.Errorf("Unexpected values: %v", data)
+	}
+
+	ma.GetValues(a1.Details.Guarantees[0], []string{"m"}, now.Add(time.Minute))
+	if count != 2 {
+		t.Errorf("Values of other interval must be retrieved. Retrieved items: %d", count)
+	}
+}
+
+func TestGetValuesPrefetchedError(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	a1 := batchAgreement("a1", "m", "metric")
+	a1.Details.Creation = now.Add(-time.Minute)
+
+	failing := func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		return map[model.Variable][]model.MetricValue{}, errors.New("unavailable")
+	}
+	ga := &Adapter{Retrieve: failing, Process: Identity}
+	ma := ga.RetrieveAllValues([]monitor.AgreementItems{
+		batchItems(a1, a1.Details.Creation, now),
+	})
+
+	ma = ma.Initialize(a1)
+	if _, err := ma.GetValues(a1.Details.Guarantees[0], []string{"m"}, now); err == nil {
+		t.Errorf("Expected error of prefetched values")
+	}
+}
@@ -35,7 +35,10 @@ import (
 	"SLALite/assessment/monitor"
 	"SLALite/model"
 	"math/rand"
+	"strings"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
 /*
@@ -48,18 +51,45 @@ on data.
 Two Process functions are provided in the package:
 Identity (returns the input) and Aggregation (aggregates values according
 to the aggregation type)
+
+The optional Check field is a function to check the connectivity with the
+monitoring (see Ping).
 */
 type Adapter struct {
-	Retrieve  Retrieve
-	Process   Process
-	agreement *model.Agreement
+	Retrieve   Retrieve
+	Process    Process
+	Check      func() error
+	agreement  *model.Agreement
+	scoped     map[string]bool
+	prefetched map[prefetchKey]prefetched
 }
 
 // Retrieve is the type of the function that makes the actual request to monitoring.
 //
 // It receives the list of variables to be able to retrieve all of them at once if possible.
+// If some items cannot be retrieved, it returns the values of the rest of items and an error
+// (usually, an Errors). The items that could not be retrieved are not in the result.
 type Retrieve func(agreement model.Agreement,
-	items []monitor.RetrievalItem) map[model.Variable][]model.MetricValue
+	items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error)
+
+// Errors is the list of errors of the items that could not be retrieved
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Err returns nil if there are no errors, or the list of errors otherwise
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
 
 // Process is the type of the function that performs additional custom processing on
 // retrieved data.
@@ -96,23 +126,50 @@ func (ga *Adapter) Initialize(a *model.Agreement) monitor.MonitoringAdapter {
 	return &result
 }
 
+// Ping implements monitor.Pinger. It returns nil if there is no Check function.
+func (ga *Adapter) Ping() error {
+	if ga.Check == nil {
+		return nil
+	}
+	return ga.Check()
+}
+
 // GetValues implements Monitoring.GetValues().
+//
+// If some values cannot be retrieved, it returns the rest of values and the
+// retrieval error.
 func (ga *Adapter) GetValues(gt model.Guarantee,
 	varnames []string,
-	now time.Time) amodel.GuaranteeData {
+	now time.Time) (amodel.GuaranteeData, error) {
 
 	a := ga.agreement
 
 	items := assessment.BuildRetrievalItems(a, gt, varnames, now)
-	unprocessed := ga.Retrieve(*a, items)
+	unprocessed, pending, errs := ga.retrievePrefetched(a, items)
+	if len(pending) > 0 {
+		retrieved, err := ga.Retrieve(*a, pending)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for v, values := range retrieved {
+			unprocessed[v] = values
+		}
+	}
 
 	/* process each of the series*/
 	valuesmap := map[model.Variable][]model.MetricValue{}
 	for v := range unprocessed {
 		valuesmap[v] = ga.Process(v, unprocessed[v])
 	}
-	result := Mount(valuesmap, lastvalues(a, gt), 0.1)
-	return result
+	result := MountWith(valuesmap, lastvalues(a, gt), interpolation(a))
+	return result, errs.Err()
+}
+
+func interpolation(a *model.Agreement) model.Interpolation {
+	if a.Assessment.Interpolation == nil {
+		return model.Interpolation{}
+	}
+	return *a.Assessment.Interpolation
 }
 
 func lastvalues(a *model.Agreement, gt model.Guarantee) model.LastValues {
@@ -130,6 +187,20 @@ func lastvalues(a *model.Agreement, gt model.Guarantee) model.LastValues {
 	return ag.LastValues
 }
 
+const (
+	// DummyName is the unique identifier of the adapter and the retriever built with a DummyRetriever
+	DummyName = "dummy"
+)
+
+func init() {
+	RegisterRetriever(DummyName, func(config *viper.Viper) Retrieve {
+		return DummyRetriever{Size: 3}.Retrieve()
+	})
+	monitor.Register(DummyName, func(config *viper.Viper) (monitor.MonitoringAdapter, error) {
+		return NewWithSources(config, DummyName, DummyRetriever{Size: 3}.Retrieve(), Identity)
+	})
+}
+
 // DummyRetriever is a simple struct that generates a RetrieveFunction that works similar
 // to the DummyAdapter, returning random values for each variable.
 //
@@ -144,7 +215,7 @@ type DummyRetriever struct {
 func (r DummyRetriever) Retrieve() Retrieve {
 
 	return func(agreement model.Agreement,
-		items []monitor.RetrievalItem) map[model.Variable][]model.MetricValue {
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
 
 		result := map[model.Variable][]model.MetricValue{}
 		for _, item := range items {
@@ -163,7 +234,7 @@ func (r DummyRetriever) Retrieve() Retrieve {
 				result[v] = append(result[v], m)
 			}
 		}
-		return result
+		return result, nil
 	}
 }
 
@@ -35,8 +35,8 @@ type mountCtx struct {
 	lens map[model.Variable]int
 	// maxlen contains the maximum length
 	maxlen int
-	// maxdelta is maximum time allowed for metrics to be considered in the same pointset
-	maxdelta float64
+	// interpolation contains the interpolation of each variable
+	interpolation map[model.Variable]model.Interpolation
 	// sumlens is the sum of the series lengths in values
 	sumlens int
 }
@@ -44,6 +44,9 @@ type mountCtx struct {
 // Be careful if you run the code here after 73069258126-09-25!
 var _INF = time.Unix(1<<61, 0)
 
+// DefaultTolerance is the default maximum time in seconds between values of the same point set
+const DefaultTolerance = 0.1
+
 /*
 Mount builds the GuaranteeData structure, directly used for agreement assessment,
 considering constant interpolation.
@@ -83,7 +86,32 @@ func Mount(valuesmap map[model.Variable][]model.MetricValue,
 	lastvalues map[string]model.MetricValue,
 	maxdelta float64) amodel.GuaranteeData {
 
-	ctx := initCtx(valuesmap, lastvalues, maxdelta)
+	return MountWith(valuesmap, lastvalues, model.Interpolation{Method: model.CONSTANT, Tolerance: maxdelta})
+}
+
+/*
+MountWith builds the GuaranteeData structure like Mount, with the interpolation
+of each variable (see model.Interpolation). The interpolation of a variable is
+its Interpolation field, with the unset fields taken from def.
+
+The methods differ in the value of a variable without a value in the tolerance
+of a point set:
+
+  - constant: the last known value (as in Mount).
+  - linear: the value at the point set time of the line between the last
+    known value and the next value. If there is no next value or the values
+    are not numbers, the last known value.
+  - strict: the point set is discarded. The values must have exactly the
+    same time; the tolerance is not used.
+
+In constant and linear methods, if the last known value is older than the staleness,
+the point set is discarded.
+*/
+func MountWith(valuesmap map[model.Variable][]model.MetricValue,
+	lastvalues map[string]model.MetricValue,
+	def model.Interpolation) amodel.GuaranteeData {
+
+	ctx := initCtx(valuesmap, lastvalues, def)
 
 	result := make(amodel.GuaranteeData, 0, ctx.maxlen)
 
@@ -109,19 +137,21 @@ func Mount(valuesmap map[model.Variable][]model.MetricValue,
 
 func initCtx(valuesmap map[model.Variable][]model.MetricValue,
 	lastvalues map[string]model.MetricValue,
-	maxdelta float64) mountCtx {
+	def model.Interpolation) mountCtx {
 
 	if lastvalues == nil {
 		lastvalues = model.LastValues{}
 	}
 	index := make(map[model.Variable]int)
 	lens := make(map[model.Variable]int)
+	interpolation := make(map[model.Variable]model.Interpolation)
 	max := 0
 	sum := 0
 
 	for v := range valuesmap {
 		// fill index
 		index[v] = 0
+		interpolation[v] = resolveInterpolation(def, v.Interpolation)
 
 		// lens and calculate maximum length
 		l := len(valuesmap[v])
@@ -132,13 +162,13 @@ func initCtx(valuesmap map[model.Variable][]model.MetricValue,
 		sum += l
 	}
 	ctx := mountCtx{
-		values:   valuesmap,
-		last:     lastvalues,
-		index:    index,
-		lens:     lens,
-		maxlen:   max,
-		maxdelta: maxdelta,
-		sumlens:  sum,
+		values:        valuesmap,
+		last:          lastvalues,
+		index:         index,
+		lens:          lens,
+		maxlen:        max,
+		interpolation: interpolation,
+		sumlens:       sum,
 	}
 	return ctx
 }
@@ -171,20 +201,99 @@ func (ctx *mountCtx) buildNextPointSet(nextp model.MetricValue) (amodel.Expressi
 	var discard = false
 
 	for v := range ctx.values {
+		ip := ctx.interpolation[v]
 		value := ctx.getCurrentValue(v)
-		if deltaTimes(nextp, value) <= ctx.maxdelta {
+		last, known := ctx.last[v.Name]
+		switch {
+		case inPointSet(ip, nextp, value):
 			data[v.Name] = value
 			ctx.index[v]++
 			ctx.last[v.Name] = value
-		} else if _, ok := ctx.last[v.Name]; !ok {
+		case ip.Method == model.STRICT || !known || isStale(ip, last, nextp.DateTime):
 			discard = true
-		} else {
-			data[v.Name] = ctx.last[v.Name]
+		case ip.Method == model.LINEAR:
+			data[v.Name] = interpolate(last, value, nextp.DateTime)
+		default:
+			data[v.Name] = last
 		}
 	}
 	return data, !discard
 }
 
+// resolveInterpolation returns the interpolation of a variable, with the unset values
+// taken from def and, if unset in def, from the defaults.
+func resolveInterpolation(def model.Interpolation, v *model.Interpolation) model.Interpolation {
+	result := def
+	if v != nil {
+		if v.Method != "" {
+			result.Method = v.Method
+		}
+		if v.Tolerance != 0 {
+			result.Tolerance = v.Tolerance
+		}
+		if v.Staleness != 0 {
+			result.Staleness = v.Staleness
+		}
+	}
+	if result.Method == "" {
+		result.Method = model.CONSTANT
+	}
+	if result.Tolerance == 0 {
+		result.Tolerance = DefaultTolerance
+	}
+	return result
+}
+
+// inPointSet returns if a value belongs to the point set of the point p
+func inPointSet(ip model.Interpolation, p model.MetricValue, value model.MetricValue) bool {
+	if ip.Method == model.STRICT {
+		return value.DateTime.Equal(p.DateTime)
+	}
+	return deltaTimes(p, value) <= ip.Tolerance
+}
+
+// isStale returns if a last known value is too old to be used at time t
+func isStale(ip model.Interpolation, last model.MetricValue, t time.Time) bool {
+	return ip.Staleness > 0 && t.Sub(last.DateTime).Seconds() > ip.Staleness
+}
+
+/*
+interpolate returns the value at time t of the line between prev and next.
+
+If next is in the infinite future (i.e., there are no more values) or the
+values are not numbers, prev is returned.
+*/
+func interpolate(prev model.MetricValue, next model.MetricValue, t time.Time) model.MetricValue {
+	if !next.DateTime.Before(_INF) || !next.DateTime.After(prev.DateTime) {
+		return prev
+	}
+	v0, ok0 := toFloat(prev.Value)
+	v1, ok1 := toFloat(next.Value)
+	if !ok0 || !ok1 {
+		return prev
+	}
+	ratio := t.Sub(prev.DateTime).Seconds() / next.DateTime.Sub(prev.DateTime).Seconds()
+	return model.MetricValue{
+		Key:      prev.Key,
+		Value:    v0 + (v1-v0)*ratio,
+		DateTime: t,
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
 /*
 getCurrentValue returns the next value of a variable according to the
 index.
@@ -60,7 +60,7 @@ func TestFindNextPoint(t *testing.T) {
 
 	valuesmap := map[model.Variable][]model.MetricValue{v1: v1V, v2: v2V, v3: v3V}
 	lastvalues := map[string]model.MetricValue{}
-	ctx := initCtx(valuesmap, lastvalues, 0.2)
+	ctx := initCtx(valuesmap, lastvalues, model.Interpolation{Tolerance: 0.2})
 
 	p := ctx.findNextPoint()
 	if p != v1V[0] {
@@ -88,7 +88,7 @@ func TestBuildNextPointSet(t *testing.T) {
 
 	valuesmap := map[model.Variable][]model.MetricValue{v1: v1V, v2: v2V, v3: v3V}
 	lastvalues := map[string]model.MetricValue{}
-	ctx := initCtx(valuesmap, lastvalues, 0.2)
+	ctx := initCtx(valuesmap, lastvalues, model.Interpolation{Tolerance: 0.2})
 
 	p := ctx.findNextPoint()
 	data, ok := ctx.buildNextPointSet(p)
@@ -182,6 +182,76 @@ func TestUnsortedSeries(t *testing.T) {
 	fmt.Printf("%#v", pointsets)
 }
 
+func TestMountWith(t *testing.T) {
+	a := newVar("a")
+	b := newVar("b")
+	aV := newValues(a.Name, t0, []m{{0, 0}, {10, 10}})
+	bV := newValues(b.Name, t0, []m{{0, 5}, {5.05, 5}})
+	valuesmap := map[model.Variable][]model.MetricValue{a: aV, b: bV}
+
+	cases := []struct {
+		name     string
+		ip       model.Interpolation
+		expected int
+	}{
+		{"constant", model.Interpolation{}, 3},
+		{"strict", model.Interpolation{Method: model.STRICT}, 1},
+		{"staleness", model.Interpolation{Method: model.CONSTANT, Staleness: 3}, 1},
+	}
+	for _, c := range cases {
+		pointsets := MountWith(valuesmap, map[string]model.MetricValue{}, c.ip)
+		if len(pointsets) != c.expected {
+			t.Errorf("%s: Unexpected number of pointsets. Expected: %d; Actual: %d",
+				c.name, c.expected, len(pointsets))
+		}
+	}
+
+	pointsets := MountWith(valuesmap, map[string]model.MetricValue{}, model.Interpolation{Method: model.LINEAR})
+	if len(pointsets) != 3 {
+		t.Fatalf("linear: Unexpected number of pointsets. Expected: %d; Actual: %d", 3, len(pointsets))
+	}
+	if v := pointsets[1][a.Name]; v.Value != 5.05 || !v.DateTime.Equal(bV[1].DateTime) {
+		t.Errorf("linear: Unexpected interpolated value: %v", v)
+	}
+	if v := pointsets[2][b.Name]; v != bV[1] {
+		t.Errorf("linear: Unexpected value without next value: %v", v)
+	}
+}
+
+func TestMountWithVariableTolerance(t *testing.T) {
+	a := newVar("a")
+	b := newVar("b")
+	aV := newValues(a.Name, t0, []m{{0, 0}, {10, 10}})
+	bV := newValues(b.Name, t0, []m{{0.5, 5}, {10.5, 5}})
+
+	pointsets := MountWith(map[model.Variable][]model.MetricValue{a: aV, b: bV},
+		map[string]model.MetricValue{}, model.Interpolation{})
+	if len(pointsets) != 3 {
+		t.Errorf("Unexpected number of pointsets. Expected: %d; Actual: %d", 3, len(pointsets))
+	}
+
+	b.Interpolation = &model.Interpolation{Tolerance: 1}
+	pointsets = MountWith(map[model.Variable][]model.MetricValue{a: aV, b: bV},
+		map[string]model.MetricValue{}, model.Interpolation{})
+	if len(pointsets) != 2 {
+		t.Errorf("Unexpected number of pointsets. Expected: %d; Actual: %d", 2, len(pointsets))
+	}
+}
+
+func TestResolveInterpolation(t *testing.T) {
+	def := model.Interpolation{Method: model.LINEAR, Staleness: 60}
+	ip := resolveInterpolation(def, &model.Interpolation{Tolerance: 2})
+	expected := model.Interpolation{Method: model.LINEAR, Tolerance: 2, Staleness: 60}
+	if ip != expected {
+		t.Errorf("Expected: %v. Actual: %v", expected, ip)
+	}
+	ip = resolveInterpolation(model.Interpolation{}, nil)
+	expected = model.Interpolation{Method: model.CONSTANT, Tolerance: DefaultTolerance}
+	if ip != expected {
+		t.Errorf("Expected: %v. Actual: %v", expected, ip)
+	}
+}
+
 func assertPointSet(t *testing.T, data amodel.ExpressionData, m1, m2, m3 model.MetricValue) bool {
 	if data[m1.Key] != m1 || data[m2.Key] != m2 || data[m3.Key] != m3 {
 		if data[m1.Key] != m1 {
@@ -0,0 +1,187 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genericadapter
+
+/*
+This file contains the selection of the monitoring source of each variable.
+
+Besides the default retriever of the adapter, a set of named sources may be
+defined in the `sources` setting. Each source has a `type` (the name of a
+registered retriever) and the settings of that retriever:
+
+	sources:
+	  prometheus-a:
+	    type: prometheus
+	    prometheusUrl: http://prometheus-a:9090
+	  lab:
+	    type: static
+	    staticPath: /etc/slalite/lab.json
+
+A variable is retrieved from the source in Variable.Source or, if empty, from
+the source in the Assessment.MonitoringSource of its agreement. If both are
+empty, the default retriever is used.
+*/
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/telemetry"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// SourcesPropertyName is the config property name of the monitoring sources
+	SourcesPropertyName = "sources"
+
+	// SourceTypePropertyName is the config property name of the retriever type of a source
+	SourceTypePropertyName = "type"
+)
+
+// RetrieverFactory builds a Retrieve function from a configuration
+type RetrieverFactory func(config *viper.Viper) Retrieve
+
+// RegisterRetriever registers a retriever factory with the name used in the `type` of a source
+func RegisterRetriever(name string, f RetrieverFactory, schema ...registry.Property) {
+	registry.Register(registry.RetrieverKind, name, f, schema...)
+}
+
+// NewRetriever builds the Retrieve function registered with a name
+func NewRetriever(name string, config *viper.Viper) (Retrieve, error) {
+	f, err := registry.Lookup(registry.RetrieverKind, name, config)
+	if err != nil {
+		return nil, err
+	}
+	return f.(RetrieverFactory)(config), nil
+}
+
+// NewSources builds the Retrieve functions of the sources defined in config
+func NewSources(config *viper.Viper) (map[string]Retrieve, error) {
+	result := make(map[string]Retrieve)
+	for name := range config.GetStringMap(SourcesPropertyName) {
+		sub := config.Sub(SourcesPropertyName + "." + name)
+		if sub == nil {
+			return nil, fmt.Errorf("source '%s' has no settings", name)
+		}
+		retrieve, err := NewRetriever(sub.GetString(SourceTypePropertyName), sub)
+		if err != nil {
+			return nil, fmt.Errorf("source '%s': %s", name, err.Error())
+		}
+		log.Infof("Monitoring source %s of type %s", name, sub.GetString(SourceTypePropertyName))
+		result[name] = instrument(sub.GetString(SourceTypePropertyName), retrieve)
+	}
+	return result, nil
+}
+
+// NewWithSources builds an Adapter that retrieves each variable from its source
+// (see Dispatch), with the sources defined in config. name is the retriever name of
+// def, the default source.
+func NewWithSources(config *viper.Viper, name string, def Retrieve, process Process) (monitor.MonitoringAdapter, error) {
+	sources, err := NewSources(config)
+	if err != nil {
+		return nil, err
+	}
+	scoped := map[string]bool{"": IsScoped(name)}
+	for source := range sources {
+		scoped[source] = IsScoped(config.GetString(SourcesPropertyName + "." + source + "." + SourceTypePropertyName))
+	}
+	retrieve := instrument(name, def)
+	if len(sources) > 0 {
+		retrieve = Dispatch(sources, retrieve)
+	}
+	return &Adapter{
+		Retrieve: retrieve,
+		Process:  process,
+		scoped:   scoped,
+	}, nil
+}
+
+// instrument returns a Retrieve function that records the duration and the
+// errors of retrieve in the metrics of SLALite, labelled with the retriever name
+func instrument(name string, retrieve Retrieve) Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		start := time.Now()
+		result, err := retrieve(agreement, items)
+		telemetry.RetrieverDuration.ObserveSince(start, name)
+		if err != nil {
+			telemetry.RetrieverErrors.Inc(name)
+		}
+		return result, err
+	}
+}
+
+// SourceOf returns the name of the monitoring source of a variable of an agreement.
+// An empty string means the default source.
+func SourceOf(agreement model.Agreement, v model.Variable) string {
+	if v.Source != "" {
+		return v.Source
+	}
+	return agreement.Assessment.MonitoringSource
+}
+
+/*
+Dispatch returns a Retrieve function that groups the items by source (see
+SourceOf) and calls the Retrieve function of each source with its items.
+
+Items with an empty source are sent to def. Items of an unknown source are
+not retrieved, and an error is returned.
+*/
+func Dispatch(sources map[string]Retrieve, def Retrieve) Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		groups := make(map[string][]monitor.RetrievalItem)
+		order := make([]string, 0)
+		for _, item := range items {
+			name := SourceOf(agreement, item.Var)
+			if _, ok := groups[name]; !ok {
+				order = append(order, name)
+			}
+			groups[name] = append(groups[name], item)
+		}
+
+		result := make(map[model.Variable][]model.MetricValue)
+		errs := Errors{}
+		for _, name := range order {
+			retrieve := def
+			if name != "" {
+				var ok bool
+				if retrieve, ok = sources[name]; !ok {
+					log.Errorf("Unknown monitoring source '%s' in agreement %s", name, agreement.Id)
+					errs = append(errs, fmt.Errorf("unknown monitoring source '%s'", name))
+					continue
+				}
+			}
+			retrieved, err := retrieve(agreement, groups[name])
+			if err != nil {
+				errs = append(errs, err)
+			}
+			for v, values := range retrieved {
+				result[v] = values
+			}
+		}
+		return result, errs.Err()
+	}
+}
@@ -0,0 +1,90 @@
+package genericadapter
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+/* constRetriever returns one value with the given value for every item */
+func constRetriever(value float64) Retrieve {
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		result := make(map[model.Variable][]model.MetricValue)
+		for _, item := range items {
+			result[item.Var] = []model.MetricValue{
+				{Key: item.Var.Name, Value: value, DateTime: item.To},
+			}
+		}
+		return result, nil
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	retrieve := Dispatch(map[string]Retrieve{
+		"a": constRetriever(1),
+		"b": constRetriever(2),
+	}, constRetriever(0))
+
+	vdef := model.Variable{Name: "def", Metric: "def"}
+	va := model.Variable{Name: "va", Metric: "va", Source: "a"}
+	vb := model.Variable{Name: "vb", Metric: "vb", Source: "b"}
+	vc := model.Variable{Name: "vc", Metric: "vc", Source: "c"}
+	items := []monitor.RetrievalItem{
+		{Var: vdef, To: time.Now()},
+		{Var: va, To: time.Now()},
+		{Var: vb, To: time.Now()},
+		{Var: vc, To: time.Now()},
+	}
+
+	result, err := retrieve(model.Agreement{}, items)
+	if err == nil || !strings.Contains(err.Error(), "'c'") {
+		t.Errorf("Expected error of unknown source. Actual: %v", err)
+	}
+	expected := map[model.Variable]float64{vdef: 0, va: 1, vb: 2}
+	for v, value := range expected {
+		if values := result[v]; len(values) != 1 || values[0].Value != value {
+			t.Errorf("Unexpected values of %s: %v", v.Name, values)
+		}
+	}
+	if _, ok := result[vc]; ok {
+		t.Errorf("Not expected values of unknown source")
+	}
+
+	/* the agreement source replaces the default source */
+	a := model.Agreement{Assessment: model.Assessment{MonitoringSource: "b"}}
+	result, _ = retrieve(a, items)
+	if values := result[vdef]; len(values) != 1 || values[0].Value != 2.0 {
+		t.Errorf("Unexpected values of agreement source: %v", values)
+	}
+	if values := result[va]; len(values) != 1 || values[0].Value != 1.0 {
+		t.Errorf("Variable source must have precedence: %v", values)
+	}
+}
+
+func TestNewSources(t *testing.T) {
+	config := viper.New()
+	config.Set(SourcesPropertyName, map[string]interface{}{
+		"lab": map[string]interface{}{
+			SourceTypePropertyName: DummyName,
+		},
+	})
+	sources, err := NewSources(config)
+	if err != nil || len(sources) != 1 || sources["lab"] == nil {
+		t.Errorf("Unexpected sources: %v. err=%v", sources, err)
+	}
+
+	config.Set(SourcesPropertyName, map[string]interface{}{
+		"lab": map[string]interface{}{
+			SourceTypePropertyName: "notexists",
+		},
+	})
+	if _, err := NewSources(config); err == nil || !strings.Contains(err.Error(), "lab") {
+		t.Errorf("Unexpected error on unknown type: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package graphite contains a retriever that gets the values of the variables
+from the Graphite render API (/render?format=json).
+
+The metric of a variable is a Graphite target (e.g. "servers.web01.cpu.idle"
+or a function like "averageSeries(servers.*.cpu.idle)").
+
+The values are queried in the (From, To] interval of the retrieval item. If the
+variable has an average aggregation, the target is summarized by Graphite over
+the interval and returned as one value at To. If the target returns several
+series, the key of each value is "<variable>{<series target>}".
+*/
+package graphite
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/assessment/monitor/genericadapter"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this retriever
+	Name = "graphite"
+
+	// URLPropertyName is the config property name of the Graphite URL
+	URLPropertyName = "graphiteUrl"
+
+	// UserPropertyName is the config property name of the user of basic authentication
+	UserPropertyName = "graphiteUser"
+
+	// PasswordPropertyName is the config property name of the password of basic authentication
+	PasswordPropertyName = "graphitePassword"
+
+	defaultURL = "http://localhost:8080"
+)
+
+// Retriever implements genericadapter.Retrieve
+type Retriever struct {
+	URL      string
+	User     string
+	Password string
+	client   *http.Client
+}
+
+// series is an element of the response of the render API
+type series struct {
+	Target     string        `json:"target"`
+	Datapoints [][2]*float64 `json:"datapoints"`
+}
+
+func init() {
+	schema := []registry.Property{
+		{Name: URLPropertyName, Default: defaultURL, Description: "Graphite URL"},
+		{Name: UserPropertyName, Description: "User of basic authentication"},
+		{Name: PasswordPropertyName, Description: "Password of basic authentication"},
+	}
+	monitor.Register(Name, func(config *viper.Viper) (monitor.MonitoringAdapter, error) {
+		return genericadapter.NewWithSources(config, Name, New(config).Retrieve(), genericadapter.Identity)
+	}, schema...)
+	genericadapter.RegisterRetriever(Name, func(config *viper.Viper) genericadapter.Retrieve {
+		return New(config).Retrieve()
+	}, schema...)
+}
+
+// New constructs a Graphite retriever from a Viper configuration
+func New(config *viper.Viper) Retriever {
+
+	config.SetDefault(URLPropertyName, defaultURL)
+	logConfig(config)
+
+	return Retriever{
+		URL:      strings.TrimSuffix(config.GetString(URLPropertyName), "/"),
+		User:     config.GetString(UserPropertyName),
+		Password: config.GetString(PasswordPropertyName),
+		client:   utils.GetClient(false),
+	}
+}
+
+func logConfig(config *viper.Viper) {
+	log.Infof("Graphite configuration:\n"+
+		"\tURL: %s\n"+
+		"\tUser: %s\n",
+		config.GetString(URLPropertyName),
+		config.GetString(UserPropertyName))
+}
+
+// Retrieve implements genericadapter.Retrieve
+func (r Retriever) Retrieve() genericadapter.Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		result := make(map[model.Variable][]model.MetricValue)
+		errs := genericadapter.Errors{}
+		for _, item := range items {
+			values, err := r.retrieveItem(item)
+			if err != nil {
+				log.Errorf("Error retrieving %s of agreement %s: %s", item.Var.Name, agreement.Id, err.Error())
+				errs = append(errs, err)
+				continue
+			}
+			result[item.Var] = values
+		}
+		return result, errs.Err()
+	}
+}
+
+func isAverage(v model.Variable) bool {
+	return v.Aggregation != nil && v.Aggregation.Type == model.AVERAGE
+}
+
+// Target returns the Graphite target of a retrieval item
+func Target(item monitor.RetrievalItem) string {
+	if !isAverage(item.Var) {
+		return item.Var.Metric
+	}
+	seconds := int64(item.To.Sub(item.From) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf(`summarize(%s,"%ds","avg",true)`, item.Var.Metric, seconds)
+}
+
+// RenderURL returns the URL of the render API for a retrieval item
+func (r Retriever) RenderURL(item monitor.RetrievalItem) string {
+	params := url.Values{}
+	params.Set("target", Target(item))
+	params.Set("from", strconv.FormatInt(item.From.Unix(), 10))
+	params.Set("until", strconv.FormatInt(item.To.Unix(), 10))
+	params.Set("format", "json")
+	return r.URL + "/render?" + params.Encode()
+}
+
+func (r Retriever) retrieveItem(item monitor.RetrievalItem) ([]model.MetricValue, error) {
+	u := r.RenderURL(item)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.User != "" {
+		req.SetBasicAuth(r.User, r.Password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Infof("%d %s", resp.StatusCode, u)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s GET %s", resp.Status, u)
+	}
+	return parse(resp.Body, item)
+}
+
+func parse(body io.Reader, item monitor.RetrievalItem) ([]model.MetricValue, error) {
+	var ss []series
+	if err := json.NewDecoder(body).Decode(&ss); err != nil {
+		return nil, err
+	}
+	result := make([]model.MetricValue, 0)
+	for _, s := range ss {
+		key := item.Var.Name
+		if len(ss) > 1 {
+			key = fmt.Sprintf("%s{%s}", item.Var.Name, s.Target)
+		}
+		for _, dp := range s.Datapoints {
+			if dp[0] == nil || dp[1] == nil {
+				continue
+			}
+			ts := time.Unix(int64(*dp[1]), 0)
+			if isAverage(item.Var) {
+				ts = item.To
+			}
+			if !ts.After(item.From) || ts.After(item.To) {
+				continue
+			}
+			result = append(result, model.MetricValue{
+				Key:      key,
+				Value:    *dp[0],
+				DateTime: ts,
+			})
+		}
+	}
+	return result, nil
+}
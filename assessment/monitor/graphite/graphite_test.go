@@ -0,0 +1,156 @@
+package graphite
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var t0 = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+var average = model.Aggregation{Type: model.AVERAGE, Window: 300}
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(URLPropertyName, "http://graphite/")
+
+	r := New(config)
+	if r.URL != "http://graphite" {
+		t.Errorf("Unexpected retriever: %v", r)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	item := monitor.RetrievalItem{
+		Var:  model.Variable{Name: "idle", Metric: "servers.web01.cpu.idle"},
+		From: t0,
+		To:   t0.Add(5 * time.Minute),
+	}
+	if target := Target(item); target != "servers.web01.cpu.idle" {
+		t.Errorf("Unexpected target: %s", target)
+	}
+	item.Var.Aggregation = &average
+	if target := Target(item); target != `summarize(servers.web01.cpu.idle,"300s","avg",true)` {
+		t.Errorf("Unexpected target: %s", target)
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	var target, from, until, user string
+	server := newServer(t, "testdata/render.json", func(r *http.Request) {
+		target = r.URL.Query().Get("target")
+		from = r.URL.Query().Get("from")
+		until = r.URL.Query().Get("until")
+		user, _, _ = r.BasicAuth()
+	})
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, User: "user", Password: "pass", client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "servers.web01.cpu.idle"}
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{
+		{Var: v, From: t0, To: t0.Add(3 * time.Minute)},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if target != v.Metric || from != "1590969600" || until != "1590969780" || user != "user" {
+		t.Errorf("Unexpected request: target=%s from=%s until=%s user=%s", target, from, until, user)
+	}
+	values := result[v]
+	if len(values) != 2 {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+	if values[0].Value != 96.25 || !values[0].DateTime.Equal(t0.Add(time.Minute)) || values[0].Key != "idle" {
+		t.Errorf("Unexpected value: %v", values[0])
+	}
+	if values[1].Value != 95.0 || !values[1].DateTime.Equal(t0.Add(3*time.Minute)) {
+		t.Errorf("Unexpected value: %v", values[1])
+	}
+}
+
+func TestRetrieveSeveralSeries(t *testing.T) {
+	server := newServer(t, "testdata/render_multi.json", nil)
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "servers.*.cpu.idle"}
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{
+		{Var: v, From: t0, To: t0.Add(3 * time.Minute)},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	values := result[v]
+	if len(values) != 2 ||
+		values[0].Key != "idle{servers.web01.cpu.idle}" ||
+		values[1].Key != "idle{servers.web02.cpu.idle}" {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestRetrieveSummarize(t *testing.T) {
+	server := newServer(t, "testdata/render_summarize.json", nil)
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "servers.web01.cpu.idle", Aggregation: &average}
+	to := t0.Add(5 * time.Minute)
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{
+		{Var: v, From: t0, To: to},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	values := result[v]
+	if len(values) != 1 || values[0].Value != 96.25 || !values[0].DateTime.Equal(to) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestRetrieveError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad target", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "servers.web01.cpu.idle"}
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{
+		{Var: v, From: t0, To: t0.Add(3 * time.Minute)},
+	})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+	if _, ok := result[v]; ok {
+		t.Errorf("Unexpected result: %v", result)
+	}
+}
+
+func newServer(t *testing.T, path string, check func(r *http.Request)) *httptest.Server {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	body, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			check(r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+}
@@ -0,0 +1,393 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package httpjson contains a retriever that gets the values of each variable
+from an HTTP endpoint that returns JSON.
+
+The URL is a text/template executed for each variable with a URLData value,
+e.g.:
+
+	https://api.example.com/metrics/{{.Metric}}?from={{unix .From}}&to={{unix .To}}&service={{query .Agreement.Details.Provider.Id}}
+
+Besides the standard functions, the template may use: query (escapes a query
+parameter), unix (seconds since epoch), unixms (milliseconds since epoch) and
+rfc3339.
+
+The values are extracted from the response with JSONPath expressions (see
+jsonpath.go): the items expression selects the points, and the timestamp and
+value expressions are evaluated on each point. For example, for the response
+
+	{"data": [{"ts": 1590969600, "v": 0.99}, {"ts": 1590973200, "v": 0.98}]}
+
+the expressions are "$.data[*]", "$.ts" and "$.v". Only the points in the
+(From, To] retrieval interval are returned. If the timestamp expression
+is empty, the values are stamped with the end of the retrieval interval.
+*/
+package httpjson
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/assessment/monitor/genericadapter"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/utils"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this retriever
+	Name = "httpjson"
+
+	// URLPropertyName is the config property name of the URL template
+	URLPropertyName = "httpjsonUrl"
+
+	// ItemsPropertyName is the config property name of the JSONPath of the points
+	ItemsPropertyName = "httpjsonItems"
+
+	// TimestampPropertyName is the config property name of the JSONPath of the timestamp of a point
+	TimestampPropertyName = "httpjsonTimestamp"
+
+	// ValuePropertyName is the config property name of the JSONPath of the value of a point
+	ValuePropertyName = "httpjsonValue"
+
+	// TimestampFormatPropertyName is the config property name of the format of
+	// the timestamps: rfc3339, unix (seconds) or unixms (milliseconds).
+	// Numeric timestamps are read as unix seconds if the format is rfc3339.
+	TimestampFormatPropertyName = "httpjsonTimestampFormat"
+
+	// HeadersPropertyName is the config property name of a map of headers to add to the requests
+	HeadersPropertyName = "httpjsonHeaders"
+
+	// BearerTokenPropertyName is the config property name of a bearer token to authenticate the requests
+	BearerTokenPropertyName = "httpjsonBearerToken"
+
+	// UserPropertyName is the config property name of the user of basic authentication
+	UserPropertyName = "httpjsonUser"
+
+	// PasswordPropertyName is the config property name of the password of basic authentication
+	PasswordPropertyName = "httpjsonPassword"
+
+	// InsecurePropertyName is the config property name that disables the verification of the server certificate
+	InsecurePropertyName = "httpjsonInsecure"
+
+	// RFC3339Format is the value of TimestampFormatPropertyName for RFC3339 timestamps
+	RFC3339Format = "rfc3339"
+
+	// UnixFormat is the value of TimestampFormatPropertyName for seconds since epoch
+	UnixFormat = "unix"
+
+	// UnixMsFormat is the value of TimestampFormatPropertyName for milliseconds since epoch
+	UnixMsFormat = "unixms"
+
+	defaultItems = "$[*]"
+	defaultValue = "$.value"
+)
+
+// URLData is the data passed to the URL template
+type URLData struct {
+	Metric    string
+	Var       model.Variable
+	From      time.Time
+	To        time.Time
+	Agreement model.Agreement
+}
+
+// Retriever implements genericadapter.Retrieve
+type Retriever struct {
+	url             *template.Template
+	items           jsonPath
+	timestamp       *jsonPath
+	value           jsonPath
+	timestampFormat string
+	headers         map[string]string
+	user            string
+	password        string
+	client          *http.Client
+}
+
+var funcs = template.FuncMap{
+	"query":   url.QueryEscape,
+	"unix":    func(t time.Time) int64 { return t.Unix() },
+	"unixms":  func(t time.Time) int64 { return t.UnixNano() / int64(time.Millisecond) },
+	"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}
+
+func init() {
+	monitor.Register(Name, func(config *viper.Viper) (monitor.MonitoringAdapter, error) {
+		return genericadapter.NewWithSources(config, Name, New(config).Retrieve(), genericadapter.Identity)
+	})
+	genericadapter.RegisterScopedRetriever(Name, func(config *viper.Viper) genericadapter.Retrieve {
+		return New(config).Retrieve()
+	},
+		registry.Property{Name: URLPropertyName, Description: "Go text/template of the URL of a variable"},
+		registry.Property{Name: ItemsPropertyName, Default: defaultItems, Description: "JSONPath of the points in the response"},
+		registry.Property{Name: TimestampPropertyName, Description: "JSONPath of the timestamp of a point"},
+		registry.Property{Name: ValuePropertyName, Default: defaultValue, Description: "JSONPath of the value of a point"},
+		registry.Property{Name: TimestampFormatPropertyName, Default: RFC3339Format, Description: "rfc3339, unix or unixms"},
+		registry.Property{Name: HeadersPropertyName, Description: "Map of headers to add to the requests"},
+		registry.Property{Name: BearerTokenPropertyName, Description: "Bearer token"},
+		registry.Property{Name: UserPropertyName, Description: "User of basic authentication"},
+		registry.Property{Name: PasswordPropertyName, Description: "Password of basic authentication"},
+		registry.Property{Name: InsecurePropertyName, Description: "Skips the verification of the server certificate"})
+}
+
+// New constructs an HTTP/JSON retriever from a Viper configuration.
+//
+// It terminates the program if the URL template or the JSONPath expressions are not valid.
+func New(config *viper.Viper) Retriever {
+
+	config.SetDefault(ItemsPropertyName, defaultItems)
+	config.SetDefault(ValuePropertyName, defaultValue)
+	config.SetDefault(TimestampFormatPropertyName, RFC3339Format)
+	logConfig(config)
+
+	headers := config.GetStringMapString(HeadersPropertyName)
+	if token := config.GetString(BearerTokenPropertyName); token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	r, err := _new(
+		config.GetString(URLPropertyName),
+		config.GetString(ItemsPropertyName),
+		config.GetString(TimestampPropertyName),
+		config.GetString(ValuePropertyName),
+		config.GetString(TimestampFormatPropertyName),
+		headers,
+		utils.GetClient(config.GetBool(InsecurePropertyName)))
+	if err != nil {
+		log.Fatal("Error configuring HTTP/JSON retriever: " + err.Error())
+	}
+	r.user = config.GetString(UserPropertyName)
+	r.password = config.GetString(PasswordPropertyName)
+	return r
+}
+
+func _new(urlTemplate, items, timestamp, value, timestampFormat string,
+	headers map[string]string, client *http.Client) (Retriever, error) {
+
+	r := Retriever{
+		timestampFormat: timestampFormat,
+		headers:         headers,
+		client:          client,
+	}
+	var err error
+	if r.url, err = template.New("url").Funcs(funcs).Parse(urlTemplate); err != nil {
+		return r, err
+	}
+	if r.items, err = compilePath(items); err != nil {
+		return r, err
+	}
+	if r.value, err = compilePath(value); err != nil {
+		return r, err
+	}
+	if timestamp != "" {
+		ts, err := compilePath(timestamp)
+		if err != nil {
+			return r, err
+		}
+		r.timestamp = &ts
+	}
+	switch timestampFormat {
+	case RFC3339Format, UnixFormat, UnixMsFormat:
+	default:
+		return r, fmt.Errorf("unknown timestamp format '%s'", timestampFormat)
+	}
+	return r, nil
+}
+
+func logConfig(config *viper.Viper) {
+	log.Infof("HTTP/JSON retriever configuration:\n"+
+		"\tURL: %s\n"+
+		"\tItems: %s\n"+
+		"\tTimestamp: %s (%s)\n"+
+		"\tValue: %s\n"+
+		"\tUser: %s\n",
+		config.GetString(URLPropertyName),
+		config.GetString(ItemsPropertyName),
+		config.GetString(TimestampPropertyName),
+		config.GetString(TimestampFormatPropertyName),
+		config.GetString(ValuePropertyName),
+		config.GetString(UserPropertyName))
+}
+
+// Retrieve implements genericadapter.Retrieve
+func (r Retriever) Retrieve() genericadapter.Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		result := make(map[model.Variable][]model.MetricValue)
+		errs := genericadapter.Errors{}
+		for _, item := range items {
+			values, err := r.retrieveItem(agreement, item)
+			if err != nil {
+				log.Errorf("Error retrieving %s of agreement %s: %s", item.Var.Name, agreement.Id, err.Error())
+				errs = append(errs, err)
+				continue
+			}
+			result[item.Var] = values
+		}
+		return result, errs.Err()
+	}
+}
+
+func (r Retriever) retrieveItem(agreement model.Agreement, item monitor.RetrievalItem) ([]model.MetricValue, error) {
+	b := new(bytes.Buffer)
+	data := URLData{
+		Metric:    item.Var.Metric,
+		Var:       item.Var,
+		From:      item.From,
+		To:        item.To,
+		Agreement: agreement,
+	}
+	if err := r.url.Execute(b, data); err != nil {
+		return nil, err
+	}
+	doc, err := r.request(b.String())
+	if err != nil {
+		return nil, err
+	}
+	return r.extract(doc, item)
+}
+
+func (r Retriever) request(rawURL string) (interface{}, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	/* the query string may contain credentials (e.g. API keys) */
+	endpoint := withoutQuery(req.URL)
+	req.Header.Set("Accept", "application/json")
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+	if r.user != "" {
+		req.SetBasicAuth(r.user, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("GET %s: %s", endpoint, err.Error())
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("%d GET %s", resp.StatusCode, endpoint)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s GET %s", resp.Status, endpoint)
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error decoding response of %s: %s", endpoint, err.Error())
+	}
+	return doc, nil
+}
+
+// withoutQuery returns u without the query string and the user info
+func withoutQuery(u *url.URL) string {
+	result := *u
+	result.RawQuery = ""
+	result.User = nil
+	return result.String()
+}
+
+func (r Retriever) extract(doc interface{}, item monitor.RetrievalItem) ([]model.MetricValue, error) {
+	points := r.items.eval(doc)
+	result := make([]model.MetricValue, 0, len(points))
+	for _, point := range points {
+		values := r.value.eval(point)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("value '%s' not found in %v", r.value.expr, point)
+		}
+		ts := item.To
+		if r.timestamp != nil {
+			timestamps := r.timestamp.eval(point)
+			if len(timestamps) == 0 {
+				return nil, fmt.Errorf("timestamp '%s' not found in %v", r.timestamp.expr, point)
+			}
+			var err error
+			if ts, err = r.parseTimestamp(timestamps[0]); err != nil {
+				return nil, err
+			}
+			/* the endpoint may ignore the interval in the URL */
+			if !ts.After(item.From) || ts.After(item.To) {
+				continue
+			}
+		}
+		result = append(result, model.MetricValue{
+			Key:      item.Var.Name,
+			Value:    parseValue(values[0]),
+			DateTime: ts,
+		})
+	}
+	return result, nil
+}
+
+func (r Retriever) parseTimestamp(v interface{}) (time.Time, error) {
+	var s string
+	switch t := v.(type) {
+	case json.Number:
+		s = t.String()
+	case string:
+		s = t
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+	}
+	if r.timestampFormat == RFC3339Format {
+		if ts, err := time.Parse(time.RFC3339, s); err == nil {
+			return ts, nil
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+	}
+	if r.timestampFormat == UnixMsFormat {
+		f = f / 1000
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
+
+// parseValue returns numbers and numeric strings as float64, and other values as is
+func parseValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,163 @@
+package httpjson
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var agreement = model.Agreement{
+	Id: "a01",
+	Details: model.Details{
+		Provider: model.Provider{Id: "p01", Name: "A provider"},
+	},
+}
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(URLPropertyName, "http://localhost/{{.Metric}}")
+	config.Set(BearerTokenPropertyName, "token")
+
+	r := New(config)
+	if r.headers["Authorization"] != "Bearer token" {
+		t.Errorf("Unexpected headers: %v", r.headers)
+	}
+}
+
+func TestNewWrong(t *testing.T) {
+	if _, err := _new("{{.Metric", defaultItems, "", defaultValue, RFC3339Format, nil, http.DefaultClient); err == nil {
+		t.Error("Expected error on wrong URL template")
+	}
+	if _, err := _new("http://localhost", "$[", "", defaultValue, RFC3339Format, nil, http.DefaultClient); err == nil {
+		t.Error("Expected error on wrong JSONPath")
+	}
+	if _, err := _new("http://localhost", defaultItems, "", defaultValue, "iso", nil, http.DefaultClient); err == nil {
+		t.Error("Expected error on unknown timestamp format")
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	var path, query, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		query = r.URL.RawQuery
+		auth = r.Header.Get("X-Api-Key")
+		w.Write([]byte(`{"data": [{"ts": 1590969600, "v": 0.99}, {"ts": 1590973200.5, "v": "0.98"}]}`))
+	}))
+	defer server.Close()
+
+	r, err := _new(server.URL+"/{{.Agreement.Details.Provider.Id}}/{{.Metric}}?from={{unix .From}}&to={{rfc3339 .To}}",
+		"$.data[*]", "$.ts", "$.v", UnixFormat, map[string]string{"x-api-key": "secret"}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	v := model.Variable{Name: "av", Metric: "availability"}
+	from := time.Unix(1590969000, 0)
+	to := time.Unix(1590976800, 0)
+	result, err := r.Retrieve()(agreement, []monitor.RetrievalItem{{Var: v, From: from, To: to}})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if path != "/p01/availability" || query != "from=1590969000&to=2020-06-01T02:00:00Z" || auth != "secret" {
+		t.Errorf("Unexpected request. Path: %s; Query: %s; Auth: %s", path, query, auth)
+	}
+	values := result[v]
+	if len(values) != 2 {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+	if values[0].Key != "av" || values[0].Value != 0.99 || !values[0].DateTime.Equal(time.Unix(1590969600, 0)) {
+		t.Errorf("Unexpected value[0]: %v", values[0])
+	}
+	if values[1].Value != 0.98 || !values[1].DateTime.Equal(time.Unix(1590973200, 5e8)) {
+		t.Errorf("Unexpected value[1]: %v", values[1])
+	}
+}
+
+func TestRetrieveInterval(t *testing.T) {
+	/* the endpoint ignores the interval */
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [{"ts": 100, "v": 1}, {"ts": 200, "v": 2}, {"ts": 300, "v": 3}]}`))
+	}))
+	defer server.Close()
+
+	r, _ := _new(server.URL, "$.data[*]", "$.ts", "$.v", UnixFormat, nil, http.DefaultClient)
+	v := model.Variable{Name: "m", Metric: "m"}
+	result, err := r.Retrieve()(agreement, []monitor.RetrievalItem{{Var: v, From: time.Unix(100, 0), To: time.Unix(200, 0)}})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if values := result[v]; len(values) != 1 || values[0].Value != 2.0 {
+		t.Errorf("Expected only the value in the interval. Actual: %v", values)
+	}
+}
+
+func TestRetrieveWithoutTimestamp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": {"uptime": 99.5}}`))
+	}))
+	defer server.Close()
+
+	r, _ := _new(server.URL, "$.status", "", "$.uptime", RFC3339Format, nil, http.DefaultClient)
+	v := model.Variable{Name: "uptime", Metric: "uptime"}
+	to := time.Now()
+	result, err := r.Retrieve()(agreement, []monitor.RetrievalItem{{Var: v, To: to}})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if values := result[v]; len(values) != 1 || values[0].Value != 99.5 || values[0].DateTime != to {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestRetrieveRFC3339(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"time": "2020-06-01T00:00:00Z", "value": 1}]`))
+	}))
+	defer server.Close()
+
+	r, _ := _new(server.URL, defaultItems, "$.time", defaultValue, RFC3339Format, nil, http.DefaultClient)
+	v := model.Variable{Name: "m", Metric: "m"}
+	result, err := r.Retrieve()(agreement, []monitor.RetrievalItem{{Var: v, To: time.Now()}})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expected := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	if values := result[v]; len(values) != 1 || !values[0].DateTime.Equal(expected) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestRetrieveWrong(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notfound" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"novalue": 1}]`))
+	}))
+	defer server.Close()
+
+	for _, u := range []string{server.URL + "/notfound", server.URL + "/novalue", "http://localhost:1"} {
+		r, _ := _new(u+"?apikey=secret", defaultItems, "", defaultValue, RFC3339Format, nil, http.DefaultClient)
+		v := model.Variable{Name: "m", Metric: "m"}
+		result, err := r.Retrieve()(agreement, []monitor.RetrievalItem{{Var: v, To: time.Now()}})
+		if err == nil {
+			t.Errorf("Expected error")
+		} else if strings.Contains(err.Error(), "secret") {
+			t.Errorf("The error contains the query string: %s", err.Error())
+		}
+		if _, ok := result[v]; ok {
+			t.Errorf("Not expected values from %s: %v", u, result)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpjson
+
+/*
+This file contains an evaluator of a subset of JSONPath expressions:
+
+	$             the root value
+	.name         member of an object
+	['name']      member of an object (allows any character in name)
+	[n]           element of an array (negative indexes count from the end)
+	[*] or .*     all the members of an object or elements of an array
+	..name        recursive descent: member name at any depth
+
+E.g. "$.data.result[*]", "$[0]", "$..value".
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	memberStep stepKind = iota
+	indexStep
+	wildcardStep
+	descentStep
+)
+
+type step struct {
+	kind  stepKind
+	name  string
+	index int
+}
+
+// jsonPath is a compiled JSONPath expression
+type jsonPath struct {
+	expr  string
+	steps []step
+}
+
+// compilePath parses a JSONPath expression. The leading $ is optional.
+func compilePath(expr string) (jsonPath, error) {
+	p := jsonPath{expr: expr}
+	s := strings.TrimSpace(expr)
+	if strings.HasPrefix(s, "$") {
+		s = s[1:]
+	} else if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			name, rest := readName(s[2:])
+			if name == "" {
+				return p, fmt.Errorf("invalid JSONPath '%s': expected name after '..'", expr)
+			}
+			p.steps = append(p.steps, step{kind: descentStep, name: name})
+			s = rest
+		case s[0] == '.':
+			name, rest := readName(s[1:])
+			if name == "" {
+				return p, fmt.Errorf("invalid JSONPath '%s': expected name after '.'", expr)
+			}
+			if name == "*" {
+				p.steps = append(p.steps, step{kind: wildcardStep})
+			} else {
+				p.steps = append(p.steps, step{kind: memberStep, name: name})
+			}
+			s = rest
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return p, fmt.Errorf("invalid JSONPath '%s': unclosed '['", expr)
+			}
+			st, err := parseBracket(s[1:end])
+			if err != nil {
+				return p, fmt.Errorf("invalid JSONPath '%s': %s", expr, err.Error())
+			}
+			p.steps = append(p.steps, st)
+			s = s[end+1:]
+		default:
+			return p, fmt.Errorf("invalid JSONPath '%s': unexpected '%c'", expr, s[0])
+		}
+	}
+	return p, nil
+}
+
+func readName(s string) (string, string) {
+	i := strings.IndexAny(s, ".[")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+func parseBracket(s string) (step, error) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return step{kind: wildcardStep}, nil
+	}
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return step{kind: memberStep, name: s[1 : len(s)-1]}, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return step{}, fmt.Errorf("invalid subscript '[%s]'", s)
+	}
+	return step{kind: indexStep, index: i}, nil
+}
+
+// eval returns the values selected by the path in a decoded JSON document
+func (p jsonPath) eval(doc interface{}) []interface{} {
+	current := []interface{}{doc}
+	for _, st := range p.steps {
+		next := make([]interface{}, 0)
+		for _, v := range current {
+			next = append(next, st.apply(v)...)
+		}
+		current = next
+	}
+	return current
+}
+
+func (st step) apply(v interface{}) []interface{} {
+	switch st.kind {
+	case memberStep:
+		if m, ok := v.(map[string]interface{}); ok {
+			if child, ok := m[st.name]; ok {
+				return []interface{}{child}
+			}
+		}
+	case indexStep:
+		if a, ok := v.([]interface{}); ok {
+			i := st.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				return []interface{}{a[i]}
+			}
+		}
+	case wildcardStep:
+		return children(v)
+	case descentStep:
+		result := make([]interface{}, 0)
+		member := step{kind: memberStep, name: st.name}
+		var walk func(v interface{})
+		walk = func(v interface{}) {
+			result = append(result, member.apply(v)...)
+			for _, child := range children(v) {
+				walk(child)
+			}
+		}
+		walk(v)
+		return result
+	}
+	return nil
+}
+
+// children returns the elements of an array or the members of an object (sorted by key)
+func children(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		return t
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		result := make([]interface{}, 0, len(t))
+		for _, k := range keys {
+			result = append(result, t[k])
+		}
+		return result
+	}
+	return nil
+}
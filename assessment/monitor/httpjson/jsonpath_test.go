@@ -0,0 +1,57 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const doc = `{
+	"data": {
+		"result": [
+			{"ts": 1, "value": "a", "labels": {"value": "x"}},
+			{"ts": 2, "value": "b"}
+		],
+		"my key": "c"
+	},
+	"pairs": [[1, "p"], [2, "q"]]
+}`
+
+func TestJSONPath(t *testing.T) {
+	var v interface{}
+	json.Unmarshal([]byte(doc), &v)
+
+	tests := []struct {
+		expr     string
+		expected []interface{}
+	}{
+		{"$.data.result[*].value", []interface{}{"a", "b"}},
+		{"data.result[1].value", []interface{}{"b"}},
+		{"$.data.result[-1].ts", []interface{}{2.0}},
+		{"$.data['my key']", []interface{}{"c"}},
+		{"$.pairs[*][1]", []interface{}{"p", "q"}},
+		{"$.data.result[1].*", []interface{}{2.0, "b"}},
+		{"$..value", []interface{}{"a", "x", "b"}},
+		{"$.data.notexists", []interface{}{}},
+		{"$.data.result[5]", []interface{}{}},
+		{"$", []interface{}{v}},
+	}
+	for _, test := range tests {
+		p, err := compilePath(test.expr)
+		if err != nil {
+			t.Errorf("Unexpected error compiling %s: %s", test.expr, err)
+			continue
+		}
+		if actual := p.eval(v); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Unexpected result of %s. Expected: %v; Actual: %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestJSONPathInvalid(t *testing.T) {
+	for _, expr := range []string{"$.", "$[1", "$[a]", "$..", "$x"} {
+		if _, err := compilePath(expr); err == nil {
+			t.Errorf("Expected error compiling %s", expr)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package influxdb
+
+import (
+	"SLALite/model"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+/*
+parseFluxCSV parses the annotated CSV returned by the Flux query endpoint.
+
+Annotation rows (starting with #) are skipped; each table starts with a header
+row that gives the position of the _time and _value columns. Rows without
+_time (e.g., the result of mean()) have a zero DateTime.
+*/
+func parseFluxCSV(r io.Reader, key string) ([]model.MetricValue, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	result := make([]model.MetricValue, 0)
+	timeCol, valueCol := -1, -1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if i := indexOf(row, "error"); i >= 0 && indexOf(row, "_value") < 0 {
+			/* the following row contains the error message */
+			if row, err = reader.Read(); err == nil && i < len(row) {
+				return nil, fmt.Errorf("InfluxDB error: %s", row[i])
+			}
+			return nil, fmt.Errorf("InfluxDB error")
+		}
+		if i := indexOf(row, "_value"); i >= 0 {
+			/* header of a new table */
+			timeCol, valueCol = indexOf(row, "_time"), i
+			continue
+		}
+		if valueCol < 0 {
+			return nil, fmt.Errorf("no _value column in Flux result")
+		}
+		if valueCol >= len(row) || row[valueCol] == "" {
+			continue
+		}
+		value := model.MetricValue{Key: key}
+		if f, err := strconv.ParseFloat(row[valueCol], 64); err == nil {
+			value.Value = f
+		} else {
+			value.Value = row[valueCol]
+		}
+		if timeCol >= 0 && timeCol < len(row) {
+			if value.DateTime, err = time.Parse(time.RFC3339Nano, row[timeCol]); err != nil {
+				return nil, fmt.Errorf("invalid _time %s", row[timeCol])
+			}
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func indexOf(row []string, name string) int {
+	for i, col := range row {
+		if col == name {
+			return i
+		}
+	}
+	return -1
+}
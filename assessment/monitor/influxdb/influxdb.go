@@ -0,0 +1,385 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package influxdb contains a retriever that gets the values of the variables
+from InfluxDB, with InfluxQL (InfluxDB 1.x /query endpoint) or Flux (InfluxDB
+2.x /api/v2/query endpoint).
+
+The metric of a variable is the measurement name, optionally followed by a
+colon and the field name (e.g. "cpu:usage_idle"). If the field is not
+specified, the field in the influxdbField setting is used.
+
+The values are queried in the (From, To] interval of the retrieval item. If the
+variable has an average aggregation, the mean of the interval is calculated by
+InfluxDB and returned as one value at To.
+*/
+package influxdb
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/assessment/monitor/genericadapter"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this retriever
+	Name = "influxdb"
+
+	// URLPropertyName is the config property name of the InfluxDB URL
+	URLPropertyName = "influxdbUrl"
+
+	// LanguagePropertyName is the config property name of the query language (influxql or flux)
+	LanguagePropertyName = "influxdbLanguage"
+
+	// DatabasePropertyName is the config property name of the database (InfluxQL)
+	DatabasePropertyName = "influxdbDatabase"
+
+	// OrgPropertyName is the config property name of the organization (Flux)
+	OrgPropertyName = "influxdbOrg"
+
+	// BucketPropertyName is the config property name of the bucket (Flux)
+	BucketPropertyName = "influxdbBucket"
+
+	// FieldPropertyName is the config property name of the default field of the measurements
+	FieldPropertyName = "influxdbField"
+
+	// TokenPropertyName is the config property name of the authentication token
+	TokenPropertyName = "influxdbToken"
+
+	// UserPropertyName is the config property name of the user (InfluxQL)
+	UserPropertyName = "influxdbUser"
+
+	// PasswordPropertyName is the config property name of the password (InfluxQL)
+	PasswordPropertyName = "influxdbPassword"
+
+	// InfluxQL is the value of LanguagePropertyName to use InfluxQL
+	InfluxQL = "influxql"
+
+	// Flux is the value of LanguagePropertyName to use Flux
+	Flux = "flux"
+
+	defaultURL   = "http://localhost:8086"
+	defaultField = "value"
+)
+
+// Retriever implements genericadapter.Retrieve
+type Retriever struct {
+	URL      string
+	Language string
+	Database string
+	Org      string
+	Bucket   string
+	Field    string
+	Token    string
+	User     string
+	Password string
+	client   *http.Client
+}
+
+func init() {
+	schema := []registry.Property{
+		{Name: URLPropertyName, Default: defaultURL, Description: "InfluxDB URL"},
+		{Name: LanguagePropertyName, Default: InfluxQL, Description: "influxql or flux"},
+		{Name: DatabasePropertyName, Description: "Database (influxql)"},
+		{Name: OrgPropertyName, Description: "Organization (flux)"},
+		{Name: BucketPropertyName, Description: "Bucket (flux)"},
+		{Name: FieldPropertyName, Default: defaultField, Description: "Field of the measurements if not in the metric"},
+		{Name: TokenPropertyName, Description: "Authentication token"},
+		{Name: UserPropertyName, Description: "User (influxql)"},
+		{Name: PasswordPropertyName, Description: "Password (influxql)"},
+	}
+	monitor.Register(Name, func(config *viper.Viper) (monitor.MonitoringAdapter, error) {
+		r := New(config)
+		ma, err := genericadapter.NewWithSources(config, Name, r.Retrieve(), genericadapter.Identity)
+		if err == nil {
+			ma.(*genericadapter.Adapter).Check = r.Ping
+		}
+		return ma, err
+	}, schema...)
+	genericadapter.RegisterRetriever(Name, func(config *viper.Viper) genericadapter.Retrieve {
+		return New(config).Retrieve()
+	}, schema...)
+}
+
+// New constructs an InfluxDB retriever from a Viper configuration
+func New(config *viper.Viper) Retriever {
+
+	config.SetDefault(URLPropertyName, defaultURL)
+	config.SetDefault(LanguagePropertyName, InfluxQL)
+	config.SetDefault(FieldPropertyName, defaultField)
+	logConfig(config)
+
+	return Retriever{
+		URL:      strings.TrimSuffix(config.GetString(URLPropertyName), "/"),
+		Language: strings.ToLower(config.GetString(LanguagePropertyName)),
+		Database: config.GetString(DatabasePropertyName),
+		Org:      config.GetString(OrgPropertyName),
+		Bucket:   config.GetString(BucketPropertyName),
+		Field:    config.GetString(FieldPropertyName),
+		Token:    config.GetString(TokenPropertyName),
+		User:     config.GetString(UserPropertyName),
+		Password: config.GetString(PasswordPropertyName),
+		client:   utils.GetClient(false),
+	}
+}
+
+func logConfig(config *viper.Viper) {
+	log.Infof("InfluxDB configuration:\n"+
+		"\tURL: %s\n"+
+		"\tLanguage: %s\n"+
+		"\tDatabase: %s\n"+
+		"\tOrg: %s\n"+
+		"\tBucket: %s\n"+
+		"\tUser: %s\n",
+		config.GetString(URLPropertyName),
+		config.GetString(LanguagePropertyName),
+		config.GetString(DatabasePropertyName),
+		config.GetString(OrgPropertyName),
+		config.GetString(BucketPropertyName),
+		config.GetString(UserPropertyName))
+}
+
+// Retrieve implements genericadapter.Retrieve
+func (r Retriever) Retrieve() genericadapter.Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		result := make(map[model.Variable][]model.MetricValue)
+		errs := genericadapter.Errors{}
+		for _, item := range items {
+			var values []model.MetricValue
+			var err error
+			if r.Language == Flux {
+				values, err = r.retrieveFlux(item)
+			} else {
+				values, err = r.retrieveInfluxQL(item)
+			}
+			if err != nil {
+				log.Errorf("Error retrieving %s of agreement %s: %s", item.Var.Name, agreement.Id, err.Error())
+				errs = append(errs, err)
+				continue
+			}
+			result[item.Var] = filter(values, item)
+		}
+		return result, errs.Err()
+	}
+}
+
+// splitMetric returns the measurement and field of a metric
+func (r Retriever) splitMetric(metric string) (string, string) {
+	if i := strings.LastIndex(metric, ":"); i >= 0 {
+		return metric[:i], metric[i+1:]
+	}
+	return metric, r.Field
+}
+
+func isAverage(v model.Variable) bool {
+	return v.Aggregation != nil && v.Aggregation.Type == model.AVERAGE
+}
+
+/*
+InfluxQLQuery returns the InfluxQL query of a retrieval item
+*/
+func (r Retriever) InfluxQLQuery(item monitor.RetrievalItem) string {
+	measurement, field := r.splitMetric(item.Var.Metric)
+	selector := quoteIdent(field)
+	if isAverage(item.Var) {
+		selector = "mean(" + selector + ")"
+	}
+	return fmt.Sprintf("SELECT %s FROM %s WHERE time > '%s' AND time <= '%s'",
+		selector, quoteIdent(measurement),
+		item.From.UTC().Format(time.RFC3339Nano), item.To.UTC().Format(time.RFC3339Nano))
+}
+
+/*
+FluxQuery returns the Flux query of a retrieval item
+*/
+func (r Retriever) FluxQuery(item monitor.RetrievalItem) string {
+	measurement, field := r.splitMetric(item.Var.Metric)
+	q := fmt.Sprintf("from(bucket: %s)\n"+
+		"  |> range(start: %s, stop: %s)\n"+
+		"  |> filter(fn: (r) => r._measurement == %s and r._field == %s)",
+		quoteString(r.Bucket),
+		item.From.UTC().Format(time.RFC3339Nano), item.To.UTC().Format(time.RFC3339Nano),
+		quoteString(measurement), quoteString(field))
+	if isAverage(item.Var) {
+		q += "\n  |> mean()"
+	}
+	return q
+}
+
+type influxQLResponse struct {
+	Results []struct {
+		Series []struct {
+			Name    string          `json:"name"`
+			Columns []string        `json:"columns"`
+			Values  [][]interface{} `json:"values"`
+		} `json:"series"`
+		Error string `json:"error"`
+	} `json:"results"`
+	Error string `json:"error"`
+}
+
+func (r Retriever) retrieveInfluxQL(item monitor.RetrievalItem) ([]model.MetricValue, error) {
+	params := url.Values{}
+	params.Set("q", r.InfluxQLQuery(item))
+	params.Set("epoch", "ms")
+	if r.Database != "" {
+		params.Set("db", r.Database)
+	}
+	req, err := http.NewRequest(http.MethodGet, r.URL+"/query?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.User != "" {
+		req.SetBasicAuth(r.User, r.Password)
+	}
+	body, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp influxQLResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+	return parseInfluxQL(resp, item)
+}
+
+func parseInfluxQL(resp influxQLResponse, item monitor.RetrievalItem) ([]model.MetricValue, error) {
+	if resp.Error != "" {
+		return nil, fmt.Errorf("InfluxDB error: %s", resp.Error)
+	}
+	result := make([]model.MetricValue, 0)
+	for _, res := range resp.Results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("InfluxDB error: %s", res.Error)
+		}
+		for _, series := range res.Series {
+			for _, row := range series.Values {
+				if len(row) < 2 || row[1] == nil {
+					continue
+				}
+				ts, ok := row[0].(float64)
+				if !ok {
+					return nil, fmt.Errorf("invalid time %v", row[0])
+				}
+				result = append(result, model.MetricValue{
+					Key:      item.Var.Name,
+					Value:    row[1],
+					DateTime: time.Unix(0, int64(ts)*int64(time.Millisecond)),
+				})
+			}
+		}
+	}
+	return stampAggregation(result, item), nil
+}
+
+func (r Retriever) retrieveFlux(item monitor.RetrievalItem) ([]model.MetricValue, error) {
+	params := url.Values{}
+	params.Set("org", r.Org)
+	req, err := http.NewRequest(http.MethodPost, r.URL+"/api/v2/query?"+params.Encode(),
+		strings.NewReader(r.FluxQuery(item)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.flux")
+	req.Header.Set("Accept", "application/csv")
+	body, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	values, err := parseFluxCSV(body, item.Var.Name)
+	if err != nil {
+		return nil, err
+	}
+	return stampAggregation(values, item), nil
+}
+
+// Ping checks that InfluxDB is reachable
+func (r Retriever) Ping() error {
+	req, err := http.NewRequest(http.MethodGet, r.URL+"/ping", nil)
+	if err != nil {
+		return err
+	}
+	body, err := r.do(req)
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+func (r Retriever) do(req *http.Request) (io.ReadCloser, error) {
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Token "+r.Token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("%d %s %s", resp.StatusCode, req.Method, req.URL)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s %s", resp.Status, req.Method, req.URL)
+	}
+	return resp.Body, nil
+}
+
+// stampAggregation sets the time of an aggregated value to the end of the interval
+func stampAggregation(values []model.MetricValue, item monitor.RetrievalItem) []model.MetricValue {
+	if isAverage(item.Var) {
+		for i := range values {
+			values[i].DateTime = item.To
+		}
+	}
+	return values
+}
+
+// filter returns the values in the (From, To] interval of the item
+func filter(values []model.MetricValue, item monitor.RetrievalItem) []model.MetricValue {
+	result := make([]model.MetricValue, 0, len(values))
+	for _, v := range values {
+		if v.DateTime.After(item.From) && !v.DateTime.After(item.To) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+func quoteString(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
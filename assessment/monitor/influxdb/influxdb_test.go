@@ -0,0 +1,209 @@
+package influxdb
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var t0 = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+var average = model.Aggregation{Type: model.AVERAGE, Window: 300}
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(LanguagePropertyName, "Flux")
+
+	r := New(config)
+	if r.URL != defaultURL || r.Language != Flux || r.Field != defaultField {
+		t.Errorf("Unexpected retriever: %v", r)
+	}
+}
+
+func TestInfluxQLQuery(t *testing.T) {
+	r := Retriever{Field: "value"}
+	item := monitor.RetrievalItem{
+		Var:  model.Variable{Name: "idle", Metric: "cpu:usage_idle"},
+		From: t0,
+		To:   t0.Add(5 * time.Minute),
+	}
+	expected := `SELECT "usage_idle" FROM "cpu" WHERE time > '2020-06-01T00:00:00Z' AND time <= '2020-06-01T00:05:00Z'`
+	if q := r.InfluxQLQuery(item); q != expected {
+		t.Errorf("Unexpected query: %s", q)
+	}
+
+	item.Var = model.Variable{Name: "mem", Metric: "mem", Aggregation: &average}
+	expected = `SELECT mean("value") FROM "mem" WHERE time > '2020-06-01T00:00:00Z' AND time <= '2020-06-01T00:05:00Z'`
+	if q := r.InfluxQLQuery(item); q != expected {
+		t.Errorf("Unexpected query: %s", q)
+	}
+}
+
+func TestFluxQuery(t *testing.T) {
+	r := Retriever{Bucket: "telegraf", Field: "value"}
+	item := monitor.RetrievalItem{
+		Var:  model.Variable{Name: "idle", Metric: "cpu:usage_idle", Aggregation: &average},
+		From: t0,
+		To:   t0.Add(5 * time.Minute),
+	}
+	expected := `from(bucket: "telegraf")
+  |> range(start: 2020-06-01T00:00:00Z, stop: 2020-06-01T00:05:00Z)
+  |> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_idle")
+  |> mean()`
+	if q := r.FluxQuery(item); q != expected {
+		t.Errorf("Unexpected query: %s", q)
+	}
+}
+
+func TestRetrieveInfluxQL(t *testing.T) {
+	var query, db, user string
+	server := newServer(t, "testdata/influxql.json", func(r *http.Request) {
+		query = r.URL.Query().Get("q")
+		db = r.URL.Query().Get("db")
+		user, _, _ = r.BasicAuth()
+	})
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, Language: InfluxQL, Database: "telegraf", Field: "value",
+		User: "user", Password: "pass", client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "cpu:usage_idle"}
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{
+		{Var: v, From: t0, To: t0.Add(3 * time.Minute)},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if !strings.Contains(query, `FROM "cpu"`) || db != "telegraf" || user != "user" {
+		t.Errorf("Unexpected request. q=%s; db=%s; user=%s", query, db, user)
+	}
+	/* the value at 00:00 is out of the interval; null values are skipped */
+	values := result[v]
+	if len(values) != 2 {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+	if values[0].Key != "idle" || values[0].Value != 96.25 || !values[0].DateTime.Equal(t0.Add(time.Minute)) {
+		t.Errorf("Unexpected value: %v", values[0])
+	}
+}
+
+func TestRetrieveInfluxQLMean(t *testing.T) {
+	server := newServer(t, "testdata/influxql_mean.json", nil)
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, Language: InfluxQL, Field: "value", client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "cpu:usage_idle", Aggregation: &average}
+	to := t0.Add(5 * time.Minute)
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{{Var: v, From: t0, To: to}})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if values := result[v]; len(values) != 1 || values[0].Value != 96.25 || values[0].DateTime != to {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestRetrieveFlux(t *testing.T) {
+	var org, token, body string
+	server := newServer(t, "testdata/flux.csv", func(r *http.Request) {
+		org = r.URL.Query().Get("org")
+		token = r.Header.Get("Authorization")
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	})
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, Language: Flux, Org: "atos", Bucket: "telegraf", Field: "value",
+		Token: "secret", client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "cpu:usage_idle"}
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{
+		{Var: v, From: t0, To: t0.Add(5 * time.Minute)},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if org != "atos" || token != "Token secret" || !strings.HasPrefix(body, `from(bucket: "telegraf")`) {
+		t.Errorf("Unexpected request. org=%s; token=%s; body=%s", org, token, body)
+	}
+	values := result[v]
+	if len(values) != 3 {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+	if values[2].Value != 95.0 || !values[2].DateTime.Equal(t0.Add(3*time.Minute)) {
+		t.Errorf("Unexpected value: %v", values[2])
+	}
+}
+
+func TestRetrieveFluxMean(t *testing.T) {
+	server := newServer(t, "testdata/flux_mean.csv", nil)
+	defer server.Close()
+
+	r := Retriever{URL: server.URL, Language: Flux, Bucket: "telegraf", Field: "value", client: http.DefaultClient}
+	v := model.Variable{Name: "idle", Metric: "cpu:usage_idle", Aggregation: &average}
+	to := t0.Add(5 * time.Minute)
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{{Var: v, From: t0, To: to}})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if values := result[v]; len(values) != 1 || values[0].Value != 96.25 || values[0].DateTime != to {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestRetrieveErrors(t *testing.T) {
+	tests := []struct {
+		language string
+		file     string
+	}{
+		{InfluxQL, "testdata/influxql_error.json"},
+		{Flux, "testdata/flux_error.csv"},
+		{InfluxQL, ""},
+	}
+	for _, test := range tests {
+		server := newServer(t, test.file, nil)
+		r := Retriever{URL: server.URL, Language: test.language, Field: "value", client: http.DefaultClient}
+		v := model.Variable{Name: "idle", Metric: "cpu:usage_idle"}
+		result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{{Var: v, From: t0, To: t0.Add(time.Hour)}})
+		if err == nil {
+			t.Errorf("Expected error")
+		}
+		if _, ok := result[v]; ok {
+			t.Errorf("Not expected values on error response %s: %v", test.file, result)
+		}
+		server.Close()
+	}
+}
+
+/*
+newServer returns a server that replies with the content of a file, or with
+404 if file is empty
+*/
+func newServer(t *testing.T, file string, inspect func(r *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inspect != nil {
+			inspect(r)
+		}
+		if file == "" {
+			http.NotFound(w, r)
+			return
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		b, _ := ioutil.ReadAll(f)
+		w.Write(b)
+	}))
+}
@@ -34,8 +34,16 @@ type MonitoringAdapter interface {
 	// A new MonitoringAdapter, copy of current adapter, must be returned
 	Initialize(a *model.Agreement) MonitoringAdapter
 
-	// GetValues retrieve the metrics corresponding to the variables found in a guarantee
-	GetValues(gt model.Guarantee, vars []string, to time.Time) assessment_model.GuaranteeData
+	// GetValues retrieve the metrics corresponding to the variables found in a guarantee.
+	//
+	// An error means that the monitoring could not be queried, so the values may be incomplete.
+	GetValues(gt model.Guarantee, vars []string, to time.Time) (assessment_model.GuaranteeData, error)
+}
+
+// Pinger is implemented by adapters that can check the connectivity with the monitoring
+type Pinger interface {
+	// Ping returns an error if the monitoring is not reachable
+	Ping() error
 }
 
 // RetrievalItem contains the retrieval information for a variable
@@ -48,8 +56,20 @@ type RetrievalItem struct {
 	To        time.Time
 }
 
+// AgreementItems contains the retrieval items of the guarantee terms of an agreement
+type AgreementItems struct {
+	Agreement *model.Agreement
+	Items     []RetrievalItem
+}
+
 // EarlyRetriever is implemented by adapters that want to (and can) retrieve
-// all monitoring information in one query for efficiency reasons
+// the monitoring information of all the agreements of an assessment cycle at
+// once for efficiency reasons (e.g. sharing identical queries of several agreements).
 type EarlyRetriever interface {
-	RetrieveAllValues(items []RetrievalItem) []assessment_model.GuaranteeData
+	// RetrieveAllValues retrieves the values of the items of all the agreements.
+	//
+	// A new MonitoringAdapter, copy of current adapter, must be returned. Its
+	// GetValues must return the retrieved values when called with the same
+	// items; the rest of the values are retrieved as usual.
+	RetrieveAllValues(batch []AgreementItems) MonitoringAdapter
 }
@@ -28,9 +28,9 @@ func TestRetrieve(t *testing.T) {
 	f := retr.Retrieve()
 	now := time.Now()
 	items := assessment.BuildRetrievalItems(&a, a.Details.Guarantees[0], []string{"execution_time"}, now)
-	metrics := f(a, items)
+	metrics, err := f(a, items)
 
-	fmt.Printf("values=%v\n", metrics)
+	fmt.Printf("values=%v err=%v\n", metrics, err)
 }
 
 func TestRetrieve2(t *testing.T) {
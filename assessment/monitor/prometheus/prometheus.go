@@ -55,6 +55,7 @@ import (
 	"SLALite/assessment/monitor"
 	"SLALite/assessment/monitor/genericadapter"
 	"SLALite/model"
+	"SLALite/registry"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -79,6 +80,22 @@ const (
 	matrixType resultType = "matrix"
 )
 
+func init() {
+	genericadapter.RegisterRetriever(Name, func(config *viper.Viper) genericadapter.Retrieve {
+		return New(config).Retrieve()
+	},
+		registry.Property{Name: PrometheusURLPropertyName, Default: defaultURL, Description: "Prometheus URL"})
+	monitor.Register(Name, func(config *viper.Viper) (monitor.MonitoringAdapter, error) {
+		r := New(config)
+		ma, err := genericadapter.NewWithSources(config, Name, r.Retrieve(), genericadapter.Identity)
+		if err == nil {
+			ma.(*genericadapter.Adapter).Check = r.Ping
+		}
+		return ma, err
+	},
+		registry.Property{Name: PrometheusURLPropertyName, Default: defaultURL, Description: "Prometheus URL"})
+}
+
 // Retriever implements genericadapter.Retrieve
 type Retriever struct {
 	URL string
@@ -104,19 +121,39 @@ func logConfig(config *viper.Viper) {
 func (r Retriever) Retrieve() genericadapter.Retrieve {
 
 	return func(agreement model.Agreement,
-		items []monitor.RetrievalItem) map[model.Variable][]model.MetricValue {
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
 
 		rootURL := r.prometheusRoot(agreement)
 		result := make(map[model.Variable][]model.MetricValue)
-		for _, item := range items {			
+		errs := genericadapter.Errors{}
+		for _, item := range items {
 			url := fmt.Sprintf("%s/api/v1/query?query=%s",
 				rootURL, item.Var.Metric)
-			query := r.request(url)
+			query, err := r.request(url)
+			if err != nil {
+				log.Errorf("Error retrieving %s of agreement %s: %s", item.Var.Name, agreement.Id, err.Error())
+				errs = append(errs, err)
+				continue
+			}
 			aux := translateVector(query, item.Var.Name)
 			result[item.Var] = aux
 		}
-		return result
+		return result, errs.Err()
+	}
+}
+
+// Ping checks that Prometheus is ready to serve queries
+func (r Retriever) Ping() error {
+	url := r.URL + "/-/ready"
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s GET %s", resp.Status, url)
 	}
+	return nil
 }
 
 func (r Retriever) prometheusRoot(agreement model.Agreement) string {
@@ -126,25 +163,24 @@ func (r Retriever) prometheusRoot(agreement model.Agreement) string {
 	return r.URL
 }
 
-func (r Retriever) request(url string) query {
+func (r Retriever) request(url string) (query, error) {
 
+	var result query
 	resp, err := http.Get(url)
 	if err != nil {
-		log.Error(err)
-		return query{}
+		return result, err
 	}
 	defer resp.Body.Close()
 
-	var result query
-	if resp.Status[0] != '2' { // StatusCode < 200 || resp.StatusCode >= 300
-		log.Errorf("%s GET %s", resp.Status, url)
-	}
 	log.Infof("%d %s", resp.StatusCode, url)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("%s GET %s", resp.Status, url)
+	}
 	err = parse(resp.Body, &result)
 	if err != nil {
-		log.Errorf("Error decoding prometheus output: %s", err.Error())
+		return result, fmt.Errorf("error decoding prometheus output of %s: %s", url, err.Error())
 	}
-	return result
+	return result, nil
 }
 
 func parse(r io.Reader, target *query) error {
@@ -17,7 +17,11 @@ limitations under the License.
 package prometheus
 
 import (
+	"SLALite/assessment/monitor"
+	"SLALite/assessment/monitor/genericadapter"
 	"SLALite/model"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -130,6 +134,61 @@ func TestPrometheusRoot(t *testing.T) {
 
 }
 
+func TestRetrieveErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("query") {
+		case "go_memstats_frees_total":
+			http.ServeFile(w, r, "testdata/vector.json")
+		case "unavailable":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("not json"))
+		}
+	}))
+	defer server.Close()
+
+	r := Retriever{URL: server.URL}
+	ok := model.Variable{Name: "frees", Metric: "go_memstats_frees_total"}
+	unavailable := model.Variable{Name: "u", Metric: "unavailable"}
+	wrong := model.Variable{Name: "w", Metric: "wrong"}
+	result, err := r.Retrieve()(model.Agreement{}, []monitor.RetrievalItem{
+		{Var: ok, To: time.Now()},
+		{Var: unavailable, To: time.Now()},
+		{Var: wrong, To: time.Now()},
+	})
+	if values := result[ok]; len(values) != 1 || values[0].Value != 862037.0 {
+		t.Errorf("Unexpected values: %v", values)
+	}
+	if _, found := result[unavailable]; found {
+		t.Errorf("Not expected values of non 2xx response: %v", result)
+	}
+	if _, found := result[wrong]; found {
+		t.Errorf("Not expected values of wrong response: %v", result)
+	}
+	if errs, isErrs := err.(genericadapter.Errors); !isErrs || len(errs) != 2 {
+		t.Errorf("Expected two errors. Actual: %v", err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	ready := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/-/ready" || !ready {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	r := Retriever{URL: server.URL}
+	if err := r.Ping(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	ready = false
+	if err := r.Ping(); err == nil {
+		t.Errorf("Expected error of not ready Prometheus")
+	}
+}
+
 func readFile(path string) (query, error) {
 	var result query
 
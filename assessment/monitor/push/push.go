@@ -0,0 +1,319 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package push contains a retriever of metric values that are pushed to SLALite
+through the REST interface, instead of being pulled from a monitoring system.
+It is useful for batch jobs and serverless functions that cannot be scraped.
+
+The pushed values are kept in memory in a Buffer, a series per agreement and
+metric. Each series keeps the last `pushCapacity` values; the older ones are
+discarded. Values pushed without an agreement are shared by all the
+agreements, and are used when the agreement has no series of the metric.
+
+The Buffer keeps up to `pushMaxSeries` series: the series without pushes in
+`pushSeriesTTL` are evicted, and so are the series of a deleted agreement when
+the Buffer is subscribed to the lifecycle events.
+*/
+package push
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/assessment/monitor/genericadapter"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/registry"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this retriever
+	Name = "push"
+
+	// CapacityPropertyName is the config property name of the maximum number of values of a series
+	CapacityPropertyName = "pushCapacity"
+
+	// DefaultCapacity is the default value of CapacityPropertyName
+	DefaultCapacity = 1000
+
+	// MaxSeriesPropertyName is the config property name of the maximum number of series
+	MaxSeriesPropertyName = "pushMaxSeries"
+
+	// DefaultMaxSeries is the default value of MaxSeriesPropertyName
+	DefaultMaxSeries = 10000
+
+	// SeriesTTLPropertyName is the config property name of the time a series is
+	// kept without pushes
+	SeriesTTLPropertyName = "pushSeriesTTL"
+
+	// DefaultSeriesTTL is the default value of SeriesTTLPropertyName
+	DefaultSeriesTTL = 24 * time.Hour
+)
+
+// ErrTooManySeries is returned when samples would exceed the maximum number of series
+var ErrTooManySeries = errors.New("too many series in the push buffer")
+
+// Sample is a pushed metric value. AgreementID is empty if the value is
+// shared by all the agreements.
+type Sample struct {
+	AgreementID string `json:"agreement_id,omitempty"`
+	model.MetricValue
+}
+
+type seriesKey struct {
+	agreementID string
+	metric      string
+}
+
+// Buffer stores the pushed values. It is safe for concurrent use.
+type Buffer struct {
+	mu        sync.RWMutex
+	capacity  int
+	maxSeries int
+	ttl       time.Duration
+	series    map[seriesKey][]model.MetricValue
+	pushed    map[seriesKey]time.Time
+}
+
+// Retriever implements genericadapter.Retrieve over a Buffer
+type Retriever struct {
+	Buffer *Buffer
+}
+
+type valError struct {
+	msg string
+}
+
+func (e *valError) Error() string {
+	return e.msg
+}
+
+func (e *valError) IsErrValidation() bool {
+	return true
+}
+
+var defaultBuffer = NewBuffer(DefaultCapacity)
+
+func init() {
+	schema := []registry.Property{
+		{Name: CapacityPropertyName, Default: DefaultCapacity, Description: "Maximum number of values kept per agreement and metric"},
+		{Name: MaxSeriesPropertyName, Default: DefaultMaxSeries, Description: "Maximum number of series (agreement and metric)"},
+		{Name: SeriesTTLPropertyName, Default: DefaultSeriesTTL, Description: "Time a series is kept without pushes"},
+	}
+	monitor.Register(Name, func(config *viper.Viper) (monitor.MonitoringAdapter, error) {
+		return genericadapter.NewWithSources(config, Name, New(config).Retrieve(), genericadapter.Identity)
+	}, schema...)
+	genericadapter.RegisterScopedRetriever(Name, func(config *viper.Viper) genericadapter.Retrieve {
+		return New(config).Retrieve()
+	}, schema...)
+}
+
+// Default returns the Buffer shared by the REST interface and the push retrievers
+func Default() *Buffer {
+	return defaultBuffer
+}
+
+// New constructs a retriever of the default Buffer from a Viper configuration
+func New(config *viper.Viper) Retriever {
+
+	config.SetDefault(CapacityPropertyName, DefaultCapacity)
+	config.SetDefault(MaxSeriesPropertyName, DefaultMaxSeries)
+	config.SetDefault(SeriesTTLPropertyName, DefaultSeriesTTL)
+	log.Infof("Push retriever configuration:\n"+
+		"\tCapacity: %d\n"+
+		"\tMax series: %d\n"+
+		"\tSeries TTL: %v",
+		config.GetInt(CapacityPropertyName),
+		config.GetInt(MaxSeriesPropertyName),
+		config.GetDuration(SeriesTTLPropertyName))
+
+	defaultBuffer.SetCapacity(config.GetInt(CapacityPropertyName))
+	defaultBuffer.SetLimits(config.GetInt(MaxSeriesPropertyName), config.GetDuration(SeriesTTLPropertyName))
+	return Retriever{
+		Buffer: defaultBuffer,
+	}
+}
+
+// NewBuffer returns an empty Buffer that keeps up to capacity values per series,
+// with the default limits of series
+func NewBuffer(capacity int) *Buffer {
+	return &Buffer{
+		capacity:  capacity,
+		maxSeries: DefaultMaxSeries,
+		ttl:       DefaultSeriesTTL,
+		series:    make(map[seriesKey][]model.MetricValue),
+		pushed:    make(map[seriesKey]time.Time),
+	}
+}
+
+// SetCapacity changes the maximum number of values per series. Series
+// longer than capacity are trimmed on the next push.
+func (b *Buffer) SetCapacity(capacity int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.capacity = capacity
+}
+
+// SetLimits changes the maximum number of series and the time a series is kept
+// without pushes. Zero values mean no limit.
+func (b *Buffer) SetLimits(maxSeries int, ttl time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxSeries = maxSeries
+	b.ttl = ttl
+}
+
+// Validate checks that samples can be added to a buffer
+func Validate(samples []Sample) error {
+	for i, s := range samples {
+		if s.Key == "" {
+			return &valError{msg: fmt.Sprintf("Sample %d: key is required", i)}
+		}
+		switch s.Value.(type) {
+		case float64, bool:
+		default:
+			return &valError{msg: fmt.Sprintf("Sample %d: value must be a number or a boolean", i)}
+		}
+	}
+	return nil
+}
+
+// Add stores samples in the buffer. Samples without datetime are stamped with now.
+//
+// The series without pushes in the TTL are evicted first. If the new series of
+// the samples exceed the maximum number of series, nothing is stored and
+// ErrTooManySeries is returned.
+func (b *Buffer) Add(now time.Time, samples ...Sample) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.evictIdle(now)
+	if b.maxSeries > 0 {
+		added := make(map[seriesKey]bool)
+		for _, s := range samples {
+			k := seriesKey{agreementID: s.AgreementID, metric: s.Key}
+			if _, ok := b.series[k]; !ok {
+				added[k] = true
+			}
+		}
+		if len(b.series)+len(added) > b.maxSeries {
+			return ErrTooManySeries
+		}
+	}
+	for _, s := range samples {
+		if s.DateTime.IsZero() {
+			s.DateTime = now
+		}
+		k := seriesKey{agreementID: s.AgreementID, metric: s.Key}
+		b.series[k] = b.insert(b.series[k], s.MetricValue)
+		b.pushed[k] = now
+	}
+	return nil
+}
+
+// evictIdle removes the series without pushes in the TTL
+func (b *Buffer) evictIdle(now time.Time) {
+	if b.ttl <= 0 {
+		return
+	}
+	for k, pushed := range b.pushed {
+		if now.Sub(pushed) > b.ttl {
+			delete(b.series, k)
+			delete(b.pushed, k)
+		}
+	}
+}
+
+// RemoveAgreement removes the series of an agreement
+func (b *Buffer) RemoveAgreement(agreementID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for k := range b.series {
+		if k.agreementID == agreementID && agreementID != "" {
+			delete(b.series, k)
+			delete(b.pushed, k)
+		}
+	}
+}
+
+// NotifyEvent implements events.Listener, removing the series of the deleted agreements
+func (b *Buffer) NotifyEvent(e events.Event) {
+	if e.Type == events.AgreementDeleted {
+		b.RemoveAgreement(e.AgreementID)
+	}
+}
+
+// insert adds value to series, keeping it sorted by datetime and trimmed to capacity
+func (b *Buffer) insert(series []model.MetricValue, value model.MetricValue) []model.MetricValue {
+	i := sort.Search(len(series), func(i int) bool {
+		return series[i].DateTime.After(value.DateTime)
+	})
+	series = append(series, model.MetricValue{})
+	copy(series[i+1:], series[i:])
+	series[i] = value
+
+	if b.capacity > 0 && len(series) > b.capacity {
+		series = append(series[:0:0], series[len(series)-b.capacity:]...)
+	}
+	return series
+}
+
+// Values returns the values of a metric of an agreement in the (from, to] interval.
+// If the agreement has no series of the metric, the shared series is used.
+func (b *Buffer) Values(agreementID string, metric string, from, to time.Time) []model.MetricValue {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	series, ok := b.series[seriesKey{agreementID: agreementID, metric: metric}]
+	if !ok {
+		series = b.series[seriesKey{metric: metric}]
+	}
+	result := make([]model.MetricValue, 0)
+	for _, v := range series {
+		if v.DateTime.After(from) && !v.DateTime.After(to) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Retrieve implements genericadapter.Retrieve.
+//
+// It returns the values of each variable metric in the (From, To] interval of the item.
+func (r Retriever) Retrieve() genericadapter.Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		result := make(map[model.Variable][]model.MetricValue)
+		for _, item := range items {
+			values := r.Buffer.Values(agreement.Id, item.Var.Metric, item.From, item.To)
+			for i := range values {
+				values[i].Key = item.Var.Name
+			}
+			result[item.Var] = values
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,110 @@
+package push
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/events"
+	"SLALite/model"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func sample(agreementID, key string, value float64, t time.Time) Sample {
+	return Sample{
+		AgreementID: agreementID,
+		MetricValue: model.MetricValue{Key: key, Value: value, DateTime: t},
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	b := NewBuffer(3)
+	b.Add(t0,
+		sample("a01", "m", 2, t0.Add(2*time.Minute)),
+		sample("a01", "m", 1, t0.Add(time.Minute)),
+		sample("a01", "m", 4, t0.Add(4*time.Minute)),
+		sample("a01", "m", 3, t0.Add(3*time.Minute)),
+		sample("", "m", 10, time.Time{}),
+		sample("", "m", 11, t0.Add(time.Minute)))
+
+	values := b.Values("a01", "m", t0, t0.Add(time.Hour))
+	if len(values) != 3 || values[0].Value != 2.0 || values[2].Value != 4.0 {
+		t.Errorf("Unexpected values: %v", values)
+	}
+
+	values = b.Values("a01", "m", t0.Add(2*time.Minute), t0.Add(3*time.Minute))
+	if len(values) != 1 || values[0].Value != 3.0 {
+		t.Errorf("Unexpected values: %v", values)
+	}
+
+	values = b.Values("a02", "m", t0.Add(-time.Minute), t0.Add(time.Hour))
+	if len(values) != 2 || values[0].Value != 10.0 || !values[0].DateTime.Equal(t0) {
+		t.Errorf("Unexpected shared values: %v", values)
+	}
+
+	if values = b.Values("a01", "other", t0, t0.Add(time.Hour)); len(values) != 0 {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
+func TestBufferLimits(t *testing.T) {
+	b := NewBuffer(DefaultCapacity)
+	b.SetLimits(2, time.Hour)
+
+	if err := b.Add(t0, sample("a01", "m", 1, t0), sample("a02", "m", 1, t0)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.Add(t0, sample("a01", "m", 2, t0.Add(time.Minute))); err != nil {
+		t.Errorf("Unexpected error adding to an existing series: %v", err)
+	}
+	if err := b.Add(t0, sample("a03", "m", 1, t0)); err != ErrTooManySeries {
+		t.Errorf("Expected too many series. Actual: %v", err)
+	}
+	if values := b.Values("a03", "m", t0.Add(-time.Minute), t0); len(values) != 0 {
+		t.Errorf("Unexpected values of rejected series: %v", values)
+	}
+
+	/* a02 is idle after an hour; a01 is pushed again */
+	b.Add(t0.Add(30*time.Minute), sample("a01", "m", 3, t0.Add(30*time.Minute)))
+	if err := b.Add(t0.Add(61*time.Minute), sample("a03", "m", 1, t0)); err != nil {
+		t.Errorf("Expected idle series evicted. Actual: %v", err)
+	}
+	if values := b.Values("a02", "m", t0.Add(-time.Minute), t0); len(values) != 0 {
+		t.Errorf("Unexpected values of evicted series: %v", values)
+	}
+
+	b.NotifyEvent(events.Event{Type: events.AgreementDeleted, AgreementID: "a01"})
+	if values := b.Values("a01", "m", t0.Add(-time.Minute), t0.Add(time.Hour)); len(values) != 0 {
+		t.Errorf("Unexpected values of deleted agreement: %v", values)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]Sample{sample("", "m", 1, t0)}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := Validate([]Sample{{MetricValue: model.MetricValue{Value: 1.0}}}); !model.IsErrValidation(err) {
+		t.Errorf("Expected validation error. Actual: %v", err)
+	}
+	if err := Validate([]Sample{{MetricValue: model.MetricValue{Key: "m", Value: "a"}}}); !model.IsErrValidation(err) {
+		t.Errorf("Expected validation error. Actual: %v", err)
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	b := NewBuffer(DefaultCapacity)
+	b.Add(t0, sample("a01", "execution_time", 50, t0.Add(time.Minute)))
+
+	a := model.Agreement{Id: "a01"}
+	v := model.Variable{Name: "time", Metric: "execution_time"}
+	result, err := Retriever{Buffer: b}.Retrieve()(a, []monitor.RetrievalItem{
+		{Var: v, From: t0, To: t0.Add(5 * time.Minute)},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	values := result[v]
+	if len(values) != 1 || values[0].Key != "time" || values[0].Value != 50.0 {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
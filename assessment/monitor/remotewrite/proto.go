@@ -0,0 +1,224 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewrite
+
+/*
+This file decodes the protobuf messages of the remote write protocol
+(prometheus/prompb/remote.proto and types.proto). Only the fields used by
+SLALite are decoded; the rest (metadata, exemplars...) are skipped.
+
+	message WriteRequest { repeated TimeSeries timeseries = 1; ... }
+	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; ... }
+	message Label { string name = 1; string value = 2; }
+	message Sample { double value = 1; int64 timestamp = 2; }
+*/
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+// TimeSeries is a series of samples identified by its labels
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// Label is a name/value pair of a series. The metric name is the __name__ label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value of a series. Timestamp is in milliseconds since epoch.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+type decoder struct {
+	buf []byte
+}
+
+func (d *decoder) more() bool {
+	return len(d.buf) > 0
+}
+
+func (d *decoder) varint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+func (d *decoder) fixed64() (uint64, error) {
+	if len(d.buf) < 8 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint64(d.buf)
+	d.buf = d.buf[8:]
+	return v, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.buf)) < n {
+		return nil, errTruncated
+	}
+	v := d.buf[:n]
+	d.buf = d.buf[n:]
+	return v, nil
+}
+
+// key returns the field number and wire type of the next field
+func (d *decoder) key() (int, int, error) {
+	k, err := d.varint()
+	return int(k >> 3), int(k & 7), err
+}
+
+// skip skips the value of a field of the wire type
+func (d *decoder) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = d.varint()
+	case wireFixed64:
+		_, err = d.fixed64()
+	case wireBytes:
+		_, err = d.bytes()
+	case wireFixed32:
+		if len(d.buf) < 4 {
+			return errTruncated
+		}
+		d.buf = d.buf[4:]
+	default:
+		err = errors.New("unsupported protobuf wire type")
+	}
+	return err
+}
+
+// fields calls f with each field of the message in buf. If f returns false,
+// the field value is skipped.
+func fields(buf []byte, f func(d *decoder, field int, wire int) (bool, error)) error {
+	d := &decoder{buf: buf}
+	for d.more() {
+		field, wire, err := d.key()
+		if err != nil {
+			return err
+		}
+		read, err := f(d, field, wire)
+		if err != nil {
+			return err
+		}
+		if !read {
+			if err := d.skip(wire); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DecodeWriteRequest decodes an uncompressed WriteRequest message
+func DecodeWriteRequest(buf []byte) ([]TimeSeries, error) {
+	result := make([]TimeSeries, 0)
+	err := fields(buf, func(d *decoder, field int, wire int) (bool, error) {
+		if field != 1 || wire != wireBytes {
+			return false, nil
+		}
+		b, err := d.bytes()
+		if err != nil {
+			return true, err
+		}
+		ts, err := decodeTimeSeries(b)
+		result = append(result, ts)
+		return true, err
+	})
+	return result, err
+}
+
+func decodeTimeSeries(buf []byte) (TimeSeries, error) {
+	var ts TimeSeries
+	err := fields(buf, func(d *decoder, field int, wire int) (bool, error) {
+		if wire != wireBytes || (field != 1 && field != 2) {
+			return false, nil
+		}
+		b, err := d.bytes()
+		if err != nil {
+			return true, err
+		}
+		if field == 1 {
+			l, err := decodeLabel(b)
+			ts.Labels = append(ts.Labels, l)
+			return true, err
+		}
+		s, err := decodeSample(b)
+		ts.Samples = append(ts.Samples, s)
+		return true, err
+	})
+	return ts, err
+}
+
+func decodeLabel(buf []byte) (Label, error) {
+	var l Label
+	err := fields(buf, func(d *decoder, field int, wire int) (bool, error) {
+		if wire != wireBytes || (field != 1 && field != 2) {
+			return false, nil
+		}
+		b, err := d.bytes()
+		if field == 1 {
+			l.Name = string(b)
+		} else {
+			l.Value = string(b)
+		}
+		return true, err
+	})
+	return l, err
+}
+
+func decodeSample(buf []byte) (Sample, error) {
+	var s Sample
+	err := fields(buf, func(d *decoder, field int, wire int) (bool, error) {
+		switch {
+		case field == 1 && wire == wireFixed64:
+			v, err := d.fixed64()
+			s.Value = math.Float64frombits(v)
+			return true, err
+		case field == 2 && wire == wireVarint:
+			v, err := d.varint()
+			s.Timestamp = int64(v)
+			return true, err
+		}
+		return false, nil
+	})
+	return s, err
+}
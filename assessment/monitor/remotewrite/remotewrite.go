@@ -0,0 +1,496 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package remotewrite contains a receiver of the Prometheus remote write
+protocol and a retriever of the received samples. Prometheus or any agent
+that supports remote write streams its samples to SLALite, so SLALite does not
+need to query Prometheus for each variable of each agreement.
+
+The samples are kept in memory in a Buffer, by series. Each series keeps the
+last `remoteWriteCapacity` samples; the older ones are discarded. The Buffer
+keeps up to `remoteWriteMaxSeries` series (the samples of new series beyond the
+limit are dropped), and evicts the series without samples in
+`remoteWriteSeriesTTL` and the ones that no agreement has selected in that time.
+The requests are limited to `remoteWriteMaxBytes`, compressed and uncompressed.
+
+The metric of a variable is a series selector with the syntax of Prometheus,
+but only with equality matchers:
+
+	http_requests_total{job="api",code="200"}
+
+If several series match the selector, the key of each value is
+"<variable>{<series labels>}".
+*/
+package remotewrite
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/assessment/monitor/genericadapter"
+	"SLALite/model"
+	"SLALite/registry"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this retriever
+	Name = "remotewrite"
+
+	// CapacityPropertyName is the config property name of the maximum number of samples of a series
+	CapacityPropertyName = "remoteWriteCapacity"
+
+	// DefaultCapacity is the default value of CapacityPropertyName
+	DefaultCapacity = 1000
+
+	// MaxSeriesPropertyName is the config property name of the maximum number of series
+	MaxSeriesPropertyName = "remoteWriteMaxSeries"
+
+	// DefaultMaxSeries is the default value of MaxSeriesPropertyName
+	DefaultMaxSeries = 10000
+
+	// SeriesTTLPropertyName is the config property name of the time a series is
+	// kept without samples or without being selected
+	SeriesTTLPropertyName = "remoteWriteSeriesTTL"
+
+	// DefaultSeriesTTL is the default value of SeriesTTLPropertyName
+	DefaultSeriesTTL = time.Hour
+
+	// MaxBytesPropertyName is the config property name of the maximum size of a request
+	MaxBytesPropertyName = "remoteWriteMaxBytes"
+
+	// DefaultMaxBytes is the default value of MaxBytesPropertyName
+	DefaultMaxBytes = 32 << 20
+
+	nameLabel = "__name__"
+
+	// sweepPeriod is the minimum time between evictions
+	sweepPeriod = time.Minute
+)
+
+// Selector selects the series whose labels have the values in the selector
+type Selector map[string]string
+
+type selectorUse struct {
+	selector Selector
+	used     time.Time
+}
+
+type series struct {
+	labels  map[string]string
+	samples []Sample
+	created time.Time
+	written time.Time
+}
+
+// Buffer stores the received series. It is safe for concurrent use.
+// It implements http.Handler to receive remote write requests.
+type Buffer struct {
+	mu        sync.RWMutex
+	capacity  int
+	maxSeries int
+	ttl       time.Duration
+	maxBytes  int64
+	series    map[string]*series
+	// selectors are the selectors used in Select, by id
+	selectors map[string]selectorUse
+	swept     time.Time
+	now       func() time.Time
+}
+
+// Retriever implements genericadapter.Retrieve over a Buffer
+type Retriever struct {
+	Buffer *Buffer
+}
+
+var defaultBuffer = NewBuffer(DefaultCapacity)
+
+func init() {
+	schema := []registry.Property{
+		{Name: CapacityPropertyName, Default: DefaultCapacity, Description: "Maximum number of samples kept per series"},
+		{Name: MaxSeriesPropertyName, Default: DefaultMaxSeries, Description: "Maximum number of series"},
+		{Name: SeriesTTLPropertyName, Default: DefaultSeriesTTL, Description: "Time a series is kept without samples or without being selected"},
+		{Name: MaxBytesPropertyName, Default: DefaultMaxBytes, Description: "Maximum size of a request"},
+	}
+	monitor.Register(Name, func(config *viper.Viper) (monitor.MonitoringAdapter, error) {
+		return genericadapter.NewWithSources(config, Name, New(config).Retrieve(), genericadapter.Identity)
+	}, schema...)
+	genericadapter.RegisterRetriever(Name, func(config *viper.Viper) genericadapter.Retrieve {
+		return New(config).Retrieve()
+	}, schema...)
+}
+
+// Default returns the Buffer shared by the REST interface and the remote write retrievers
+func Default() *Buffer {
+	return defaultBuffer
+}
+
+// New constructs a retriever of the default Buffer from a Viper configuration
+func New(config *viper.Viper) Retriever {
+
+	config.SetDefault(CapacityPropertyName, DefaultCapacity)
+	config.SetDefault(MaxSeriesPropertyName, DefaultMaxSeries)
+	config.SetDefault(SeriesTTLPropertyName, DefaultSeriesTTL)
+	config.SetDefault(MaxBytesPropertyName, DefaultMaxBytes)
+	log.Infof("Remote write retriever configuration:\n"+
+		"\tCapacity: %d\n"+
+		"\tMax series: %d\n"+
+		"\tSeries TTL: %v\n"+
+		"\tMax bytes: %d",
+		config.GetInt(CapacityPropertyName),
+		config.GetInt(MaxSeriesPropertyName),
+		config.GetDuration(SeriesTTLPropertyName),
+		config.GetInt64(MaxBytesPropertyName))
+
+	defaultBuffer.SetCapacity(config.GetInt(CapacityPropertyName))
+	defaultBuffer.SetLimits(config.GetInt(MaxSeriesPropertyName), config.GetDuration(SeriesTTLPropertyName),
+		config.GetInt64(MaxBytesPropertyName))
+	return Retriever{
+		Buffer: defaultBuffer,
+	}
+}
+
+// NewBuffer returns an empty Buffer that keeps up to capacity samples per series,
+// with the default limits
+func NewBuffer(capacity int) *Buffer {
+	return &Buffer{
+		capacity:  capacity,
+		maxSeries: DefaultMaxSeries,
+		ttl:       DefaultSeriesTTL,
+		maxBytes:  DefaultMaxBytes,
+		series:    make(map[string]*series),
+		selectors: make(map[string]selectorUse),
+		now:       time.Now,
+	}
+}
+
+// SetCapacity changes the maximum number of samples per series. Series
+// longer than capacity are trimmed on the next write.
+func (b *Buffer) SetCapacity(capacity int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.capacity = capacity
+}
+
+// SetLimits changes the maximum number of series, the time a series is kept
+// without samples or without being selected and the maximum size of a request.
+// Zero values mean no limit.
+func (b *Buffer) SetLimits(maxSeries int, ttl time.Duration, maxBytes int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxSeries = maxSeries
+	b.ttl = ttl
+	b.maxBytes = maxBytes
+}
+
+// ServeHTTP receives a snappy compressed WriteRequest
+func (b *Buffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.RLock()
+	maxBytes := b.maxBytes
+	b.mu.RUnlock()
+
+	body := r.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	compressed, err := ioutil.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if n, err := snappy.DecodedLen(compressed); err == nil && maxBytes > 0 && int64(n) > maxBytes {
+		http.Error(w, fmt.Sprintf("uncompressed request larger than %d bytes", maxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tss, err := DecodeWriteRequest(buf)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.Add(tss...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Add stores the samples of the series. NaN values (e.g. staleness markers) are ignored.
+// The samples of new series beyond the maximum number of series are dropped.
+func (b *Buffer) Add(tss ...TimeSeries) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.sweep(now)
+	dropped := 0
+	for _, ts := range tss {
+		labels := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			labels[l.Name] = l.Value
+		}
+		id := seriesID(labels)
+		s, ok := b.series[id]
+		if !ok {
+			if b.maxSeries > 0 && len(b.series) >= b.maxSeries {
+				dropped++
+				continue
+			}
+			s = &series{labels: labels, created: now}
+			b.series[id] = s
+		}
+		s.written = now
+		for _, sample := range ts.Samples {
+			if math.IsNaN(sample.Value) {
+				continue
+			}
+			b.insert(s, sample)
+		}
+	}
+	if dropped > 0 {
+		log.Warnf("Remote write: %d series dropped; the buffer has %d series", dropped, len(b.series))
+	}
+}
+
+// sweep evicts, at most once per sweepPeriod, the series without samples in the
+// TTL and the series older than the TTL that no selector used in the TTL matches
+func (b *Buffer) sweep(now time.Time) {
+	if b.ttl <= 0 || now.Sub(b.swept) < sweepPeriod {
+		return
+	}
+	b.swept = now
+	selectors := make([]Selector, 0, len(b.selectors))
+	for id, use := range b.selectors {
+		if now.Sub(use.used) > b.ttl {
+			delete(b.selectors, id)
+			continue
+		}
+		selectors = append(selectors, use.selector)
+	}
+	for id, s := range b.series {
+		if now.Sub(s.written) > b.ttl || (now.Sub(s.created) > b.ttl && !selected(s, selectors)) {
+			delete(b.series, id)
+		}
+	}
+}
+
+// selected returns if one of the selectors matches a series
+func selected(s *series, selectors []Selector) bool {
+	for _, selector := range selectors {
+		if selector.matches(s.labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// insert adds a sample to a series, keeping it sorted by timestamp and trimmed to capacity
+func (b *Buffer) insert(s *series, sample Sample) {
+	i := sort.Search(len(s.samples), func(i int) bool {
+		return s.samples[i].Timestamp > sample.Timestamp
+	})
+	s.samples = append(s.samples, Sample{})
+	copy(s.samples[i+1:], s.samples[i:])
+	s.samples[i] = sample
+
+	if b.capacity > 0 && len(s.samples) > b.capacity {
+		s.samples = append(s.samples[:0:0], s.samples[len(s.samples)-b.capacity:]...)
+	}
+}
+
+// Select returns the samples in the (from, to] interval of the series that
+// match the selector, by series id. The selector keeps its series in the buffer.
+func (b *Buffer) Select(selector Selector, from, to time.Time) map[string][]Sample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.selectors[seriesID(selector)] = selectorUse{selector: selector, used: b.now()}
+
+	fromMs, toMs := timestamp(from), timestamp(to)
+	result := make(map[string][]Sample)
+	for id, s := range b.series {
+		if !selector.matches(s.labels) {
+			continue
+		}
+		samples := make([]Sample, 0)
+		for _, sample := range s.samples {
+			if sample.Timestamp > fromMs && sample.Timestamp <= toMs {
+				samples = append(samples, sample)
+			}
+		}
+		result[id] = samples
+	}
+	return result
+}
+
+// Retrieve implements genericadapter.Retrieve.
+//
+// It returns the values of the series selected by each variable metric in
+// the (From, To] interval of the item.
+func (r Retriever) Retrieve() genericadapter.Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		result := make(map[model.Variable][]model.MetricValue)
+		errs := genericadapter.Errors{}
+		for _, item := range items {
+			selector, err := ParseSelector(item.Var.Metric)
+			if err != nil {
+				log.Errorf("Error in metric of %s of agreement %s: %s", item.Var.Name, agreement.Id, err.Error())
+				errs = append(errs, err)
+				continue
+			}
+			selected := r.Buffer.Select(selector, item.From, item.To)
+			ids := make([]string, 0, len(selected))
+			for id := range selected {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			values := make([]model.MetricValue, 0)
+			for _, id := range ids {
+				key := item.Var.Name
+				if len(ids) > 1 {
+					key = item.Var.Name + id[strings.Index(id, "{"):]
+				}
+				for _, sample := range selected[id] {
+					values = append(values, model.MetricValue{
+						Key:      key,
+						Value:    sample.Value,
+						DateTime: time.Unix(0, sample.Timestamp*int64(time.Millisecond)),
+					})
+				}
+			}
+			result[item.Var] = values
+		}
+		return result, errs.Err()
+	}
+}
+
+func timestamp(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// seriesID returns the identifier of a series: name{label="value",...}, sorted by label name
+func seriesID(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		if name != nameLabel {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(labels[nameLabel])
+	sb.WriteString("{")
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(name)
+		sb.WriteString("=")
+		sb.WriteString(strconv.Quote(labels[name]))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (s Selector) matches(labels map[string]string) bool {
+	for name, value := range s {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSelector parses a series selector like name{label="value",...}.
+// The name or the labels may be omitted, but not both.
+func ParseSelector(s string) (Selector, error) {
+	s = strings.TrimSpace(s)
+	result := make(Selector)
+
+	i := strings.Index(s, "{")
+	name := s
+	if i >= 0 {
+		name = strings.TrimSpace(s[:i])
+	}
+	if name != "" {
+		result[nameLabel] = name
+	}
+	if i < 0 {
+		if name == "" {
+			return nil, fmt.Errorf("empty selector")
+		}
+		return result, nil
+	}
+	if !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("missing '}' in selector %s", s)
+	}
+
+	rest := strings.TrimSpace(s[i+1 : len(s)-1])
+	for rest != "" {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("missing '=' in selector %s", s)
+		}
+		label := strings.TrimSpace(rest[:eq])
+		rest = strings.TrimSpace(rest[eq+1:])
+		quoted := quotedPrefix(rest)
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of label %s in selector %s", label, s)
+		}
+		result[label] = value
+		rest = strings.TrimSpace(rest[len(quoted):])
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, ","))
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+	return result, nil
+}
+
+// quotedPrefix returns the double quoted string at the start of s, or "" if
+// s does not start with a complete quoted string
+func quotedPrefix(s string) string {
+	if !strings.HasPrefix(s, `"`) {
+		return ""
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1]
+		}
+	}
+	return ""
+}
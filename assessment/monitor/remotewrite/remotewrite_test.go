@@ -0,0 +1,260 @@
+package remotewrite
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+)
+
+var t0 = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func ms(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func newSeries(name string, labels map[string]string, samples ...Sample) TimeSeries {
+	ts := TimeSeries{Labels: []Label{{Name: nameLabel, Value: name}}, Samples: samples}
+	for k, v := range labels {
+		ts.Labels = append(ts.Labels, Label{Name: k, Value: v})
+	}
+	return ts
+}
+
+/*
+ * Minimal protobuf encoder of WriteRequest, to test the decoder
+ */
+func appendKey(b []byte, field, wire int) []byte {
+	return appendVarint(b, uint64(field<<3|wire))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutUvarint(buf, v)]...)
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = appendKey(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func encode(tss []TimeSeries) []byte {
+	var req []byte
+	for _, ts := range tss {
+		var bts []byte
+		for _, l := range ts.Labels {
+			var bl []byte
+			bl = appendBytes(bl, 1, []byte(l.Name))
+			bl = appendBytes(bl, 2, []byte(l.Value))
+			bts = appendBytes(bts, 1, bl)
+		}
+		for _, s := range ts.Samples {
+			bs := appendKey(nil, 1, wireFixed64)
+			v := make([]byte, 8)
+			binary.LittleEndian.PutUint64(v, math.Float64bits(s.Value))
+			bs = append(bs, v...)
+			bs = appendKey(bs, 2, wireVarint)
+			bs = appendVarint(bs, uint64(s.Timestamp))
+			bts = appendBytes(bts, 2, bs)
+		}
+		// unknown field (exemplars) must be skipped
+		bts = appendBytes(bts, 3, []byte{})
+		req = appendBytes(req, 1, bts)
+	}
+	// unknown field (metadata) must be skipped
+	req = appendBytes(req, 3, []byte("metadata"))
+	return req
+}
+
+func TestDecodeWriteRequest(t *testing.T) {
+	expected := []TimeSeries{
+		{
+			Labels:  []Label{{Name: nameLabel, Value: "up"}, {Name: "job", Value: "api"}},
+			Samples: []Sample{{Value: 1, Timestamp: ms(t0)}, {Value: 0.5, Timestamp: ms(t0) + 1000}},
+		},
+	}
+	actual, err := DecodeWriteRequest(encode(expected))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %v. Actual: %v", expected, actual)
+	}
+
+	buf := encode(expected)
+	if _, err := DecodeWriteRequest(buf[:len(buf)-15]); err == nil {
+		t.Errorf("Expected error decoding truncated message")
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	cases := []struct {
+		s        string
+		expected Selector
+	}{
+		{"up", Selector{nameLabel: "up"}},
+		{`up{job="api"}`, Selector{nameLabel: "up", "job": "api"}},
+		{` up { job = "api", code="2\"0,0" , } `, Selector{nameLabel: "up", "job": "api", "code": `2"0,0`}},
+		{`{job="api"}`, Selector{"job": "api"}},
+	}
+	for _, c := range cases {
+		actual, err := ParseSelector(c.s)
+		if err != nil || !reflect.DeepEqual(c.expected, actual) {
+			t.Errorf("ParseSelector(%s). Expected: %v. Actual: %v, %v", c.s, c.expected, actual, err)
+		}
+	}
+
+	for _, s := range []string{"", "{}", `up{job="api"`, `up{job}`, `up{job=api}`, `up{job="api}`} {
+		if _, err := ParseSelector(s); err == nil {
+			t.Errorf("ParseSelector(%s). Expected error", s)
+		}
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	b := NewBuffer(2)
+	b.Add(
+		newSeries("up", map[string]string{"job": "api"},
+			Sample{Value: 1, Timestamp: ms(t0.Add(2 * time.Minute))},
+			Sample{Value: 0, Timestamp: ms(t0.Add(time.Minute))},
+			Sample{Value: math.NaN(), Timestamp: ms(t0.Add(4 * time.Minute))},
+			Sample{Value: 1, Timestamp: ms(t0.Add(3 * time.Minute))}),
+		newSeries("up", map[string]string{"job": "db"},
+			Sample{Value: 1, Timestamp: ms(t0.Add(time.Minute))}))
+
+	selected := b.Select(Selector{nameLabel: "up", "job": "api"}, t0, t0.Add(time.Hour))
+	expected := map[string][]Sample{
+		`up{job="api"}`: {
+			{Value: 1, Timestamp: ms(t0.Add(2 * time.Minute))},
+			{Value: 1, Timestamp: ms(t0.Add(3 * time.Minute))},
+		},
+	}
+	if !reflect.DeepEqual(expected, selected) {
+		t.Errorf("Expected: %v. Actual: %v", expected, selected)
+	}
+
+	selected = b.Select(Selector{nameLabel: "up"}, t0.Add(2*time.Minute), t0.Add(time.Hour))
+	if len(selected) != 2 || len(selected[`up{job="api"}`]) != 1 || len(selected[`up{job="db"}`]) != 0 {
+		t.Errorf("Unexpected selection: %v", selected)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	b := NewBuffer(DefaultCapacity)
+	body := snappy.Encode(nil, encode([]TimeSeries{
+		newSeries("up", nil, Sample{Value: 1, Timestamp: ms(t0.Add(time.Minute))}),
+	}))
+
+	req := httptest.NewRequest("POST", "/api/v1/write", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	b.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Unexpected status: %d", rr.Code)
+	}
+	if selected := b.Select(Selector{nameLabel: "up"}, t0, t0.Add(time.Hour)); len(selected["up{}"]) != 1 {
+		t.Errorf("Unexpected selection: %v", selected)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/write", bytes.NewReader([]byte("not snappy")))
+	rr = httptest.NewRecorder()
+	b.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Unexpected status: %d", rr.Code)
+	}
+}
+
+func TestBufferLimits(t *testing.T) {
+	now := t0
+	b := NewBuffer(DefaultCapacity)
+	b.now = func() time.Time { return now }
+	b.SetLimits(2, time.Hour, DefaultMaxBytes)
+	sample := Sample{Value: 1, Timestamp: ms(t0)}
+
+	b.Add(newSeries("up", map[string]string{"job": "api"}, sample),
+		newSeries("up", map[string]string{"job": "db"}, sample),
+		newSeries("up", map[string]string{"job": "web"}, sample))
+	if len(b.series) != 2 {
+		t.Errorf("Expected 2 series. Actual: %d", len(b.series))
+	}
+
+	/* api is selected and written, db is selected and idle, web is neither */
+	now = t0.Add(30 * time.Minute)
+	b.Select(Selector{nameLabel: "up", "job": "api"}, t0, now)
+	b.Select(Selector{"job": "db"}, t0, now)
+	b.Add(newSeries("up", map[string]string{"job": "api"}, sample))
+	now = t0.Add(61 * time.Minute)
+	b.Add(newSeries("up", map[string]string{"job": "web"}, sample))
+	if _, ok := b.series[`up{job="db"}`]; ok {
+		t.Errorf("Expected idle series to be evicted")
+	}
+	if _, ok := b.series[`up{job="api"}`]; !ok {
+		t.Errorf("Expected selected series to be kept")
+	}
+	if _, ok := b.series[`up{job="web"}`]; !ok {
+		t.Errorf("Expected new series to be added after eviction")
+	}
+
+	/* the api selector is not used in the TTL; web is younger than the TTL */
+	now = t0.Add(100 * time.Minute)
+	b.Add(newSeries("up", map[string]string{"job": "web"}, sample))
+	if _, ok := b.series[`up{job="api"}`]; ok {
+		t.Errorf("Expected unselected series to be evicted")
+	}
+	if _, ok := b.series[`up{job="web"}`]; !ok {
+		t.Errorf("Expected new series to be kept")
+	}
+}
+
+func TestServeHTTPTooLarge(t *testing.T) {
+	b := NewBuffer(DefaultCapacity)
+	b.SetLimits(DefaultMaxSeries, DefaultSeriesTTL, 16)
+	body := snappy.Encode(nil, bytes.Repeat([]byte{0}, 1024))
+
+	req := httptest.NewRequest("POST", "/api/v1/write", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	b.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Unexpected status: %d", rr.Code)
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	b := NewBuffer(DefaultCapacity)
+	b.Add(
+		newSeries("up", map[string]string{"job": "api"}, Sample{Value: 1, Timestamp: ms(t0.Add(time.Minute))}),
+		newSeries("up", map[string]string{"job": "db"}, Sample{Value: 0, Timestamp: ms(t0.Add(time.Minute))}))
+
+	api := model.Variable{Name: "api_up", Metric: `up{job="api"}`}
+	all := model.Variable{Name: "all_up", Metric: "up"}
+	wrong := model.Variable{Name: "wrong", Metric: "up{"}
+	result, err := Retriever{Buffer: b}.Retrieve()(model.Agreement{Id: "a01"}, []monitor.RetrievalItem{
+		{Var: api, From: t0, To: t0.Add(5 * time.Minute)},
+		{Var: all, From: t0, To: t0.Add(5 * time.Minute)},
+		{Var: wrong, From: t0, To: t0.Add(5 * time.Minute)},
+	})
+	if err == nil {
+		t.Errorf("Expected error of wrong selector")
+	}
+
+	values := result[api]
+	if len(values) != 1 || values[0].Key != "api_up" || values[0].Value != 1.0 ||
+		!values[0].DateTime.Equal(t0.Add(time.Minute)) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+	values = result[all]
+	if len(values) != 2 || values[0].Key != `all_up{job="api"}` || values[1].Key != `all_up{job="db"}` {
+		t.Errorf("Unexpected values: %v", values)
+	}
+	if _, ok := result[wrong]; ok {
+		t.Errorf("Unexpected values of wrong selector: %v", result[wrong])
+	}
+}
@@ -59,6 +59,6 @@ func (ma *ArrayMonitoringAdapter) Initialize(a *model.Agreement) monitor.Monitor
 }
 
 // GetValues implements monitor.MonitoringAdapter.GetValues
-func (ma *ArrayMonitoringAdapter) GetValues(gt model.Guarantee, vars []string, now time.Time) assessment_model.GuaranteeData {
-	return ma.values
+func (ma *ArrayMonitoringAdapter) GetValues(gt model.Guarantee, vars []string, now time.Time) (assessment_model.GuaranteeData, error) {
+	return ma.values, nil
 }
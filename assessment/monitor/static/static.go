@@ -0,0 +1,121 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package static contains a retriever that reads the metric values from a JSON
+file. It is useful for metrics that are reported manually or by batch jobs,
+and for testing.
+
+The file contains an object whose keys are metric names and whose values are
+the lists of values of each metric:
+
+	{
+	  "availability": [
+	    {"value": 0.99, "datetime": "2020-06-01T00:00:00Z"},
+	    {"value": 0.98, "datetime": "2020-06-02T00:00:00Z"}
+	  ]
+	}
+
+The file is read on each retrieval, so it can be updated while SLALite runs.
+*/
+package static
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/assessment/monitor/genericadapter"
+	"SLALite/model"
+	"SLALite/registry"
+	"encoding/json"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this retriever
+	Name = "static"
+
+	// PathPropertyName is the config property name of the path of the values file
+	PathPropertyName = "staticPath"
+)
+
+// Retriever implements genericadapter.Retrieve
+type Retriever struct {
+	Path string
+}
+
+func init() {
+	genericadapter.RegisterRetriever(Name, func(config *viper.Viper) genericadapter.Retrieve {
+		return New(config).Retrieve()
+	},
+		registry.Property{Name: PathPropertyName, Description: "Path of the JSON file with the values"})
+}
+
+// New constructs a static retriever from a Viper configuration
+func New(config *viper.Viper) Retriever {
+
+	logConfig(config)
+
+	return Retriever{
+		Path: config.GetString(PathPropertyName),
+	}
+}
+
+func logConfig(config *viper.Viper) {
+	log.Infof("Static retriever configuration:\n"+
+		"\tPath: %s", config.GetString(PathPropertyName))
+}
+
+// Retrieve implements genericadapter.Retrieve.
+//
+// It returns the values of each variable metric in the (From, To] interval of the item.
+func (r Retriever) Retrieve() genericadapter.Retrieve {
+
+	return func(agreement model.Agreement,
+		items []monitor.RetrievalItem) (map[model.Variable][]model.MetricValue, error) {
+
+		result := make(map[model.Variable][]model.MetricValue)
+		values, err := r.read()
+		if err != nil {
+			log.Errorf("Error reading static values in %s: %s", r.Path, err.Error())
+			return result, err
+		}
+		for _, item := range items {
+			aux := make([]model.MetricValue, 0)
+			for _, value := range values[item.Var.Metric] {
+				if value.DateTime.After(item.From) && !value.DateTime.After(item.To) {
+					value.Key = item.Var.Name
+					aux = append(aux, value)
+				}
+			}
+			result[item.Var] = aux
+		}
+		return result, nil
+	}
+}
+
+func (r Retriever) read() (map[string][]model.MetricValue, error) {
+	f, err := os.Open(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values map[string][]model.MetricValue
+	err = json.NewDecoder(f).Decode(&values)
+	return values, err
+}
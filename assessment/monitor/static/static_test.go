@@ -0,0 +1,61 @@
+package static
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(PathPropertyName, "testdata/values.json")
+
+	r := New(config)
+	if r.Path != "testdata/values.json" {
+		t.Errorf("Unexpected retriever: %v", r)
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	v := model.Variable{Name: "av", Metric: "availability"}
+	items := []monitor.RetrievalItem{
+		{
+			Var:  v,
+			From: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2020, 6, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Var:  model.Variable{Name: "x", Metric: "notexists"},
+			From: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2020, 6, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	result, err := Retriever{Path: "testdata/values.json"}.Retrieve()(model.Agreement{}, items)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	values := result[v]
+	if len(values) != 2 || values[0].Value != 0.98 || values[1].Key != "av" {
+		t.Errorf("Unexpected values: %v", values)
+	}
+	if values, ok := result[items[1].Var]; !ok || len(values) != 0 {
+		t.Errorf("Expected empty values of not existing metric. Actual: %v", values)
+	}
+}
+
+func TestRetrieveNotExists(t *testing.T) {
+	items := []monitor.RetrievalItem{
+		{Var: model.Variable{Name: "av", Metric: "availability"}, To: time.Now()},
+	}
+	result, err := Retriever{Path: "testdata/notexists.json"}.Retrieve()(model.Agreement{}, items)
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+	if len(result) != 0 {
+		t.Errorf("Unexpected values: %v", result)
+	}
+}
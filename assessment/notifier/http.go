@@ -0,0 +1,47 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"SLALite/registry"
+	"SLALite/utils"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// TimeoutPropertyName is the config property name of the timeout of the
+	// requests of the HTTP notifiers
+	TimeoutPropertyName = "notifierTimeout"
+
+	// DefaultTimeout is the default value of TimeoutPropertyName
+	DefaultTimeout = 10 * time.Second
+)
+
+// TimeoutProperty is the schema of TimeoutPropertyName, to be registered by the HTTP notifiers
+var TimeoutProperty = registry.Property{Name: TimeoutPropertyName, Default: DefaultTimeout, Description: "Timeout of the requests"}
+
+// HTTPClient returns the client of the HTTP notifiers: it trusts the CAs of
+// utils.GetClient and times out after the configured timeout
+func HTTPClient(config *viper.Viper) *http.Client {
+	config.SetDefault(TimeoutPropertyName, DefaultTimeout)
+	client := utils.GetClient(false)
+	client.Timeout = config.GetDuration(TimeoutPropertyName)
+	return client
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package kafkanotifier contains a ViolationsNotifier that sends violations and
+lifecycle events as JSON messages to Kafka.
+
+The topic is a text/template executed with the agreement (e.g.
+"sla.{{.Details.Provider.Id}}"), and the key of every message is the agreement
+id, so that all the messages of an agreement land in the same partition.
+*/
+package kafkanotifier
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/telemetry"
+	"SLALite/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this notifier
+	Name = "kafka"
+
+	// BrokersPropertyName is the config property name of the comma separated list of brokers
+	BrokersPropertyName = "kafkaBrokers"
+
+	// TopicPropertyName is the config property name of the topic template
+	TopicPropertyName = "kafkaTopic"
+
+	// TLSPropertyName is the config property name that enables TLS
+	TLSPropertyName = "kafkaTls"
+
+	// TLSInsecurePropertyName is the config property name that disables the verification of the server certificate
+	TLSInsecurePropertyName = "kafkaTlsInsecure"
+
+	// TLSCertPropertyName is the config property name of the client certificate path
+	TLSCertPropertyName = "kafkaTlsCert"
+
+	// TLSKeyPropertyName is the config property name of the client private key path
+	TLSKeyPropertyName = "kafkaTlsKey"
+
+	// SASLMechanismPropertyName is the config property name of the SASL mechanism (plain, scram-sha-256, scram-sha-512).
+	// If empty, no SASL authentication is done.
+	SASLMechanismPropertyName = "kafkaSaslMechanism"
+
+	// SASLUserPropertyName is the config property name of the SASL user
+	SASLUserPropertyName = "kafkaSaslUser"
+
+	// SASLPasswordPropertyName is the config property name of the SASL password
+	SASLPasswordPropertyName = "kafkaSaslPassword"
+
+	defaultBrokers = "localhost:9092"
+	defaultTopic   = "sla-violations"
+	writeTimeout   = 10 * time.Second
+)
+
+// messageWriter is the subset of *kafka.Writer used by the notifier
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// writerFactory returns the writer of a topic
+type writerFactory func(topic string) messageWriter
+
+type _notifier struct {
+	topic     *template.Template
+	newWriter writerFactory
+
+	mu      sync.Mutex
+	writers map[string]messageWriter
+}
+
+type violationInfo struct {
+	Type        string            `json:"type"`
+	AgreementID string            `json:"agreement_id"`
+	Client      model.Client      `json:"client"`
+	Violations  []model.Violation `json:"violations"`
+}
+
+func init() {
+	notifier.Register(Name, New,
+		registry.Property{Name: BrokersPropertyName, Default: defaultBrokers, Description: "Comma separated list of brokers"},
+		registry.Property{Name: TopicPropertyName, Default: defaultTopic, Description: "Go text/template of the topic"},
+		registry.Property{Name: TLSPropertyName, Description: "Enables TLS"},
+		registry.Property{Name: TLSInsecurePropertyName, Description: "Skips the verification of the server certificate"},
+		registry.Property{Name: TLSCertPropertyName, Description: "Client certificate path"},
+		registry.Property{Name: TLSKeyPropertyName, Description: "Client private key path"},
+		registry.Property{Name: SASLMechanismPropertyName, Description: "plain, scram-sha-256 or scram-sha-512"},
+		registry.Property{Name: SASLUserPropertyName, Description: "SASL user"},
+		registry.Property{Name: SASLPasswordPropertyName, Description: "SASL password"})
+}
+
+// New constructs a Kafka Notifier from a Viper configuration.
+//
+// It terminates the program if the configuration is not valid.
+func New(config *viper.Viper) notifier.ViolationNotifier {
+
+	config.SetDefault(BrokersPropertyName, defaultBrokers)
+	config.SetDefault(TopicPropertyName, defaultTopic)
+	logConfig(config)
+
+	dialer, err := buildDialer(config)
+	if err != nil {
+		log.Fatal("Error configuring Kafka notifier: " + err.Error())
+	}
+	brokers := strings.Split(config.GetString(BrokersPropertyName), ",")
+	factory := func(topic string) messageWriter {
+		return kafka.NewWriter(kafka.WriterConfig{
+			Brokers:  brokers,
+			Topic:    topic,
+			Dialer:   dialer,
+			Balancer: &kafka.Hash{},
+		})
+	}
+	not, err := _new(config.GetString(TopicPropertyName), factory)
+	if err != nil {
+		log.Fatal("Error parsing Kafka topic template: " + err.Error())
+	}
+	return not
+}
+
+func _new(topic string, factory writerFactory) (notifier.ViolationNotifier, error) {
+	tmpl, err := template.New("topic").Parse(topic)
+	if err != nil {
+		return nil, err
+	}
+	return &_notifier{
+		topic:     tmpl,
+		newWriter: factory,
+		writers:   make(map[string]messageWriter),
+	}, nil
+}
+
+func buildDialer(config *viper.Viper) (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{
+		Timeout:   writeTimeout,
+		DualStack: true,
+	}
+	if config.GetBool(TLSPropertyName) {
+		tlsConfig, err := utils.GetTLSConfig(
+			config.GetBool(TLSInsecurePropertyName),
+			config.GetString(TLSCertPropertyName),
+			config.GetString(TLSKeyPropertyName))
+		if err != nil {
+			return nil, err
+		}
+		dialer.TLS = tlsConfig
+	}
+	mechanism, err := buildSASLMechanism(
+		config.GetString(SASLMechanismPropertyName),
+		config.GetString(SASLUserPropertyName),
+		config.GetString(SASLPasswordPropertyName))
+	if err != nil {
+		return nil, err
+	}
+	dialer.SASLMechanism = mechanism
+	return dialer, nil
+}
+
+func buildSASLMechanism(name string, user string, password string) (sasl.Mechanism, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: user, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, user, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, user, password)
+	}
+	return nil, fmt.Errorf("unknown SASL mechanism '%s'", name)
+}
+
+func logConfig(config *viper.Viper) {
+	log.Printf("KafkaNotifier configuration\n"+
+		"\tBrokers: %v\n"+
+		"\tTopic: %v\n"+
+		"\tTLS: %v\n"+
+		"\tSASL mechanism: %v\n"+
+		"\tSASL user: %v\n",
+		config.GetString(BrokersPropertyName),
+		config.GetString(TopicPropertyName),
+		config.GetBool(TLSPropertyName),
+		config.GetString(SASLMechanismPropertyName),
+		config.GetString(SASLUserPropertyName))
+}
+
+/* Implements notifier.NotifyViolations */
+func (not *_notifier) NotifyViolations(agreement *model.Agreement, result *amodel.Result) {
+	vs := result.GetViolations()
+	if len(vs) == 0 {
+		return
+	}
+	info := violationInfo{
+		Type:        "violation",
+		AgreementID: agreement.Id,
+		Client:      agreement.Details.Client,
+		Violations:  vs,
+	}
+	if err := not.send(agreement, info); err != nil {
+		log.Errorf("KafkaNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("KafkaNotifier. Sent violations of agreement %s", agreement.Id)
+	}
+}
+
+/* Implements events.Listener */
+func (not *_notifier) NotifyEvent(e events.Event) {
+	a := e.Agreement
+	if a == nil {
+		a = &model.Agreement{Id: e.AgreementID}
+	}
+	if err := not.send(a, e); err != nil {
+		log.Errorf("KafkaNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("KafkaNotifier. Sent event %s of agreement %s", e.Type, e.AgreementID)
+	}
+}
+
+func (not *_notifier) send(a *model.Agreement, payload interface{}) error {
+	topic := new(bytes.Buffer)
+	if err := not.topic.Execute(topic, a); err != nil {
+		return err
+	}
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	return not.writer(topic.String()).WriteMessages(ctx, kafka.Message{
+		Key:   []byte(a.Id),
+		Value: value,
+	})
+}
+
+func (not *_notifier) writer(topic string) messageWriter {
+	not.mu.Lock()
+	defer not.mu.Unlock()
+
+	w, ok := not.writers[topic]
+	if !ok {
+		w = not.newWriter(topic)
+		not.writers[topic] = w
+	}
+	return w
+}
+
+// Flush implements notifier.Flusher. It closes the writers, which sends their
+// pending messages. New writers are created on the next notification.
+func (not *_notifier) Flush() error {
+	not.mu.Lock()
+	defer not.mu.Unlock()
+
+	var result error
+	for topic, w := range not.writers {
+		if err := w.Close(); err != nil {
+			log.Errorf("KafkaNotifier error closing writer of topic %s: %s", topic, err)
+			result = err
+		}
+		delete(not.writers, topic)
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package kafkanotifier
+
+import (
+	"SLALite/assessment"
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/spf13/viper"
+)
+
+var agreement model.Agreement
+var ma *simpleadapter.ArrayMonitoringAdapter
+
+/* fakeWriter stores the messages written to a topic */
+type fakeWriter struct {
+	topic    string
+	messages []kafka.Message
+	err      error
+	closed   bool
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return w.err
+}
+
+func (w *fakeWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+type fakeFactory map[string]*fakeWriter
+
+func (f fakeFactory) newWriter(topic string) messageWriter {
+	w := &fakeWriter{topic: topic}
+	f[topic] = w
+	return w
+}
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(SASLMechanismPropertyName, "scram-sha-256")
+	config.Set(SASLUserPropertyName, "user")
+	config.Set(SASLPasswordPropertyName, "pass")
+
+	New(config)
+}
+
+func TestBuildSASLMechanism(t *testing.T) {
+	for _, name := range []string{"", "plain", "SCRAM-SHA-256", "scram-sha-512"} {
+		if _, err := buildSASLMechanism(name, "user", "pass"); err != nil {
+			t.Errorf("Unexpected error on mechanism %s: %s", name, err)
+		}
+	}
+	if _, err := buildSASLMechanism("gssapi", "user", "pass"); err == nil {
+		t.Error("Expected error on unknown mechanism")
+	}
+}
+
+func TestBuildDialerTLS(t *testing.T) {
+	config := viper.New()
+	config.Set(TLSPropertyName, true)
+
+	dialer, err := buildDialer(config)
+	if err != nil || dialer.TLS == nil {
+		t.Errorf("Expected TLS dialer. err=%v", err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	factory := fakeFactory{}
+
+	not, _ := _new("sla.{{.Details.Provider.Id}}", factory.newWriter)
+	not.NotifyViolations(&agreement, &result)
+	not.NotifyViolations(&agreement, &result)
+
+	w, ok := factory["sla.mf2c"]
+	if !ok || len(factory) != 1 {
+		t.Fatalf("Unexpected topics: %v", factory)
+	}
+	if len(w.messages) != 2 || string(w.messages[0].Key) != agreement.Id {
+		t.Fatalf("Unexpected messages: %v", w.messages)
+	}
+	var info violationInfo
+	json.Unmarshal(w.messages[0].Value, &info)
+	if info.AgreementID != agreement.Id || len(info.Violations) != 1 {
+		t.Errorf("Unexpected message value: %v", info)
+	}
+}
+
+func TestSendEmpty(t *testing.T) {
+	Init()
+	factory := fakeFactory{}
+
+	not, _ := _new(defaultTopic, factory.newWriter)
+	not.NotifyViolations(&agreement, &amodel.Result{})
+	if len(factory) != 0 {
+		t.Errorf("Not expected messages without violations: %v", factory)
+	}
+}
+
+func TestSendWrong(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	factory := func(topic string) messageWriter {
+		return &fakeWriter{err: errors.New("broker not available")}
+	}
+
+	not, _ := _new(defaultTopic, factory)
+	not.NotifyViolations(&agreement, &result)
+}
+
+func TestFlush(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	factory := fakeFactory{}
+
+	not, _ := _new(defaultTopic, factory.newWriter)
+	not.NotifyViolations(&agreement, &result)
+	if err := not.(notifier.Flusher).Flush(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if w := factory[defaultTopic]; w == nil || !w.closed {
+		t.Errorf("Expected closed writer: %v", w)
+	}
+
+	/* a new writer is created after a flush */
+	not.NotifyViolations(&agreement, &result)
+	if w := factory[defaultTopic]; w.closed || len(w.messages) != 1 {
+		t.Errorf("Expected new writer: %v", w)
+	}
+}
+
+func TestSendEvent(t *testing.T) {
+	Init()
+	factory := fakeFactory{}
+
+	not, _ := _new("sla.{{.Id}}", factory.newWriter)
+	not.(events.Listener).NotifyEvent(events.Event{Type: events.AgreementDeleted, AgreementID: "a01"})
+
+	w := factory["sla.a01"]
+	if w == nil || len(w.messages) != 1 || string(w.messages[0].Key) != "a01" {
+		t.Fatalf("Unexpected topics: %v", factory)
+	}
+	var e events.Event
+	json.Unmarshal(w.messages[0].Value, &e)
+	if e.Type != events.AgreementDeleted {
+		t.Errorf("Unexpected event: %v", e)
+	}
+}
+
+func TestWrongTemplate(t *testing.T) {
+	if _, err := _new("sla.{{.Id", nil); err == nil {
+		t.Error("Expected error on wrong template")
+	}
+}
+
+func Init() {
+	agreement, _ = utils.ReadAgreement("testdata/agreement.json")
+	ma = simpleadapter.New(amodel.GuaranteeData{
+		amodel.ExpressionData{
+			"execution_time": model.MetricValue{
+				Key:      "execution_time",
+				Value:    1000,
+				DateTime: time.Now(),
+			},
+		},
+	})
+}
@@ -19,11 +19,25 @@ package lognotifier
 
 import (
 	assessment_model "SLALite/assessment/model"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
 	"SLALite/model"
+	"github.com/spf13/viper"
 
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// Name is the unique identifier of this notifier
+	Name = "log"
+)
+
+func init() {
+	notifier.Register(Name, func(config *viper.Viper) notifier.ViolationNotifier {
+		return LogNotifier{}
+	})
+}
+
 // LogNotifier logs violations
 type LogNotifier struct {
 }
@@ -40,3 +54,13 @@ func (n LogNotifier) NotifyViolations(agreement *model.Agreement, result *assess
 		}
 	}
 }
+
+// NotifyEvent implements events.Listener interface
+func (n LogNotifier) NotifyEvent(e events.Event) {
+	if e.Incident != nil {
+		log.Infof("Event %s of agreement %s at %s: incident %s of guarantee %s (%d violations)",
+			e.Type, e.AgreementID, e.Datetime, e.Incident.Id, e.Incident.Guarantee, e.Incident.Count)
+		return
+	}
+	log.Infof("Event %s of agreement %s at %s", e.Type, e.AgreementID, e.Datetime)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	assessment_model "SLALite/assessment/model"
+	"SLALite/events"
+	"SLALite/model"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+/*
+This file contains helpers to build human readable messages for notifiers
+that send text to people (e.g. email or chat notifiers).
+*/
+
+var violationsTemplate = template.Must(template.New("violations").Funcs(funcs).Parse(
+	`Agreement {{.Agreement.Id}} ({{.Agreement.Name}}) has been violated.
+
+Provider: {{.Agreement.Details.Provider.Name}}
+Client: {{.Agreement.Details.Client.Name}}
+{{range .Violations}}
+- Guarantee {{.Guarantee}} failed at {{date .Datetime}}
+  Constraint: {{.Constraint}}
+  {{if .NoData}}No monitoring data{{else}}Values: {{values .Values}}{{end}}
+{{- end}}
+`))
+
+var eventTemplate = template.Must(template.New("event").Funcs(funcs).Parse(
+	`{{title .Type}} of agreement {{.AgreementID}} at {{date .Datetime}}.
+{{- if .ToState}}
+State: {{.FromState}} -> {{.ToState}}
+{{- end}}
+{{- if .Guarantee}}
+Guarantee: {{.Guarantee}}
+{{- end}}
+{{- with .Incident}}
+Guarantee: {{.Guarantee}}
+Constraint: {{.Constraint}}
+Start: {{date .Start}}
+{{- if .End}}
+End: {{date .End}}
+{{- end}}
+Violations: {{.Count}}
+{{- end}}
+`))
+
+var funcs = template.FuncMap{
+	"date":   formatDate,
+	"values": formatValues,
+	"title":  formatType,
+}
+
+// ViolationsSubject returns a one-line summary of the violations of an agreement
+func ViolationsSubject(agreement *model.Agreement, result *assessment_model.Result) string {
+	gts := make([]string, 0, len(result.Violated))
+	for gt := range result.Violated {
+		gts = append(gts, gt)
+	}
+	sort.Strings(gts)
+	return fmt.Sprintf("SLA violation in agreement %s: %s", agreement.Id, strings.Join(gts, ", "))
+}
+
+// ViolationsText returns a human readable description of the violations of an agreement
+func ViolationsText(agreement *model.Agreement, result *assessment_model.Result) string {
+	violations := result.GetViolations()
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].Datetime.Before(violations[j].Datetime)
+	})
+	data := struct {
+		Agreement  *model.Agreement
+		Violations []model.Violation
+	}{agreement, violations}
+
+	b := new(bytes.Buffer)
+	violationsTemplate.Execute(b, data)
+	return b.String()
+}
+
+// EventSubject returns a one-line summary of a lifecycle event
+func EventSubject(e events.Event) string {
+	return fmt.Sprintf("%s: agreement %s", formatType(e.Type), e.AgreementID)
+}
+
+// EventText returns a human readable description of a lifecycle event
+func EventText(e events.Event) string {
+	b := new(bytes.Buffer)
+	eventTemplate.Execute(b, e)
+	return b.String()
+}
+
+func formatDate(t interface{}) string {
+	switch d := t.(type) {
+	case time.Time:
+		return d.UTC().Format(time.RFC1123)
+	case *time.Time:
+		if d != nil {
+			return d.UTC().Format(time.RFC1123)
+		}
+	}
+	return ""
+}
+
+func formatValues(values []model.MetricValue) string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, fmt.Sprintf("%s=%v", v.Key, v.Value))
+	}
+	return strings.Join(result, ", ")
+}
+
+func formatType(t events.Type) string {
+	s := strings.Replace(string(t), "_", " ", -1)
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package natsnotifier contains a ViolationsNotifier that publishes violations and
+lifecycle events as JSON messages to NATS.
+
+The subject is a text/template executed with the agreement. The default subject
+ends with the agreement id (e.g. "sla.violations.a01"), so that subscribers can
+select the agreements they are interested in with wildcards.
+*/
+package natsnotifier
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/telemetry"
+	"SLALite/utils"
+	"bytes"
+	"encoding/json"
+	"sync"
+	"text/template"
+
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this notifier
+	Name = "nats"
+
+	// URLPropertyName is the config property name of the comma separated list of NATS servers
+	URLPropertyName = "natsUrl"
+
+	// SubjectPropertyName is the config property name of the subject template
+	SubjectPropertyName = "natsSubject"
+
+	// UserPropertyName is the config property name of the NATS user
+	UserPropertyName = "natsUser"
+
+	// PasswordPropertyName is the config property name of the NATS password
+	PasswordPropertyName = "natsPassword"
+
+	// TokenPropertyName is the config property name of the NATS authentication token
+	TokenPropertyName = "natsToken"
+
+	// CredentialsPropertyName is the config property name of a NATS 2.0 credentials file (JWT and NKey seed)
+	CredentialsPropertyName = "natsCredentials"
+
+	// TLSPropertyName is the config property name that enables TLS
+	TLSPropertyName = "natsTls"
+
+	// TLSInsecurePropertyName is the config property name that disables the verification of the server certificate
+	TLSInsecurePropertyName = "natsTlsInsecure"
+
+	// TLSCertPropertyName is the config property name of the client certificate path
+	TLSCertPropertyName = "natsTlsCert"
+
+	// TLSKeyPropertyName is the config property name of the client private key path
+	TLSKeyPropertyName = "natsTlsKey"
+
+	defaultSubject = "sla.violations.{{.Id}}"
+)
+
+// publisher is the subset of *nats.Conn used by the notifier
+type publisher interface {
+	Publish(subject string, data []byte) error
+	Flush() error
+}
+
+// connectFunc returns a connection to NATS
+type connectFunc func() (publisher, error)
+
+type _notifier struct {
+	subject *template.Template
+	connect connectFunc
+
+	mu   sync.Mutex
+	conn publisher
+}
+
+type violationInfo struct {
+	Type        string            `json:"type"`
+	AgreementID string            `json:"agreement_id"`
+	Client      model.Client      `json:"client"`
+	Violations  []model.Violation `json:"violations"`
+}
+
+func init() {
+	notifier.Register(Name, New,
+		registry.Property{Name: URLPropertyName, Default: nats.DefaultURL, Description: "Comma separated list of NATS servers"},
+		registry.Property{Name: SubjectPropertyName, Default: defaultSubject, Description: "Go text/template of the subject"},
+		registry.Property{Name: UserPropertyName, Description: "NATS user"},
+		registry.Property{Name: PasswordPropertyName, Description: "NATS password"},
+		registry.Property{Name: TokenPropertyName, Description: "NATS authentication token"},
+		registry.Property{Name: CredentialsPropertyName, Description: "NATS credentials file"},
+		registry.Property{Name: TLSPropertyName, Description: "Enables TLS"},
+		registry.Property{Name: TLSInsecurePropertyName, Description: "Skips the verification of the server certificate"},
+		registry.Property{Name: TLSCertPropertyName, Description: "Client certificate path"},
+		registry.Property{Name: TLSKeyPropertyName, Description: "Client private key path"})
+}
+
+// New constructs a NATS Notifier from a Viper configuration.
+//
+// The connection to NATS is established on the first notification.
+// It terminates the program if the configuration is not valid.
+func New(config *viper.Viper) notifier.ViolationNotifier {
+
+	config.SetDefault(URLPropertyName, nats.DefaultURL)
+	config.SetDefault(SubjectPropertyName, defaultSubject)
+	logConfig(config)
+
+	opts, err := buildOptions(config)
+	if err != nil {
+		log.Fatal("Error configuring NATS notifier: " + err.Error())
+	}
+	url := config.GetString(URLPropertyName)
+	connect := func() (publisher, error) {
+		return nats.Connect(url, opts...)
+	}
+	not, err := _new(config.GetString(SubjectPropertyName), connect)
+	if err != nil {
+		log.Fatal("Error parsing NATS subject template: " + err.Error())
+	}
+	return not
+}
+
+func _new(subject string, connect connectFunc) (notifier.ViolationNotifier, error) {
+	tmpl, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return nil, err
+	}
+	return &_notifier{
+		subject: tmpl,
+		connect: connect,
+	}, nil
+}
+
+func buildOptions(config *viper.Viper) ([]nats.Option, error) {
+	opts := []nats.Option{
+		nats.Name("SLALite"),
+		nats.MaxReconnects(-1),
+	}
+	if user := config.GetString(UserPropertyName); user != "" {
+		opts = append(opts, nats.UserInfo(user, config.GetString(PasswordPropertyName)))
+	}
+	if token := config.GetString(TokenPropertyName); token != "" {
+		opts = append(opts, nats.Token(token))
+	}
+	if creds := config.GetString(CredentialsPropertyName); creds != "" {
+		opts = append(opts, nats.UserCredentials(creds))
+	}
+	if config.GetBool(TLSPropertyName) {
+		tlsConfig, err := utils.GetTLSConfig(
+			config.GetBool(TLSInsecurePropertyName),
+			config.GetString(TLSCertPropertyName),
+			config.GetString(TLSKeyPropertyName))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+	return opts, nil
+}
+
+func logConfig(config *viper.Viper) {
+	log.Printf("NatsNotifier configuration\n"+
+		"\tURL: %v\n"+
+		"\tSubject: %v\n"+
+		"\tUser: %v\n"+
+		"\tCredentials: %v\n"+
+		"\tTLS: %v\n",
+		config.GetString(URLPropertyName),
+		config.GetString(SubjectPropertyName),
+		config.GetString(UserPropertyName),
+		config.GetString(CredentialsPropertyName),
+		config.GetBool(TLSPropertyName))
+}
+
+/* Implements notifier.NotifyViolations */
+func (not *_notifier) NotifyViolations(agreement *model.Agreement, result *amodel.Result) {
+	vs := result.GetViolations()
+	if len(vs) == 0 {
+		return
+	}
+	info := violationInfo{
+		Type:        "violation",
+		AgreementID: agreement.Id,
+		Client:      agreement.Details.Client,
+		Violations:  vs,
+	}
+	if err := not.send(agreement, info); err != nil {
+		log.Errorf("NatsNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("NatsNotifier. Sent violations of agreement %s", agreement.Id)
+	}
+}
+
+/* Implements events.Listener */
+func (not *_notifier) NotifyEvent(e events.Event) {
+	a := e.Agreement
+	if a == nil {
+		a = &model.Agreement{Id: e.AgreementID}
+	}
+	if err := not.send(a, e); err != nil {
+		log.Errorf("NatsNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("NatsNotifier. Sent event %s of agreement %s", e.Type, e.AgreementID)
+	}
+}
+
+func (not *_notifier) send(a *model.Agreement, payload interface{}) error {
+	subject := new(bytes.Buffer)
+	if err := not.subject.Execute(subject, a); err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	conn, err := not.getConn()
+	if err != nil {
+		return err
+	}
+	return conn.Publish(subject.String(), data)
+}
+
+func (not *_notifier) getConn() (publisher, error) {
+	not.mu.Lock()
+	defer not.mu.Unlock()
+
+	if not.conn == nil {
+		conn, err := not.connect()
+		if err != nil {
+			return nil, err
+		}
+		not.conn = conn
+	}
+	return not.conn, nil
+}
+
+// Flush implements notifier.Flusher. It waits until the server has received
+// the published messages.
+func (not *_notifier) Flush() error {
+	not.mu.Lock()
+	defer not.mu.Unlock()
+
+	if not.conn == nil {
+		return nil
+	}
+	return not.conn.Flush()
+}
@@ -0,0 +1,167 @@
+package natsnotifier
+
+import (
+	"SLALite/assessment"
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/utils"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var agreement model.Agreement
+var ma *simpleadapter.ArrayMonitoringAdapter
+
+type message struct {
+	subject string
+	data    []byte
+}
+
+/* fakeConn stores the published messages */
+type fakeConn struct {
+	messages []message
+	flushed  int
+}
+
+func (c *fakeConn) Publish(subject string, data []byte) error {
+	c.messages = append(c.messages, message{subject, data})
+	return nil
+}
+
+func (c *fakeConn) Flush() error {
+	c.flushed = len(c.messages)
+	return nil
+}
+
+func (c *fakeConn) connect() (publisher, error) {
+	return c, nil
+}
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(UserPropertyName, "user")
+	config.Set(TLSPropertyName, true)
+
+	New(config)
+}
+
+func TestBuildOptions(t *testing.T) {
+	config := viper.New()
+	config.Set(TokenPropertyName, "token")
+	config.Set(TLSPropertyName, true)
+	opts, err := buildOptions(config)
+	if err != nil || len(opts) != 4 {
+		t.Errorf("Unexpected options: %v. err=%v", opts, err)
+	}
+
+	config.Set(TLSCertPropertyName, "testdata/notexists.pem")
+	config.Set(TLSKeyPropertyName, "testdata/notexists.pem")
+	if _, err := buildOptions(config); err == nil {
+		t.Error("Expected error on not existing certificate")
+	}
+}
+
+func TestSend(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	conn := &fakeConn{}
+
+	not, _ := _new(defaultSubject, conn.connect)
+	not.NotifyViolations(&agreement, &result)
+
+	if len(conn.messages) != 1 || conn.messages[0].subject != "sla.violations.id" {
+		t.Fatalf("Unexpected messages: %v", conn.messages)
+	}
+	var info violationInfo
+	json.Unmarshal(conn.messages[0].data, &info)
+	if info.AgreementID != agreement.Id || len(info.Violations) != 1 {
+		t.Errorf("Unexpected message data: %v", info)
+	}
+}
+
+func TestSendEmpty(t *testing.T) {
+	Init()
+	conn := &fakeConn{}
+
+	not, _ := _new(defaultSubject, conn.connect)
+	not.NotifyViolations(&agreement, &amodel.Result{})
+	if len(conn.messages) != 0 {
+		t.Errorf("Not expected messages without violations: %v", conn.messages)
+	}
+}
+
+func TestSendWrong(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	attempts := 0
+	connect := func() (publisher, error) {
+		attempts++
+		return nil, errors.New("no servers available")
+	}
+
+	not, _ := _new(defaultSubject, connect)
+	not.NotifyViolations(&agreement, &result)
+	not.NotifyViolations(&agreement, &result)
+	if attempts != 2 {
+		t.Errorf("Expected a connection attempt on each notification. Actual: %d", attempts)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	conn := &fakeConn{}
+
+	not, _ := _new(defaultSubject, conn.connect)
+	if err := not.(notifier.Flusher).Flush(); err != nil {
+		t.Errorf("Unexpected error flushing without connection: %v", err)
+	}
+	not.NotifyViolations(&agreement, &result)
+	not.(notifier.Flusher).Flush()
+	if conn.flushed != 1 {
+		t.Errorf("Expected flushed messages. Actual: %d", conn.flushed)
+	}
+}
+
+func TestSendEvent(t *testing.T) {
+	Init()
+	conn := &fakeConn{}
+
+	not, _ := _new("sla.{{.Details.Provider.Id}}.{{.Id}}", conn.connect)
+	not.(events.Listener).NotifyEvent(events.NewAgreementEvent(events.AgreementCreated, &agreement))
+
+	if len(conn.messages) != 1 || conn.messages[0].subject != "sla.mf2c.id" {
+		t.Fatalf("Unexpected messages: %v", conn.messages)
+	}
+	var e events.Event
+	json.Unmarshal(conn.messages[0].data, &e)
+	if e.Type != events.AgreementCreated {
+		t.Errorf("Unexpected event: %v", e)
+	}
+}
+
+func TestWrongTemplate(t *testing.T) {
+	if _, err := _new("sla.{{.Id", nil); err == nil {
+		t.Error("Expected error on wrong template")
+	}
+}
+
+func Init() {
+	agreement, _ = utils.ReadAgreement("testdata/agreement.json")
+	ma = simpleadapter.New(amodel.GuaranteeData{
+		amodel.ExpressionData{
+			"execution_time": model.MetricValue{
+				Key:      "execution_time",
+				Value:    1000,
+				DateTime: time.Now(),
+			},
+		},
+	})
+}
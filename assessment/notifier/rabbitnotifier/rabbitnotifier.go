@@ -19,10 +19,13 @@ package rabbitnotifier
 
 import (
 	assessment_model "SLALite/assessment/model"
+	"SLALite/assessment/notifier"
 	"SLALite/model"
+	"SLALite/telemetry"
 	"encoding/json"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/streadway/amqp"
 )
 
@@ -31,6 +34,12 @@ const (
 	Name = "rabbit"
 )
 
+func init() {
+	notifier.Register(Name, func(config *viper.Viper) notifier.ViolationNotifier {
+		return RabbitNotifier{}
+	})
+}
+
 //RabbitNotifier logs violations
 type RabbitNotifier struct {
 }
@@ -42,7 +51,8 @@ var conn *amqp.Connection
 //Error traitment
 func failOnError(err error, msg string) {
 	if err != nil {
-		log.Error("%s: %s", msg, err)
+		log.Errorf("%s: %s", msg, err)
+		telemetry.NotifierFailures.Inc(Name)
 	}
 }
 
@@ -0,0 +1,40 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"SLALite/registry"
+
+	"github.com/spf13/viper"
+)
+
+// Factory builds a notifier from the main configuration
+type Factory func(config *viper.Viper) ViolationNotifier
+
+// Register registers a notifier factory with the name used in the `notifier` setting
+func Register(name string, f Factory, schema ...registry.Property) {
+	registry.Register(registry.NotifierKind, name, f, schema...)
+}
+
+// New builds the notifier registered with a name
+func New(name string, config *viper.Viper) (ViolationNotifier, error) {
+	f, err := registry.Lookup(registry.NotifierKind, name, config)
+	if err != nil {
+		return nil, err
+	}
+	return f.(Factory)(config), nil
+}
@@ -3,7 +3,10 @@ package rest
 import (
 	amodel "SLALite/assessment/model"
 	"SLALite/assessment/notifier"
+	"SLALite/events"
 	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/telemetry"
 	"bytes"
 	"encoding/json"
 	"net/http"
@@ -21,7 +24,8 @@ const (
 )
 
 type _notifier struct {
-	url string
+	url    string
+	client *http.Client
 }
 
 type violationInfo struct {
@@ -32,23 +36,33 @@ type violationInfo struct {
 	Violations    []model.Violation `json:"violations"`
 }
 
+func init() {
+	notifier.Register(Name, New,
+		registry.Property{Name: NotificationURLPropertyName, Description: "URL to POST violations and events to"},
+		notifier.TimeoutProperty)
+}
+
 // New constructs a REST Notifier
 func New(config *viper.Viper) notifier.ViolationNotifier {
 
+	config.SetDefault(notifier.TimeoutPropertyName, notifier.DefaultTimeout)
 	logConfig(config)
-	return _new(config.GetString(NotificationURLPropertyName))
+	return _new(config.GetString(NotificationURLPropertyName), notifier.HTTPClient(config))
 }
 
-func _new(url string) notifier.ViolationNotifier {
+func _new(url string, client *http.Client) notifier.ViolationNotifier {
 	return _notifier{
-		url: url,
+		url:    url,
+		client: client,
 	}
 }
 
 func logConfig(config *viper.Viper) {
 	log.Printf("RestNotifier configuration\n"+
-		"\tURL: %v\n",
-		config.GetString(NotificationURLPropertyName))
+		"\tURL: %v\n"+
+		"\tTimeout: %v\n",
+		config.GetString(NotificationURLPropertyName),
+		config.GetDuration(notifier.TimeoutPropertyName))
 
 }
 
@@ -67,14 +81,32 @@ func (not _notifier) NotifyViolations(agreement *model.Agreement, result *amodel
 		Violations:  vs,
 	}
 
-	b := new(bytes.Buffer)
-	json.NewEncoder(b).Encode(info)
-
-	_, err := http.Post(not.url, "application/json; charset=utf-8", b)
-
-	if err != nil {
+	if err := not.post(info); err != nil {
 		log.Errorf("RestNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
 	} else {
 		log.Infof("RestNotifier. Sent violations: %v", info)
 	}
 }
+
+/* Implements events.Listener */
+func (not _notifier) NotifyEvent(e events.Event) {
+	if err := not.post(e); err != nil {
+		log.Errorf("RestNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("RestNotifier. Sent event %s of agreement %s", e.Type, e.AgreementID)
+	}
+}
+
+func (not _notifier) post(payload interface{}) error {
+	b := new(bytes.Buffer)
+	json.NewEncoder(b).Encode(payload)
+
+	resp, err := not.client.Post(not.url, "application/json; charset=utf-8", b)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
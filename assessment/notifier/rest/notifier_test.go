@@ -4,8 +4,11 @@ import (
 	"SLALite/assessment"
 	amodel "SLALite/assessment/model"
 	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
 	"SLALite/model"
 	"SLALite/utils"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -30,7 +33,9 @@ func TestNew(t *testing.T) {
 	config.AutomaticEnv()
 	config.Set(NotificationURLPropertyName, "http://localhost:8080")
 
-	New(config)
+	if not := New(config).(_notifier); not.client.Timeout != notifier.DefaultTimeout {
+		t.Errorf("Unexpected timeout: %v", not.client.Timeout)
+	}
 }
 func TestSend(t *testing.T) {
 
@@ -40,7 +45,7 @@ func TestSend(t *testing.T) {
 	server.Start()
 	defer server.Close()
 
-	not := _new(server.URL)
+	not := _new(server.URL, http.DefaultClient)
 	not.NotifyViolations(&agreement, &result)
 }
 
@@ -51,7 +56,7 @@ func TestSendEmpty(t *testing.T) {
 	server.Start()
 	defer server.Close()
 
-	not := _new(server.URL)
+	not := _new(server.URL, http.DefaultClient)
 	not.NotifyViolations(&agreement, &amodel.Result{})
 }
 
@@ -62,10 +67,26 @@ func TestSendWrong(t *testing.T) {
 	server.Start()
 	defer server.Close()
 
-	not := _new("http://localhost:1")
+	not := _new("http://localhost:1", http.DefaultClient)
 	not.NotifyViolations(&agreement, &result)
 }
 
+func TestSendEvent(t *testing.T) {
+	Init()
+	var received events.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	not := _new(server.URL, http.DefaultClient).(events.Listener)
+	not.NotifyEvent(events.NewAgreementEvent(events.AgreementTerminated, &agreement))
+
+	if received.Type != events.AgreementTerminated || received.AgreementID != agreement.Id {
+		t.Errorf("Unexpected event received: %v", received)
+	}
+}
+
 func TestSendIntegration(t *testing.T) {
 	url, ok := os.LookupEnv("SLA_NOTIFICATION_URL")
 
@@ -74,7 +95,7 @@ func TestSendIntegration(t *testing.T) {
 	}
 	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
 
-	not := _new(url)
+	not := _new(url, http.DefaultClient)
 	not.NotifyViolations(&agreement, &result)
 }
 
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package slacknotifier contains a ViolationsNotifier that posts human readable
+// messages to a Slack or Mattermost incoming webhook.
+package slacknotifier
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/telemetry"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this notifier
+	Name = "slack"
+
+	// WebhookURLPropertyName is the config property name of the incoming webhook URL
+	WebhookURLPropertyName = "slackWebhookUrl"
+
+	// ChannelPropertyName is the config property name of the channel to post to.
+	// If empty, the default channel of the webhook is used.
+	ChannelPropertyName = "slackChannel"
+
+	// UsernamePropertyName is the config property name of the name the messages are posted with.
+	// If empty, the default name of the webhook is used.
+	UsernamePropertyName = "slackUsername"
+)
+
+type _notifier struct {
+	url      string
+	channel  string
+	username string
+	client   *http.Client
+}
+
+// message is the payload accepted by Slack and Mattermost incoming webhooks
+type message struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+func init() {
+	notifier.Register(Name, New,
+		registry.Property{Name: WebhookURLPropertyName, Description: "Incoming webhook URL"},
+		registry.Property{Name: ChannelPropertyName, Description: "Channel to post to"},
+		registry.Property{Name: UsernamePropertyName, Description: "Name to post with"},
+		notifier.TimeoutProperty)
+}
+
+// New constructs a Slack Notifier from a Viper configuration
+func New(config *viper.Viper) notifier.ViolationNotifier {
+
+	config.SetDefault(notifier.TimeoutPropertyName, notifier.DefaultTimeout)
+	logConfig(config)
+	return _new(
+		config.GetString(WebhookURLPropertyName),
+		config.GetString(ChannelPropertyName),
+		config.GetString(UsernamePropertyName),
+		notifier.HTTPClient(config))
+}
+
+func _new(url string, channel string, username string, client *http.Client) notifier.ViolationNotifier {
+	return _notifier{
+		url:      url,
+		channel:  channel,
+		username: username,
+		client:   client,
+	}
+}
+
+func logConfig(config *viper.Viper) {
+	log.Printf("SlackNotifier configuration\n"+
+		"\tURL: %v\n"+
+		"\tChannel: %v\n"+
+		"\tUsername: %v\n"+
+		"\tTimeout: %v\n",
+		config.GetString(WebhookURLPropertyName),
+		config.GetString(ChannelPropertyName),
+		config.GetString(UsernamePropertyName),
+		config.GetDuration(notifier.TimeoutPropertyName))
+}
+
+/* Implements notifier.NotifyViolations */
+func (not _notifier) NotifyViolations(agreement *model.Agreement, result *amodel.Result) {
+	if len(result.GetViolations()) == 0 {
+		return
+	}
+	not.send(notifier.ViolationsSubject(agreement, result), notifier.ViolationsText(agreement, result))
+}
+
+/* Implements events.Listener */
+func (not _notifier) NotifyEvent(e events.Event) {
+	not.send(notifier.EventSubject(e), notifier.EventText(e))
+}
+
+func (not _notifier) send(subject string, text string) {
+	msg := message{
+		Text:     fmt.Sprintf("*%s*\n```\n%s```", subject, text),
+		Channel:  not.channel,
+		Username: not.username,
+	}
+	if err := not.post(msg); err != nil {
+		log.Errorf("SlackNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("SlackNotifier. Sent message '%s'", subject)
+	}
+}
+
+func (not _notifier) post(payload interface{}) error {
+	b := new(bytes.Buffer)
+	json.NewEncoder(b).Encode(payload)
+
+	resp, err := not.client.Post(not.url, "application/json; charset=utf-8", b)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
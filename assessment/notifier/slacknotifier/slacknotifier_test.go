@@ -0,0 +1,125 @@
+package slacknotifier
+
+import (
+	"SLALite/assessment"
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var agreement model.Agreement
+var ma *simpleadapter.ArrayMonitoringAdapter
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(WebhookURLPropertyName, "http://localhost:8080/hooks/xxx")
+	config.Set(ChannelPropertyName, "sla")
+	config.Set(notifier.TimeoutPropertyName, "3s")
+
+	not := New(config).(_notifier)
+	if not.url != "http://localhost:8080/hooks/xxx" || not.channel != "sla" || not.client.Timeout != 3*time.Second {
+		t.Errorf("Unexpected notifier: %v", not)
+	}
+}
+
+func TestSend(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	var received message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	not := _new(server.URL, "sla", "slalite", http.DefaultClient)
+	not.NotifyViolations(&agreement, &result)
+
+	if received.Channel != "sla" || received.Username != "slalite" ||
+		!strings.Contains(received.Text, "SLA violation in agreement id: dijkstra") ||
+		!strings.Contains(received.Text, "Values: execution_time=1000") {
+		t.Errorf("Unexpected message: %v", received)
+	}
+}
+
+func TestSendEmpty(t *testing.T) {
+	Init()
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	not := _new(server.URL, "", "", http.DefaultClient)
+	not.NotifyViolations(&agreement, &amodel.Result{})
+	if called {
+		t.Error("Not expected a message without violations")
+	}
+}
+
+func TestSendWrong(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	not := _new(server.URL, "", "", http.DefaultClient)
+	not.NotifyViolations(&agreement, &result)
+}
+
+func TestSendTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	not := _new(server.URL, "", "", &http.Client{Timeout: 50 * time.Millisecond}).(_notifier)
+	start := time.Now()
+	if err := not.post(message{Text: "text"}); err == nil {
+		t.Error("Expected error on a hung endpoint")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the request to time out. Elapsed: %v", elapsed)
+	}
+}
+
+func TestSendEvent(t *testing.T) {
+	Init()
+	var received message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	not := _new(server.URL, "", "", http.DefaultClient).(events.Listener)
+	not.NotifyEvent(events.NewAgreementEvent(events.AgreementStarted, &agreement))
+
+	if !strings.Contains(received.Text, "Agreement started: agreement id") {
+		t.Errorf("Unexpected message: %v", received)
+	}
+}
+
+func Init() {
+	agreement, _ = utils.ReadAgreement("testdata/agreement.json")
+	ma = simpleadapter.New(amodel.GuaranteeData{
+		amodel.ExpressionData{
+			"execution_time": model.MetricValue{
+				Key:      "execution_time",
+				Value:    1000,
+				DateTime: time.Now(),
+			},
+		},
+	})
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smtpnotifier contains a ViolationsNotifier that sends human readable
+// emails about violations and lifecycle events through an SMTP server.
+package smtpnotifier
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/telemetry"
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this notifier
+	Name = "smtp"
+
+	// HostPropertyName is the config property name of the SMTP server host
+	HostPropertyName = "smtpHost"
+
+	// PortPropertyName is the config property name of the SMTP server port
+	PortPropertyName = "smtpPort"
+
+	// UserPropertyName is the config property name of the SMTP user. If empty, no authentication is done.
+	UserPropertyName = "smtpUser"
+
+	// PasswordPropertyName is the config property name of the SMTP password
+	PasswordPropertyName = "smtpPassword"
+
+	// FromPropertyName is the config property name of the sender address
+	FromPropertyName = "smtpFrom"
+
+	// ToPropertyName is the config property name of the comma separated list of recipients
+	ToPropertyName = "smtpTo"
+
+	defaultHost = "localhost"
+	defaultPort = 25
+	defaultFrom = "slalite@localhost"
+)
+
+type _notifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func init() {
+	notifier.Register(Name, New,
+		registry.Property{Name: HostPropertyName, Default: defaultHost, Description: "SMTP server host"},
+		registry.Property{Name: PortPropertyName, Default: defaultPort, Description: "SMTP server port"},
+		registry.Property{Name: UserPropertyName, Description: "SMTP user. No authentication if empty"},
+		registry.Property{Name: PasswordPropertyName, Description: "SMTP password"},
+		registry.Property{Name: FromPropertyName, Default: defaultFrom, Description: "Sender address"},
+		registry.Property{Name: ToPropertyName, Description: "Comma separated list of recipients"})
+}
+
+// New constructs an SMTP Notifier from a Viper configuration
+func New(config *viper.Viper) notifier.ViolationNotifier {
+
+	config.SetDefault(HostPropertyName, defaultHost)
+	config.SetDefault(PortPropertyName, defaultPort)
+	config.SetDefault(FromPropertyName, defaultFrom)
+	logConfig(config)
+
+	host := config.GetString(HostPropertyName)
+	var auth smtp.Auth
+	if user := config.GetString(UserPropertyName); user != "" {
+		auth = smtp.PlainAuth("", user, config.GetString(PasswordPropertyName), host)
+	}
+	return _new(
+		net.JoinHostPort(host, strconv.Itoa(config.GetInt(PortPropertyName))),
+		auth,
+		config.GetString(FromPropertyName),
+		splitAddresses(config.GetString(ToPropertyName)))
+}
+
+func _new(addr string, auth smtp.Auth, from string, to []string) notifier.ViolationNotifier {
+	return _notifier{
+		addr: addr,
+		auth: auth,
+		from: from,
+		to:   to,
+	}
+}
+
+func logConfig(config *viper.Viper) {
+	log.Printf("SmtpNotifier configuration\n"+
+		"\tServer: %s:%d\n"+
+		"\tUser: %s\n"+
+		"\tFrom: %s\n"+
+		"\tTo: %s\n",
+		config.GetString(HostPropertyName),
+		config.GetInt(PortPropertyName),
+		config.GetString(UserPropertyName),
+		config.GetString(FromPropertyName),
+		config.GetString(ToPropertyName))
+}
+
+/* Implements notifier.NotifyViolations */
+func (n _notifier) NotifyViolations(agreement *model.Agreement, result *amodel.Result) {
+	if len(result.GetViolations()) == 0 {
+		return
+	}
+	n.sendMail(notifier.ViolationsSubject(agreement, result), notifier.ViolationsText(agreement, result))
+}
+
+/* Implements events.Listener */
+func (n _notifier) NotifyEvent(e events.Event) {
+	n.sendMail(notifier.EventSubject(e), notifier.EventText(e))
+}
+
+func (n _notifier) sendMail(subject string, body string) {
+	if len(n.to) == 0 {
+		log.Warn("SmtpNotifier: no recipients configured")
+		return
+	}
+	msg := n.buildMessage(subject, body, time.Now())
+	if err := smtp.SendMail(n.addr, n.auth, n.from, n.to, msg); err != nil {
+		log.Errorf("SmtpNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("SmtpNotifier. Sent email '%s' to %v", subject, n.to)
+	}
+}
+
+func (n _notifier) buildMessage(subject string, body string, date time.Time) []byte {
+	b := new(bytes.Buffer)
+	fmt.Fprintf(b, "From: %s\r\n", n.from)
+	fmt.Fprintf(b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(b, "Subject: %s\r\n", headerValue(subject))
+	fmt.Fprintf(b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(b, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(b, "\r\n")
+	b.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return b.Bytes()
+}
+
+// headerValue removes the line breaks of a header value, which come from the
+// agreements, and encodes it (RFC 2047) if it is not plain ASCII
+func headerValue(s string) string {
+	s = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s)
+	return mime.QEncoding.Encode("utf-8", s)
+}
+
+func splitAddresses(s string) []string {
+	result := make([]string, 0)
+	for _, addr := range strings.Split(s, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			result = append(result, addr)
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package smtpnotifier
+
+import (
+	"SLALite/assessment"
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/utils"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var agreement model.Agreement
+var ma *simpleadapter.ArrayMonitoringAdapter
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(ToPropertyName, "a@example.com, b@example.com")
+
+	not := New(config).(_notifier)
+	if not.addr != "localhost:25" || len(not.to) != 2 || not.to[1] != "b@example.com" {
+		t.Errorf("Unexpected notifier: %v", not)
+	}
+}
+
+func TestSend(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	server, mails := startServer(t)
+	defer server.Close()
+
+	not := _new(server.Addr().String(), nil, "sla@example.com", []string{"manager@example.com"})
+	not.NotifyViolations(&agreement, &result)
+
+	mail := <-mails
+	if !strings.Contains(mail, "Subject: SLA violation in agreement id: dijkstra") {
+		t.Errorf("Unexpected subject in mail: %s", mail)
+	}
+	if !strings.Contains(mail, "Constraint: execution_time < 100") ||
+		!strings.Contains(mail, "Values: execution_time=1000") {
+		t.Errorf("Unexpected body in mail: %s", mail)
+	}
+}
+
+func TestSendEmpty(t *testing.T) {
+	Init()
+	not := _new("localhost:1", nil, "sla@example.com", []string{"manager@example.com"})
+	not.NotifyViolations(&agreement, &amodel.Result{})
+}
+
+func TestSendWrong(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+
+	not := _new("localhost:1", nil, "sla@example.com", []string{"manager@example.com"})
+	not.NotifyViolations(&agreement, &result)
+}
+
+func TestSendEvent(t *testing.T) {
+	Init()
+	server, mails := startServer(t)
+	defer server.Close()
+
+	not := _new(server.Addr().String(), nil, "sla@example.com", []string{"manager@example.com"})
+	not.(events.Listener).NotifyEvent(events.NewAgreementEvent(events.AgreementExpired, &agreement))
+
+	mail := <-mails
+	if !strings.Contains(mail, "Subject: Agreement expired: agreement id") {
+		t.Errorf("Unexpected mail: %s", mail)
+	}
+}
+
+func TestBuildMessageSubject(t *testing.T) {
+	not := _new("localhost:25", nil, "sla@example.com", []string{"manager@example.com"}).(_notifier)
+
+	msg := string(not.buildMessage("Violation in a01\r\nBcc: victim@example.com", "body", time.Now()))
+	if strings.Contains(msg, "\r\nBcc:") {
+		t.Errorf("Header injected in mail: %s", msg)
+	}
+	if !strings.Contains(msg, "Subject: Violation in a01 Bcc: victim@example.com\r\n") {
+		t.Errorf("Unexpected subject in mail: %s", msg)
+	}
+
+	msg = string(not.buildMessage("Violación en a01", "body", time.Now()))
+	if !strings.Contains(msg, "Subject: =?utf-8?q?Violaci=C3=B3n_en_a01?=\r\n") {
+		t.Errorf("Expected encoded subject in mail: %s", msg)
+	}
+}
+
+func Init() {
+	agreement, _ = utils.ReadAgreement("testdata/agreement.json")
+	ma = simpleadapter.New(amodel.GuaranteeData{
+		amodel.ExpressionData{
+			"execution_time": model.MetricValue{
+				Key:      "execution_time",
+				Value:    1000,
+				DateTime: time.Now(),
+			},
+		},
+	})
+}
+
+/*
+startServer starts a minimal SMTP server that accepts one mail and sends
+its data to the returned channel
+*/
+func startServer(t *testing.T) (net.Listener, chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error starting SMTP server: %s", err)
+	}
+	mails := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				lines, _ := tp.ReadDotLines()
+				mails <- strings.Join(lines, "\n")
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+	return l, mails
+}
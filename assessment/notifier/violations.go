@@ -23,3 +23,10 @@ import (
 type ViolationNotifier interface {
 	NotifyViolations(agreement *model.Agreement, result *assessment_model.Result)
 }
+
+// Flusher is implemented by notifiers that may keep notifications pending to
+// be sent (e.g. in a client buffer). Flush sends the pending notifications;
+// it is called on shutdown.
+type Flusher interface {
+	Flush() error
+}
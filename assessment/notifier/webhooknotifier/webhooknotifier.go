@@ -0,0 +1,230 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package webhooknotifier contains a ViolationsNotifier that posts violations and
+lifecycle events to an HTTP endpoint, with a body built from a user supplied
+text/template.
+
+The template is executed with a Message value. Besides the standard functions,
+the template may use:
+
+	json: encodes a value as JSON (e.g. {{json .Violations}})
+	date: formats a time.Time in RFC3339 (e.g. {{date .Event.Datetime}})
+
+Example of template:
+
+	{"summary": {{json .Subject}}, "agreement": "{{.AgreementID}}", "details": {{json .Text}}}
+*/
+package webhooknotifier
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/registry"
+	"SLALite/telemetry"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Name is the unique identifier of this notifier
+	Name = "webhook"
+
+	// URLPropertyName is the config property name of the URL to post to
+	URLPropertyName = "webhookUrl"
+
+	// TemplatePropertyName is the config property name of the body template
+	TemplatePropertyName = "webhookTemplate"
+
+	// TemplateFilePropertyName is the config property name of a file containing the
+	// body template. It is used if webhookTemplate is empty.
+	TemplateFilePropertyName = "webhookTemplateFile"
+
+	// ContentTypePropertyName is the config property name of the Content-Type of the requests
+	ContentTypePropertyName = "webhookContentType"
+
+	// DefaultTemplate sends the Message as JSON
+	DefaultTemplate = "{{json .}}"
+
+	// DefaultContentType is the default value of webhookContentType
+	DefaultContentType = "application/json; charset=utf-8"
+
+	// ViolationType is the Message type of violation notifications.
+	// Events have the event type (e.g. agreement_started).
+	ViolationType = "violation"
+)
+
+// Message is the data passed to the body template
+type Message struct {
+	Type        string            `json:"type"`
+	AgreementID string            `json:"agreement_id"`
+	Subject     string            `json:"subject"`
+	Text        string            `json:"text"`
+	Agreement   *model.Agreement  `json:"agreement,omitempty"`
+	Violations  []model.Violation `json:"violations,omitempty"`
+	Event       *events.Event     `json:"event,omitempty"`
+}
+
+type _notifier struct {
+	url         string
+	contentType string
+	tmpl        *template.Template
+	client      *http.Client
+}
+
+var funcs = template.FuncMap{
+	"json": toJSON,
+	"date": func(t time.Time) string { return t.Format(time.RFC3339) },
+}
+
+func init() {
+	notifier.Register(Name, New,
+		registry.Property{Name: URLPropertyName, Description: "URL to POST to"},
+		/* no default, so that webhookTemplateFile is read if webhookTemplate is not set */
+		registry.Property{Name: TemplatePropertyName, Description: "Go text/template of the body (default: " + DefaultTemplate + ")"},
+		registry.Property{Name: TemplateFilePropertyName, Description: "File with the template of the body"},
+		registry.Property{Name: ContentTypePropertyName, Default: DefaultContentType, Description: "Content-Type of the requests"},
+		notifier.TimeoutProperty)
+}
+
+// New constructs a Webhook Notifier from a Viper configuration.
+//
+// It terminates the program if the template cannot be read or parsed.
+func New(config *viper.Viper) notifier.ViolationNotifier {
+
+	config.SetDefault(ContentTypePropertyName, DefaultContentType)
+	config.SetDefault(notifier.TimeoutPropertyName, notifier.DefaultTimeout)
+	logConfig(config)
+
+	text, err := readTemplate(config)
+	if err != nil {
+		log.Fatal("Error reading webhook template: " + err.Error())
+	}
+	not, err := _new(config.GetString(URLPropertyName), config.GetString(ContentTypePropertyName), text,
+		notifier.HTTPClient(config))
+	if err != nil {
+		log.Fatal("Error parsing webhook template: " + err.Error())
+	}
+	return not
+}
+
+func _new(url string, contentType string, text string, client *http.Client) (notifier.ViolationNotifier, error) {
+	tmpl, err := template.New("webhook").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return _notifier{
+		url:         url,
+		contentType: contentType,
+		tmpl:        tmpl,
+		client:      client,
+	}, nil
+}
+
+func readTemplate(config *viper.Viper) (string, error) {
+	if text := config.GetString(TemplatePropertyName); text != "" {
+		return text, nil
+	}
+	if path := config.GetString(TemplateFilePropertyName); path != "" {
+		b, err := ioutil.ReadFile(path)
+		return string(b), err
+	}
+	return DefaultTemplate, nil
+}
+
+func logConfig(config *viper.Viper) {
+	log.Printf("WebhookNotifier configuration\n"+
+		"\tURL: %v\n"+
+		"\tContent type: %v\n"+
+		"\tTemplate: %v\n"+
+		"\tTemplate file: %v\n"+
+		"\tTimeout: %v\n",
+		config.GetString(URLPropertyName),
+		config.GetString(ContentTypePropertyName),
+		config.GetString(TemplatePropertyName),
+		config.GetString(TemplateFilePropertyName),
+		config.GetDuration(notifier.TimeoutPropertyName))
+}
+
+/* Implements notifier.NotifyViolations */
+func (not _notifier) NotifyViolations(agreement *model.Agreement, result *amodel.Result) {
+	vs := result.GetViolations()
+	if len(vs) == 0 {
+		return
+	}
+	not.send(Message{
+		Type:        ViolationType,
+		AgreementID: agreement.Id,
+		Subject:     notifier.ViolationsSubject(agreement, result),
+		Text:        notifier.ViolationsText(agreement, result),
+		Agreement:   agreement,
+		Violations:  vs,
+	})
+}
+
+/* Implements events.Listener */
+func (not _notifier) NotifyEvent(e events.Event) {
+	not.send(Message{
+		Type:        string(e.Type),
+		AgreementID: e.AgreementID,
+		Subject:     notifier.EventSubject(e),
+		Text:        notifier.EventText(e),
+		Agreement:   e.Agreement,
+		Event:       &e,
+	})
+}
+
+func (not _notifier) send(msg Message) {
+	b := new(bytes.Buffer)
+	if err := not.tmpl.Execute(b, msg); err != nil {
+		log.Errorf("WebhookNotifier error executing template: %s", err)
+		return
+	}
+	if err := not.post(b); err != nil {
+		log.Errorf("WebhookNotifier error: %s", err)
+		telemetry.NotifierFailures.Inc(Name)
+	} else {
+		log.Infof("WebhookNotifier. Sent %s of agreement %s", msg.Type, msg.AgreementID)
+	}
+}
+
+func (not _notifier) post(body *bytes.Buffer) error {
+	resp, err := not.client.Post(not.url, not.contentType, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
@@ -0,0 +1,151 @@
+package webhooknotifier
+
+import (
+	"SLALite/assessment"
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/assessment/notifier"
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/utils"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var agreement model.Agreement
+var ma *simpleadapter.ArrayMonitoringAdapter
+
+func TestNew(t *testing.T) {
+	config := viper.New()
+	config.Set(URLPropertyName, "http://localhost:8080")
+	config.Set(TemplateFilePropertyName, "testdata/template.txt")
+
+	not := New(config).(_notifier)
+	if not.contentType != DefaultContentType || not.tmpl == nil {
+		t.Errorf("Unexpected notifier: %v", not)
+	}
+}
+
+func TestNewFromRegistry(t *testing.T) {
+	config := viper.New()
+	config.Set(URLPropertyName, "http://localhost:8080")
+	config.Set(TemplateFilePropertyName, "testdata/template.txt")
+
+	not, err := notifier.New(Name, config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b := new(bytes.Buffer)
+	not.(_notifier).tmpl.Execute(b, Message{Subject: "s", AgreementID: "a01"})
+	if b.String() != `{"summary": "s", "agreement": "a01"}`+"\n" {
+		t.Errorf("Expected template of the file. Actual: %s", b.String())
+	}
+
+	not, _ = notifier.New(Name, viper.New())
+	if text := not.(_notifier).tmpl.Root.String(); text != DefaultTemplate {
+		t.Errorf("Expected default template. Actual: %s", text)
+	}
+}
+
+func TestNewWrongTemplate(t *testing.T) {
+	if _, err := _new("http://localhost:8080", DefaultContentType, "{{.Type", http.DefaultClient); err == nil {
+		t.Error("Expected error on wrong template")
+	}
+}
+
+func TestSend(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	var body string
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		contentType = r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	not, _ := _new(server.URL, "text/plain", "{{.Type}} {{.AgreementID}} {{range .Violations}}{{.Guarantee}}{{end}}", http.DefaultClient)
+	not.NotifyViolations(&agreement, &result)
+
+	if body != "violation id dijkstra" || contentType != "text/plain" {
+		t.Errorf("Unexpected request. Body: %s; Content-Type: %s", body, contentType)
+	}
+}
+
+func TestSendDefaultTemplate(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	not, _ := _new(server.URL, DefaultContentType, DefaultTemplate, http.DefaultClient)
+	not.NotifyViolations(&agreement, &result)
+
+	if received.Type != ViolationType || received.AgreementID != agreement.Id || len(received.Violations) != 1 {
+		t.Errorf("Unexpected message: %v", received)
+	}
+}
+
+func TestSendEmpty(t *testing.T) {
+	Init()
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	not, _ := _new(server.URL, DefaultContentType, DefaultTemplate, http.DefaultClient)
+	not.NotifyViolations(&agreement, &amodel.Result{})
+	if called {
+		t.Error("Not expected a request without violations")
+	}
+}
+
+func TestSendWrong(t *testing.T) {
+	Init()
+	result, _ := assessment.EvaluateAgreement(&agreement, ma, time.Now())
+
+	not, _ := _new("http://localhost:1", DefaultContentType, DefaultTemplate, http.DefaultClient)
+	not.NotifyViolations(&agreement, &result)
+}
+
+func TestSendEvent(t *testing.T) {
+	Init()
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	not, _ := _new(server.URL, DefaultContentType, `{"type": "{{.Type}}", "state": "{{.Event.ToState}}"}`, http.DefaultClient)
+	e, _ := events.NewTransitionEvent(&agreement, model.STOPPED, model.STARTED)
+	not.(events.Listener).NotifyEvent(e)
+
+	if received["type"] != string(events.AgreementStarted) || received["state"] != string(model.STARTED) {
+		t.Errorf("Unexpected message: %v", received)
+	}
+}
+
+func Init() {
+	agreement, _ = utils.ReadAgreement("testdata/agreement.json")
+	ma = simpleadapter.New(amodel.GuaranteeData{
+		amodel.ExpressionData{
+			"execution_time": model.MetricValue{
+				Key:      "execution_time",
+				Value:    1000,
+				DateTime: time.Now(),
+			},
+		},
+	})
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assessment
+
+import (
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxReplaySteps is the maximum number of steps of a replay
+const MaxReplaySteps = 100000
+
+// Replay evaluates the agreement a over the period (from, to], as the periodic
+// assessment would have done every step, with the monitoring values of the period.
+// It is the same as NewReplay followed by RunReplay.
+//
+// The guarantee terms of a may be modified to check new guarantee terms against
+// past values. The agreement is evaluated as if it were started at from; the
+// stored agreement and its assessment are not modified, and the violations are
+// not notified. The failing values in the maintenance windows are excluded.
+func Replay(a model.Agreement, ma monitor.MonitoringAdapter, from, to time.Time, step time.Duration,
+	windows ...model.MaintenanceWindow) (*model.Replay, error) {
+	replay, err := NewReplay(a, from, to, step)
+	if err != nil {
+		return nil, err
+	}
+	RunReplay(replay, a, ma, windows...)
+	return replay, nil
+}
+
+// NewReplay checks the period of a replay of the agreement a and returns the
+// replay, in running status and without results. The replay is evaluated by RunReplay.
+func NewReplay(a model.Agreement, from, to time.Time, step time.Duration) (*model.Replay, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from (%v) must be before to (%v)", from, to)
+	}
+	if step < time.Second {
+		return nil, fmt.Errorf("step (%v) must be at least 1s", step)
+	}
+	if steps := to.Sub(from) / step; steps > MaxReplaySteps {
+		return nil, fmt.Errorf("too many steps (%d). The maximum is %d", steps, MaxReplaySteps)
+	}
+
+	replay := &model.Replay{
+		Id:          uuid.New().String(),
+		AgreementId: a.Id,
+		From:        from,
+		To:          to,
+		Step:        int(step / time.Second),
+		Created:     time.Now(),
+		Status:      model.REPLAYRUNNING,
+		Guarantees:  a.Details.Guarantees,
+		Results:     make(map[string]model.ReplayGuarantee),
+		Violations:  make([]model.Violation, 0),
+	}
+	return replay, nil
+}
+
+// RunReplay evaluates the agreement a over the period of replay (see Replay),
+// filling the results of replay and setting its status to done.
+func RunReplay(replay *model.Replay, a model.Agreement, ma monitor.MonitoringAdapter,
+	windows ...model.MaintenanceWindow) {
+	from, to := replay.From, replay.To
+	step := time.Duration(replay.Step) * time.Second
+	log.Infof("Replay(%s). From %v to %v every %v", a.Id, from, to, step)
+
+	a.State = model.STARTED
+	a.Assessment = model.Assessment{
+		LastExecution:    from,
+		MonitoringURL:    a.Assessment.MonitoringURL,
+		MonitoringSource: a.Assessment.MonitoringSource,
+		Interpolation:    a.Assessment.Interpolation,
+	}
+	for now := from; now.Before(to) && a.State == model.STARTED; {
+		now = now.Add(step)
+		if now.After(to) {
+			now = to
+		}
+		result := AssessAgreement(&a, ma, now, windows...)
+
+		for _, gt := range a.Details.Guarantees {
+			gtResult := replay.Results[gt.Name]
+			for _, p := range result.Evaluated[gt.Name] {
+				gtResult.Evaluated++
+				if p.Failed {
+					gtResult.Failed++
+				} else if p.Excluded {
+					gtResult.Excluded++
+				}
+			}
+			if result.Errors[gt.Name] != nil {
+				gtResult.Errors++
+			} else if result.NoData[gt.Name] {
+				gtResult.NoData++
+			}
+			replay.Results[gt.Name] = gtResult
+		}
+		replay.Violations = append(replay.Violations, result.GetViolations()...)
+	}
+
+	for name, gtResult := range replay.Results {
+		gtResult.Compliance = 1
+		if gtResult.Evaluated > 0 {
+			gtResult.Compliance = float64(gtResult.Evaluated-gtResult.Failed) / float64(gtResult.Evaluated)
+		}
+		replay.Results[name] = gtResult
+	}
+	sort.SliceStable(replay.Violations, func(i, j int) bool {
+		return replay.Violations[i].Datetime.Before(replay.Violations[j].Datetime)
+	})
+	replay.Status = model.REPLAYDONE
+}
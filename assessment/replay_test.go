@@ -0,0 +1,85 @@
+package assessment
+
+import (
+	assessment_model "SLALite/assessment/model"
+	"SLALite/assessment/monitor"
+	"SLALite/model"
+	"testing"
+	"time"
+)
+
+// windowAdapter returns the values in the interval of the retrieval items
+type windowAdapter struct {
+	agreement *model.Agreement
+	values    assessment_model.GuaranteeData
+}
+
+func (wa windowAdapter) Initialize(a *model.Agreement) monitor.MonitoringAdapter {
+	wa.agreement = a
+	return wa
+}
+
+func (wa windowAdapter) GetValues(gt model.Guarantee, vars []string, now time.Time) (assessment_model.GuaranteeData, error) {
+	item := BuildRetrievalItems(wa.agreement, gt, vars, now)[0]
+	result := assessment_model.GuaranteeData{}
+	for _, p := range wa.values {
+		if t := p.Datetime(); t.After(item.From) && !t.After(item.To) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func TestReplay(t *testing.T) {
+	a := createAgreement("arp01", p1, c2, "Agreement arp01", "m >= 10")
+	a.State = model.STOPPED
+	a.Assessment.LastExecution = t_(100)
+
+	values := assessment_model.GuaranteeData{}
+	for i, v := range []int{20, 5, 15, 3, 30, 40} {
+		values = append(values, assessment_model.ExpressionData{
+			"m": model.MetricValue{Key: "m", Value: v, DateTime: t_(time.Duration(i*10 + 5))},
+		})
+	}
+	ma := windowAdapter{values: values}
+
+	replay, err := Replay(a, ma, t_(0), t_(60), 20*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.State != model.STOPPED || !a.Assessment.LastExecution.Equal(t_(100)) {
+		t.Errorf("Replay modified the agreement: %+v", a)
+	}
+	if replay.Status != model.REPLAYDONE {
+		t.Errorf("Unexpected status: %s", replay.Status)
+	}
+	result := replay.Results["TestGuarantee"]
+	if result.Evaluated != 6 || result.Failed != 2 || result.Compliance != 4.0/6 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(replay.Violations) != 2 || !replay.Violations[0].Datetime.Before(replay.Violations[1].Datetime) {
+		t.Errorf("Unexpected violations: %v", replay.Violations)
+	}
+
+	t.Run("Guarantees", func(t *testing.T) {
+		a.Details.Guarantees[0].Constraint = "m >= 25"
+		replay, _ := Replay(a, ma, t_(0), t_(45), 20*time.Second)
+		if r := replay.Results["TestGuarantee"]; r.Evaluated != 5 || r.Failed != 4 || len(replay.Violations) != 4 {
+			t.Errorf("Unexpected result: %+v", r)
+		}
+	})
+	t.Run("NoData", func(t *testing.T) {
+		replay, _ := Replay(a, windowAdapter{}, t_(0), t_(60), 20*time.Second)
+		if r := replay.Results["TestGuarantee"]; r.NoData != 3 || r.Compliance != 1 {
+			t.Errorf("Unexpected result: %+v", r)
+		}
+	})
+	t.Run("WrongPeriod", func(t *testing.T) {
+		if _, err := Replay(a, ma, t_(60), t_(0), time.Second); err == nil {
+			t.Error("Expected error on from after to")
+		}
+		if _, err := Replay(a, ma, t_(0), t_(60), 0); err == nil {
+			t.Error("Expected error on zero step")
+		}
+	})
+}
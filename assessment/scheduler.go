@@ -0,0 +1,172 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assessment
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/model"
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Scheduler runs AssessActiveAgreements periodically. It is safe for concurrent use.
+//
+// The runs may be paused and resumed, and a run may be triggered at any moment.
+// Only one run is executed at a time, and the on-demand assessments
+// (see AssessAgreement) wait for the run in progress.
+type Scheduler struct {
+	cfg     Config
+	period  time.Duration
+	trigger chan struct{}
+
+	/* assessMu serializes the runs and the on-demand assessments */
+	assessMu sync.Mutex
+
+	mu      sync.Mutex
+	paused  bool
+	running bool
+	lastRun time.Time
+}
+
+// SchedulerStatus is the status of a Scheduler
+type SchedulerStatus struct {
+	Paused  bool       `json:"paused"`
+	Running bool       `json:"running"`
+	Period  string     `json:"period"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+}
+
+// NewScheduler returns a Scheduler that assesses the agreements with cfg every period
+func NewScheduler(cfg Config, period time.Duration) *Scheduler {
+	return &Scheduler{
+		cfg:     cfg,
+		period:  period,
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// Run executes the periodic assessment until ctx is done. A run in progress
+// when ctx is done stops after the agreement being evaluated (see Config.Context),
+// and Run returns after it.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Assessment scheduler stopped")
+			return
+		case <-ticker.C:
+			if s.Paused() {
+				log.Debug("Assessment scheduler paused. Skipping run")
+				continue
+			}
+			s.run(ctx)
+		case <-s.trigger:
+			s.run(ctx)
+		}
+	}
+}
+
+// Trigger requests an immediate run, even if the scheduler is paused. It returns
+// false if there is already a run requested that has not started yet.
+func (s *Scheduler) Trigger() bool {
+	select {
+	case s.trigger <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pause skips the periodic runs until Resume is called. A run in progress is not affected.
+func (s *Scheduler) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+}
+
+// Resume restarts the periodic runs
+func (s *Scheduler) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+}
+
+// Paused returns if the periodic runs are paused
+func (s *Scheduler) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// Status returns the current status of the scheduler
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := SchedulerStatus{
+		Paused:  s.paused,
+		Running: s.running,
+		Period:  s.period.String(),
+	}
+	if !s.lastRun.IsZero() {
+		lastRun := s.lastRun
+		result.LastRun = &lastRun
+	}
+	return result
+}
+
+// AssessAgreement assesses the agreement identified by id, persisting and
+// notifying the results as a periodic run does. It waits for the run in
+// progress, if any, and reads the agreement afterwards, so that the
+// assessment is not overwritten by the run.
+func (s *Scheduler) AssessAgreement(id string) (*model.Agreement, amodel.Result, error) {
+	s.assessMu.Lock()
+	defer s.assessMu.Unlock()
+
+	a, err := s.cfg.Repo.GetAgreement(id)
+	if err != nil {
+		return nil, amodel.Result{}, err
+	}
+	return a, AssessSingleAgreement(s.cfg, a), nil
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	s.assessMu.Lock()
+	defer s.assessMu.Unlock()
+
+	s.setRunning(true, time.Time{})
+	cfg := s.cfg
+	cfg.Context = ctx
+	start := time.Now()
+	AssessActiveAgreements(cfg)
+	s.setRunning(false, start)
+}
+
+func (s *Scheduler) setRunning(running bool, lastRun time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = running
+	if !lastRun.IsZero() {
+		s.lastRun = lastRun
+	}
+}
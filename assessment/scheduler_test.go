@@ -0,0 +1,156 @@
+package assessment
+
+import (
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/model"
+	"context"
+	"testing"
+	"time"
+)
+
+// countingRepo counts the assessment runs
+type countingRepo struct {
+	model.IRepository
+	runs    chan struct{}
+	updates int
+}
+
+func (r *countingRepo) GetAgreementsByState(states ...model.State) (model.Agreements, error) {
+	r.runs <- struct{}{}
+	return r.IRepository.GetAgreementsByState(states...)
+}
+
+func (r *countingRepo) UpdateAgreement(a *model.Agreement) (*model.Agreement, error) {
+	r.updates++
+	return r.IRepository.UpdateAgreement(a)
+}
+
+func TestScheduler(t *testing.T) {
+	crepo := &countingRepo{IRepository: repo, runs: make(chan struct{}, 10)}
+	s := NewScheduler(Config{
+		Repo:    crepo,
+		Adapter: simpleadapter.New(nil),
+	}, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	waitRun(t, crepo.runs)
+
+	s.Pause()
+	if !s.Status().Paused {
+		t.Error("Expected paused scheduler")
+	}
+	time.Sleep(30 * time.Millisecond)
+	drain(crepo.runs)
+	time.Sleep(50 * time.Millisecond)
+	if len(crepo.runs) != 0 {
+		t.Errorf("Expected no runs while paused. Actual: %d", len(crepo.runs))
+	}
+	if st := s.Status(); st.LastRun == nil || st.Running {
+		t.Errorf("Unexpected status %+v", st)
+	}
+
+	if !s.Trigger() {
+		t.Error("Expected triggered run")
+	}
+	waitRun(t, crepo.runs)
+
+	s.Resume()
+	waitRun(t, crepo.runs)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Scheduler did not stop on cancellation")
+	}
+}
+
+func TestAssessActiveAgreementsCancelled(t *testing.T) {
+	a := createAgreement("asc01", p1, c2, "Agreement asc01", "m >= 0")
+	a.State = model.STARTED
+	repo.CreateAgreement(&a)
+	defer repo.DeleteAgreement(&a)
+
+	crepo := &countingRepo{IRepository: repo, runs: make(chan struct{}, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	AssessActiveAgreements(Config{
+		Repo:    crepo,
+		Adapter: simpleadapter.New(nil),
+		Context: ctx,
+	})
+	if crepo.updates != 0 {
+		t.Errorf("Expected no agreements evaluated. Actual: %d", crepo.updates)
+	}
+}
+
+func waitRun(t *testing.T, runs chan struct{}) {
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("Expected an assessment run")
+	}
+}
+
+func drain(runs chan struct{}) {
+	for len(runs) > 0 {
+		<-runs
+	}
+}
+
+type blockingRepo struct {
+	model.IRepository
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRepo) GetAgreementsByState(states ...model.State) (model.Agreements, error) {
+	close(r.started)
+	<-r.release
+	return r.IRepository.GetAgreementsByState(states...)
+}
+
+func TestSchedulerAssessAgreement(t *testing.T) {
+	a := createAgreement("sch01", p1, c2, "Agreement sch01", "m >= 0")
+	a.State = model.STARTED
+	repo.CreateAgreement(&a)
+	defer repo.DeleteAgreement(&a)
+
+	brepo := &blockingRepo{IRepository: repo, started: make(chan struct{}), release: make(chan struct{})}
+	s := NewScheduler(Config{
+		Repo:    brepo,
+		Adapter: simpleadapter.New(nil),
+	}, time.Hour)
+
+	go s.run(context.Background())
+	<-brepo.started
+
+	done := make(chan struct{})
+	go func() {
+		if _, _, err := s.AssessAgreement(a.Id); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected the assessment to wait for the run in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(brepo.release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the assessment after the run")
+	}
+
+	if _, _, err := s.AssessAgreement("notfound"); err == nil {
+		t.Error("Expected error assessing a non existing agreement")
+	}
+}
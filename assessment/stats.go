@@ -0,0 +1,102 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assessment
+
+import (
+	amodel "SLALite/assessment/model"
+	"SLALite/model"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// saveResults persists the violations of an assessment and adds its evaluation
+// counters to the daily stats of each guarantee term, to be used in reports,
+// and its point sets to the evaluation history.
+func (cfg Config) saveResults(a *model.Agreement, result amodel.Result, now time.Time) {
+	if result.LastExecution == nil {
+		/* not evaluated */
+		return
+	}
+	stats := make(map[string]*model.DailyStats)
+	get := func(gtname string, t time.Time) *model.DailyStats {
+		s := model.NewDailyStats(a.Id, gtname, t)
+		if current, ok := stats[s.Id]; ok {
+			return current
+		}
+		stats[s.Id] = &s
+		return &s
+	}
+
+	for _, gt := range a.Details.Guarantees {
+		for _, p := range result.Evaluated[gt.Name] {
+			s := get(gt.Name, p.Values.Datetime())
+			s.Evaluated++
+			if p.Failed {
+				s.Failed++
+			} else if p.Excluded {
+				s.Excluded++
+			}
+		}
+		if result.Errors[gt.Name] != nil {
+			get(gt.Name, now).Errors++
+		} else if result.NoData[gt.Name] {
+			get(gt.Name, now).NoData++
+		}
+	}
+	for _, gtResult := range result.Violated {
+		for i := range gtResult.Violations {
+			/* the id is set in the result, so that notified violations have it */
+			v := &gtResult.Violations[i]
+			if v.Id == "" {
+				v.Id = uuid.New().String()
+			}
+			if _, err := cfg.Repo.CreateViolation(v); err != nil {
+				log.Errorf("Error saving violation of agreement %s: %s", a.Id, err.Error())
+			}
+			get(v.Guarantee, v.Datetime).Violations++
+		}
+	}
+
+	for _, s := range stats {
+		if err := cfg.addDailyStats(s); err != nil {
+			log.Errorf("Error saving stats of agreement %s: %s", a.Id, err.Error())
+		}
+	}
+	cfg.saveHistory(a, result, now)
+}
+
+// addDailyStats adds s to the stored stats of the same guarantee term and day
+func (cfg Config) addDailyStats(s *model.DailyStats) error {
+	stored, err := cfg.Repo.GetDailyStats(s.AgreementId, s.Date, s.Date.Add(24*time.Hour))
+	if err != nil {
+		return err
+	}
+	for _, current := range stored {
+		if current.Id == s.Id {
+			s.Evaluated += current.Evaluated
+			s.Failed += current.Failed
+			s.Excluded += current.Excluded
+			s.NoData += current.NoData
+			s.Errors += current.Errors
+			s.Violations += current.Violations
+		}
+	}
+	_, err = cfg.Repo.SaveDailyStats(s)
+	return err
+}
@@ -0,0 +1,42 @@
+package assessment
+
+import (
+	assessment_model "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
+	"SLALite/model"
+	"testing"
+	"time"
+)
+
+func TestSaveResults(t *testing.T) {
+	a := createAgreement("ast01", p1, c2, "Agreement ast01", "m >= 10")
+	a.State = model.STARTED
+	repo.CreateAgreement(&a)
+	defer repo.DeleteAgreement(&a)
+
+	values := assessment_model.GuaranteeData{
+		createSimpleEvaluationData("m", 5),
+		createSimpleEvaluationData("m", 15),
+	}
+	cfg := Config{
+		Repo:    repo,
+		Adapter: simpleadapter.New(values),
+		Now:     time.Now(),
+	}
+	AssessSingleAgreement(cfg, &a)
+	AssessSingleAgreement(cfg, &a)
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	stats, err := repo.GetDailyStats(a.Id, day, day.Add(24*time.Hour))
+	if err != nil || len(stats) != 1 {
+		t.Fatalf("Expected stats of a day. Actual: %v (%v)", stats, err)
+	}
+	if s := stats[0]; s.Guarantee != "TestGuarantee" || s.Evaluated != 4 || s.Failed != 2 || s.Violations != 2 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+
+	violations, err := repo.GetViolationsByAgreement(a.Id, day, day.Add(24*time.Hour))
+	if err != nil || len(violations) != 2 {
+		t.Errorf("Expected 2 saved violations. Actual: %v (%v)", violations, err)
+	}
+}
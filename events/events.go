@@ -0,0 +1,261 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package events defines the lifecycle events of an agreement (creation, state
+transitions, expiration and deletion) and a simple bus to deliver them to
+the interested listeners.
+
+Usage:
+
+	bus := events.NewBus()
+	bus.Subscribe(listener)
+	...
+	bus.Publish(events.Event{Type: events.AgreementCreated, ...})
+
+A bus returned by NewQueuedBus delivers the events in a goroutine, so that the
+listeners that do network I/O (e.g. notifiers) do not block the publishers:
+
+	bus := events.NewQueuedBus(1000)
+	defer bus.Close()
+*/
+package events
+
+import (
+	"SLALite/model"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type is the type of a lifecycle event
+type Type string
+
+const (
+	// AgreementCreated is published when an agreement is stored in the repository
+	AgreementCreated Type = "agreement_created"
+
+	// AgreementStarted is published when an agreement transits to STARTED
+	AgreementStarted Type = "agreement_started"
+
+	// AgreementStopped is published when an agreement transits to STOPPED
+	AgreementStopped Type = "agreement_stopped"
+
+	// AgreementTerminated is published when an agreement transits to TERMINATED
+	AgreementTerminated Type = "agreement_terminated"
+
+	// AgreementExpired is published instead of AgreementTerminated when an
+	// agreement is terminated because its expiration date has passed
+	AgreementExpired Type = "agreement_expired"
+
+	// AgreementDeleted is published when an agreement is removed from the repository
+	AgreementDeleted Type = "agreement_deleted"
+
+	// IncidentOpened is published when the first violation of a guarantee term opens an incident
+	IncidentOpened Type = "incident_opened"
+
+	// IncidentClosed is published when a guarantee term with an open incident is fulfilled again
+	IncidentClosed Type = "incident_closed"
+
+	// GuaranteeNoData is published when a guarantee term with the WARN missing data
+	// policy starts to have no values
+	GuaranteeNoData Type = "guarantee_no_data"
+)
+
+// Event is the information sent to listeners on a lifecycle event.
+//
+// FromState and ToState are only set on state transitions; Incident is only
+// set on incident events; Guarantee is only set on guarantee events.
+// swagger:model
+type Event struct {
+	Type        Type             `json:"type"`
+	AgreementID string           `json:"agreement_id"`
+	Datetime    time.Time        `json:"datetime"`
+	FromState   model.State      `json:"from_state,omitempty"`
+	ToState     model.State      `json:"to_state,omitempty"`
+	Agreement   *model.Agreement `json:"agreement,omitempty"`
+	Incident    *model.Incident  `json:"incident,omitempty"`
+	Guarantee   string           `json:"guarantee,omitempty"`
+}
+
+// Listener is implemented by the entities interested in lifecycle events
+// (e.g., notifiers)
+type Listener interface {
+	NotifyEvent(e Event)
+}
+
+// ListenerFunc is an adapter to use ordinary functions as Listeners
+type ListenerFunc func(e Event)
+
+// NotifyEvent implements Listener
+func (f ListenerFunc) NotifyEvent(e Event) {
+	f(e)
+}
+
+// Publisher is the interface used by the emitters of events
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Bus is a Publisher that dispatches each event to all the subscribed
+// listeners, in subscription order. The dispatch is synchronous, unless the
+// Bus is queued (see NewQueuedBus).
+//
+// A nil *Bus is a valid Publisher that discards all the events.
+type Bus struct {
+	mu        sync.RWMutex
+	listeners []Listener
+	queue     chan Event
+	closed    bool
+	done      chan struct{}
+}
+
+// NewBus returns an empty Bus that dispatches the events synchronously
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// NewQueuedBus returns an empty Bus that queues up to capacity events and
+// dispatches them in order in a goroutine. When the queue is full, the
+// published events are discarded. Call Close to dispatch the queued events
+// and stop the goroutine.
+func NewQueuedBus(capacity int) *Bus {
+	b := &Bus{
+		queue: make(chan Event, capacity),
+		done:  make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *Bus) run() {
+	for e := range b.queue {
+		b.dispatch(e)
+	}
+	close(b.done)
+}
+
+// Close waits until the queued events are dispatched. The events published
+// afterwards are discarded. It does nothing if the Bus is not queued.
+func (b *Bus) Close() {
+	if b == nil || b.queue == nil {
+		return
+	}
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+	<-b.done
+}
+
+// Subscribe adds a listener to the bus
+func (b *Bus) Subscribe(l Listener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, l)
+}
+
+// Publish sends an event to every subscribed listener.
+//
+// If the event has no Datetime, it is set to the current time. A queued Bus
+// sends a copy of the agreement and the incident, as the publisher may modify
+// them before the event is dispatched.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Datetime.IsZero() {
+		e.Datetime = time.Now()
+	}
+	log.Debugf("Publishing event %s of agreement %s", e.Type, e.AgreementID)
+
+	if b.queue == nil {
+		b.dispatch(e)
+		return
+	}
+	if e.Agreement != nil {
+		a := *e.Agreement
+		e.Agreement = &a
+	}
+	if e.Incident != nil {
+		i := *e.Incident
+		e.Incident = &i
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		log.Warnf("Event %s of agreement %s discarded: the bus is closed", e.Type, e.AgreementID)
+		return
+	}
+	select {
+	case b.queue <- e:
+	default:
+		log.Errorf("Event %s of agreement %s discarded: the queue is full", e.Type, e.AgreementID)
+	}
+}
+
+func (b *Bus) dispatch(e Event) {
+	b.mu.RLock()
+	listeners := b.listeners
+	b.mu.RUnlock()
+
+	for _, l := range listeners {
+		l.NotifyEvent(e)
+	}
+}
+
+// NewAgreementEvent builds an event about an agreement
+func NewAgreementEvent(t Type, a *model.Agreement) Event {
+	return Event{
+		Type:        t,
+		AgreementID: a.Id,
+		Agreement:   a,
+	}
+}
+
+// NewIncidentEvent builds an event about an incident of an agreement
+func NewIncidentEvent(t Type, a *model.Agreement, i *model.Incident) Event {
+	e := NewAgreementEvent(t, a)
+	e.Incident = i
+	return e
+}
+
+// NewTransitionEvent builds the event corresponding to a state transition of
+// an agreement. The returned bool is false if there is not such a transition
+// (i.e., from == to).
+func NewTransitionEvent(a *model.Agreement, from model.State, to model.State) (Event, bool) {
+	if from == to {
+		return Event{}, false
+	}
+	var t Type
+	switch to {
+	case model.STARTED:
+		t = AgreementStarted
+	case model.STOPPED:
+		t = AgreementStopped
+	case model.TERMINATED:
+		t = AgreementTerminated
+	default:
+		return Event{}, false
+	}
+	e := NewAgreementEvent(t, a)
+	e.FromState = from
+	e.ToState = to
+	return e, true
+}
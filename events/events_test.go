@@ -0,0 +1,104 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"SLALite/model"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	bus := NewBus()
+	received := make([]Event, 0)
+	bus.Subscribe(ListenerFunc(func(e Event) {
+		received = append(received, e)
+	}))
+	bus.Subscribe(ListenerFunc(func(e Event) {
+		received = append(received, e)
+	}))
+
+	a := model.Agreement{Id: "a01"}
+	bus.Publish(NewAgreementEvent(AgreementCreated, &a))
+
+	if expected, actual := 2, len(received); expected != actual {
+		t.Fatalf("Expected: %d; Actual: %d", expected, actual)
+	}
+	e := received[0]
+	if e.Type != AgreementCreated || e.AgreementID != "a01" {
+		t.Errorf("Unexpected event: %v", e)
+	}
+	if e.Datetime.IsZero() {
+		t.Errorf("Datetime not set")
+	}
+}
+
+func TestPublishQueued(t *testing.T) {
+	bus := NewQueuedBus(2)
+	release := make(chan struct{})
+	received := make([]Event, 0)
+	bus.Subscribe(ListenerFunc(func(e Event) {
+		<-release
+		received = append(received, e)
+	}))
+
+	/* the publisher is not blocked by the listener */
+	a := model.Agreement{Id: "a01", State: model.STARTED}
+	bus.Publish(NewAgreementEvent(AgreementCreated, &a))
+	a.State = model.TERMINATED
+	for i := 0; i < 5; i++ {
+		bus.Publish(NewAgreementEvent(AgreementDeleted, &a))
+	}
+	close(release)
+	bus.Close()
+	bus.Publish(NewAgreementEvent(AgreementDeleted, &a))
+
+	if len(received) < 2 || len(received) > 3 {
+		t.Fatalf("Expected the queued events only. Actual: %v", received)
+	}
+	if e := received[0]; e.Type != AgreementCreated || e.Agreement.State != model.STARTED {
+		t.Errorf("Expected a copy of the agreement at publish time: %v", e)
+	}
+}
+
+func TestPublishNilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Type: AgreementCreated})
+}
+
+func TestNewTransitionEvent(t *testing.T) {
+	a := model.Agreement{Id: "a01"}
+
+	if _, ok := NewTransitionEvent(&a, model.STARTED, model.STARTED); ok {
+		t.Errorf("Expected no event on same state")
+	}
+
+	expected := map[model.State]Type{
+		model.STARTED:    AgreementStarted,
+		model.STOPPED:    AgreementStopped,
+		model.TERMINATED: AgreementTerminated,
+	}
+	for to, typ := range expected {
+		e, ok := NewTransitionEvent(&a, "from", to)
+		if !ok {
+			t.Errorf("Expected event on transition to %s", to)
+			continue
+		}
+		if e.Type != typ || e.FromState != "from" || e.ToState != to {
+			t.Errorf("Unexpected event: %v", e)
+		}
+	}
+}
@@ -6,9 +6,12 @@ require (
 	github.com/Knetic/govaluate v3.0.0+incompatible
 	github.com/coreos/bbolt v1.3.2
 	github.com/globalsign/mgo v0.0.0-20181015135952-eeefdecb41b8
+	github.com/golang/snappy v0.0.1
 	github.com/google/uuid v1.1.1
 	github.com/gorilla/mux v1.7.4
 	github.com/labstack/gommon v0.3.0 // indirect
+	github.com/nats-io/nats.go v1.10.0
+	github.com/segmentio/kafka-go v0.3.5
 	github.com/sirupsen/logrus v1.6.0
 	github.com/spf13/viper v1.7.0
 	github.com/streadway/amqp v0.0.0-20200108173154-1c71cc93ed71
@@ -18,21 +18,25 @@ package main
 import (
 	"SLALite/assessment"
 	"SLALite/assessment/monitor"
-	"SLALite/assessment/monitor/genericadapter"
-	"SLALite/assessment/monitor/prometheus"
+	"SLALite/assessment/monitor/push"
 	"SLALite/assessment/notifier"
-	"SLALite/assessment/notifier/lognotifier"
-	"SLALite/assessment/notifier/rabbitnotifier"
-	"SLALite/assessment/notifier/rest"
+	"SLALite/events"
+	"SLALite/registry"
 
 	"SLALite/model"
-	"SLALite/repositories/memrepository"
-	"SLALite/repositories/mongodb"
+	"SLALite/repositories/lifecycle"
 	"SLALite/repositories/validation"
+	"SLALite/retention"
 	"SLALite/utils"
+	"context"
 	"flag"
+	"fmt"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -50,8 +54,14 @@ func main() {
 	configPath := flag.String("d", utils.UnixConfigPath, "Directories where to search config files")
 	configBasename := flag.String("b", utils.ConfigName, "Filename (w/o extension) of config file")
 	configFile := flag.String("f", "", "Path of configuration file. Overrides -b and -d")
+	list := flag.Bool("l", false, "List the available repositories, adapters and notifiers with their settings")
 	flag.Parse()
 
+	if *list {
+		fmt.Print(registry.Describe())
+		return
+	}
+
 	log.Infof("Running SLALite %s compiled on %s", version, date)
 	config := createMainConfig(configFile, configPath, configBasename)
 	logMainConfig(config)
@@ -59,71 +69,103 @@ func main() {
 	singlefile := config.GetBool(utils.SingleFilePropertyName)
 	checkPeriod := config.GetDuration(utils.CheckPeriodPropertyName)
 	repoType := config.GetString(utils.RepositoryTypePropertyName)
+	adapterType := config.GetString(utils.AdapterTypePropertyName)
+	notifierType := config.GetString(utils.NotifierTypePropertyName)
 
 	utils.AddTrustedCAs(config)
+	if err := utils.RegisterCalendars(config); err != nil {
+		log.Fatal("Error registering calendars: ", err.Error())
+	}
 
 	var repoconfig *viper.Viper
 	if singlefile {
 		repoconfig = config
 	}
 
-	var repo model.IRepository
-	var errRepo error
-	switch repoType {
-	case utils.DefaultRepositoryType:
-		repo, errRepo = memrepository.New(repoconfig)
-	case "mongodb":
-		repo, errRepo = mongodb.New(repoconfig)
-	}
+	repo, errRepo := registry.NewRepository(repoType, repoconfig)
 	if errRepo != nil {
 		log.Fatal("Error creating repository: ", errRepo.Error())
 	}
 
-	validater := model.NewDefaultValidator(config.GetBool(utils.ExternalIDsPropertyName), true)
-
-	adapter := buildAdapter(config)
+	if flag.Arg(0) == statementCommand {
+		if err := runStatement(repo, flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal("Error writing statement: ", err.Error())
+		}
+		return
+	}
 
-	notifier := buildNotifier(config)
+	validater := model.NewDefaultValidator(config.GetBool(utils.ExternalIDsPropertyName), true)
 
-	repo, _ = validation.New(repo, validater)
-	if repo != nil {
-		a, _ := NewApp(config, repo, validater)
-		go createValidationThread(repo, adapter, notifier, checkPeriod)
-		a.Run()
+	adapter, errAdapter := monitor.New(adapterType, config)
+	if errAdapter != nil {
+		log.Fatal("Error creating adapter: ", errAdapter.Error())
 	}
-}
 
-func buildAdapter(config *viper.Viper) monitor.MonitoringAdapter {
-	aType := config.GetString(utils.AdapterTypePropertyName)
-	switch aType {
-	case prometheus.Name:
-		adapter := genericadapter.New(
-			prometheus.New(config).Retrieve(),
-			genericadapter.Identity)
-		return adapter
-	default:
-		adapter := genericadapter.New(
-			genericadapter.DummyRetriever{Size: 3}.Retrieve(),
-			genericadapter.Identity)
-		return adapter
+	violationNotifier, errNotifier := notifier.New(notifierType, config)
+	if errNotifier != nil {
+		log.Fatal("Error creating notifier: ", errNotifier.Error())
 	}
 
-}
-
-func buildNotifier(config *viper.Viper) notifier.ViolationNotifier {
-	nType := config.GetString(utils.NotifierTypePropertyName)
-	switch nType {
-	case rest.Name:
-		notifier := rest.New(config)
-		return notifier
-	case rabbitnotifier.Name:
-		notifier := rabbitnotifier.RabbitNotifier{}
-		return notifier
-	default:
-		notifier := lognotifier.LogNotifier{}
-		return notifier
+	bus := events.NewQueuedBus(config.GetInt(utils.EventsQueueSizePropertyName))
+	if listener, ok := violationNotifier.(events.Listener); ok {
+		bus.Subscribe(listener)
 	}
+	bus.Subscribe(push.Default())
 
+	repo, _ = validation.New(repo, validater)
+	repo, _ = lifecycle.New(repo, bus)
+	if repo != nil {
+		a, _ := NewApp(config, repo, validater)
+		if pinger, ok := adapter.(monitor.Pinger); ok {
+			a.AddReadinessCheck("monitoring", pinger.Ping)
+		}
+		onMonitoringError, errPolicy := assessment.ParseMonitoringErrorPolicy(config.GetString(utils.OnMonitoringErrorPropertyName))
+		if errPolicy != nil {
+			log.Fatal("Error parsing configuration: ", errPolicy.Error())
+		}
+		assessCfg := assessment.Config{
+			Repo:      repo,
+			Adapter:   adapter,
+			Notifier:  violationNotifier,
+			Events:    bus,
+			Incidents: config.GetBool(utils.IncidentsPropertyName),
+
+			HistoryRetention:  config.GetDuration(utils.HistoryRetentionPropertyName),
+			HistoryResolution: config.GetDuration(utils.HistoryResolutionPropertyName),
+			OnMonitoringError: onMonitoringError,
+		}
+		scheduler := assessment.NewScheduler(assessCfg, checkPeriod*time.Second)
+		a.AddScheduler(scheduler)
+		a.AddAssessment(assessCfg)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go waitForSignal(cancel)
+
+		var jobs sync.WaitGroup
+		jobs.Add(1)
+		go func() {
+			scheduler.Run(ctx)
+			jobs.Done()
+		}()
+		if retentionManager := retention.New(config, repo); retentionManager.Enabled() {
+			jobs.Add(1)
+			go func() {
+				retentionManager.Run(ctx)
+				jobs.Done()
+			}()
+		}
+		if err := a.Run(ctx); err != nil {
+			log.Fatal("Error serving REST API: ", err.Error())
+		}
+		jobs.Wait()
+		bus.Close()
+		if flusher, ok := violationNotifier.(notifier.Flusher); ok {
+			if err := flusher.Flush(); err != nil {
+				log.Errorf("Error flushing notifications: %s", err.Error())
+			}
+		}
+		log.Info("SLALite stopped")
+	}
 }
 
 //
@@ -140,8 +182,13 @@ func createMainConfig(file *string, paths *string, basename *string) *viper.Vipe
 	config.SetDefault(utils.CheckPeriodPropertyName, utils.DefaultCheckPeriod)
 	config.SetDefault(utils.RepositoryTypePropertyName, utils.DefaultRepositoryType)
 	config.SetDefault(utils.AdapterTypePropertyName, utils.DefaultAdapterType)
-	config.SetDefault(utils.NotifierTypePropertyName, utils.DefaultAdapterType)
+	config.SetDefault(utils.NotifierTypePropertyName, utils.DefaultNotifierType)
 	config.SetDefault(utils.ExternalIDsPropertyName, utils.DefaultExternalIDs)
+	config.SetDefault(utils.IncidentsPropertyName, utils.DefaultIncidents)
+	config.SetDefault(utils.HistoryRetentionPropertyName, utils.DefaultHistoryRetention)
+	config.SetDefault(utils.HistoryResolutionPropertyName, utils.DefaultHistoryResolution)
+	config.SetDefault(utils.OnMonitoringErrorPropertyName, utils.DefaultOnMonitoringError)
+	config.SetDefault(utils.EventsQueueSizePropertyName, utils.DefaultEventsQueueSize)
 
 	if *file != "" {
 		config.SetConfigFile(*file)
@@ -167,6 +214,10 @@ func logMainConfig(config *viper.Viper) {
 	adapterType := config.GetString(utils.AdapterTypePropertyName)
 	notifierType := config.GetString(utils.NotifierTypePropertyName)
 	externalIDs := config.GetBool(utils.ExternalIDsPropertyName)
+	incidents := config.GetBool(utils.IncidentsPropertyName)
+	historyRetention := config.GetDuration(utils.HistoryRetentionPropertyName)
+	historyResolution := config.GetDuration(utils.HistoryResolutionPropertyName)
+	onMonitoringError := config.GetString(utils.OnMonitoringErrorPropertyName)
 
 	log.Infof("SLALite initialization\n"+
 		"\tConfigfile: %s\n"+
@@ -174,8 +225,13 @@ func logMainConfig(config *viper.Viper) {
 		"\tAdapter type: %s\n"+
 		"\tNotifier type: %s\n"+
 		"\tExternal IDs: %v\n"+
+		"\tIncidents: %v\n"+
+		"\tHistory retention: %v\n"+
+		"\tHistory resolution: %v\n"+
+		"\tOn monitoring error: %s\n"+
 		"\tCheck period:%d\n",
-		config.ConfigFileUsed(), repoType, adapterType, notifierType, externalIDs, checkPeriod)
+		config.ConfigFileUsed(), repoType, adapterType, notifierType, externalIDs, incidents,
+		historyRetention, historyResolution, onMonitoringError, checkPeriod)
 
 	caPath := config.GetString(utils.CAPathPropertyName)
 	if caPath != "" {
@@ -183,16 +239,14 @@ func logMainConfig(config *viper.Viper) {
 	}
 }
 
-func createValidationThread(repo model.IRepository, ma monitor.MonitoringAdapter,
-	not notifier.ViolationNotifier, checkPeriod time.Duration) {
-
-	ticker := time.NewTicker(checkPeriod * time.Second)
-
-	for {
-		<-ticker.C
-		assessment.AssessActiveAgreements(repo, ma, not)
-	}
+// waitForSignal calls cancel when SIGINT or SIGTERM is received
+func waitForSignal(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
+	s := <-signals
+	log.Infof("Received %s. Stopping SLALite", s)
+	cancel()
 }
 
 func validateProviders(repo model.IRepository) {
@@ -16,15 +16,21 @@ limitations under the License.
 package main
 
 import (
+	"SLALite/assessment"
+	amodel "SLALite/assessment/model"
+	"SLALite/assessment/monitor/simpleadapter"
 	"SLALite/model"
+	"SLALite/reports"
 	"SLALite/utils"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"strconv"
@@ -44,6 +50,7 @@ var p2 = model.Provider{Id: "p02", Name: "Provider02"}
 var c2 = model.Client{Id: "c02", Name: "A client"}
 var pdelete = model.Provider{Id: "pdelete", Name: "Removable provider"}
 var dbName = "test.db"
+var assessNow = time.Now()
 var providerPrefix = "pf_" + strconv.Itoa(rand.Int())
 var agreementPrefix = "apf_" + strconv.Itoa(rand.Int())
 
@@ -68,6 +75,13 @@ func TestMain(m *testing.M) {
 			log.Fatalf("Error creating initial state: %v", err)
 		}
 		a, _ = NewApp(viper.New(), repo, model.NewDefaultValidator(false, true))
+		a.AddAssessment(assessment.Config{
+			Repo: repo,
+			Now:  assessNow,
+			Adapter: simpleadapter.New(amodel.GuaranteeData{
+				{"test_value": model.MetricValue{Key: "test_value", Value: 5, DateTime: assessNow.Add(-time.Second)}},
+			}),
+		})
 	} else {
 		log.Fatal("Error initializing repository")
 	}
@@ -734,6 +748,523 @@ func testCreateAgreementFromTemplateMissingFields(t *testing.T) {
 	checkStatus(t, http.StatusBadRequest, res.Code)
 }
 
+func TestIncidents(t *testing.T) {
+	ag := createAgreement("ainc01", p1, c2, "Agreement inc01", nil)
+	repo.CreateAgreement(&ag)
+	now := time.Now()
+	incident := model.Incident{
+		Id:            "inc01",
+		AgreementId:   ag.Id,
+		Guarantee:     "TestGuarantee",
+		Constraint:    "[execution_time] < 100",
+		State:         model.OPEN,
+		Start:         now,
+		LastViolation: now,
+		Count:         1,
+	}
+	repo.CreateIncident(&incident)
+
+	t.Run("GetAgreementIncidents", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/ainc01/incidents", nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+
+		var incidents model.Incidents
+		_ = json.NewDecoder(res.Body).Decode(&incidents)
+		if len(incidents) != 1 || incidents[0].Id != incident.Id {
+			t.Errorf("Unexpected incidents: %v", incidents)
+		}
+	})
+	t.Run("GetAgreementIncidentsByState", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/ainc01/incidents?state=closed", nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+
+		var incidents model.Incidents
+		_ = json.NewDecoder(res.Body).Decode(&incidents)
+		if len(incidents) != 0 {
+			t.Errorf("Expected no closed incidents. Actual: %v", incidents)
+		}
+	})
+	t.Run("GetAgreementIncidentsNotExists", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/notexists/incidents", nil)
+		res := request(req)
+		checkStatus(t, http.StatusNotFound, res.Code)
+	})
+	t.Run("GetIncident", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/incidents/inc01", nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+
+		var actual model.Incident
+		_ = json.NewDecoder(res.Body).Decode(&actual)
+		if actual.Id != incident.Id || actual.State != model.OPEN {
+			t.Errorf("Unexpected incident: %v", actual)
+		}
+	})
+	t.Run("GetIncidentNotExists", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/incidents/notexists", nil)
+		res := request(req)
+		checkStatus(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestPushMetrics(t *testing.T) {
+	ag := createAgreement("apush01", p1, c2, "Agreement push01", nil)
+	repo.CreateAgreement(&ag)
+	t0 := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("PushAgreementMetrics", func(t *testing.T) {
+		body := `[{"key": "push_metric", "value": 10, "datetime": "2020-06-01T00:01:00Z"}]`
+		req, _ := http.NewRequest("POST", "/agreements/apush01/metrics", strings.NewReader(body))
+		res := request(req)
+		checkStatus(t, http.StatusNoContent, res.Code)
+
+		values := a.metrics.Values("apush01", "push_metric", t0, t0.Add(time.Hour))
+		if len(values) != 1 || values[0].Value != 10.0 {
+			t.Errorf("Unexpected values: %v", values)
+		}
+	})
+	t.Run("PushAgreementMetricsNotExists", func(t *testing.T) {
+		body := `[{"key": "push_metric", "value": 10}]`
+		req, _ := http.NewRequest("POST", "/agreements/notexists/metrics", strings.NewReader(body))
+		res := request(req)
+		checkStatus(t, http.StatusNotFound, res.Code)
+	})
+	t.Run("PushAgreementMetricsWrongInput", func(t *testing.T) {
+		body := `[{"value": 10}]`
+		req, _ := http.NewRequest("POST", "/agreements/apush01/metrics", strings.NewReader(body))
+		res := request(req)
+		checkStatus(t, http.StatusBadRequest, res.Code)
+	})
+	t.Run("PushMetrics", func(t *testing.T) {
+		body := `[
+			{"agreement_id": "apush01", "key": "push_bulk", "value": 1, "datetime": "2020-06-01T00:01:00Z"},
+			{"key": "push_bulk", "value": 2, "datetime": "2020-06-01T00:02:00Z"}
+		]`
+		req, _ := http.NewRequest("POST", "/metrics", strings.NewReader(body))
+		res := request(req)
+		checkStatus(t, http.StatusNoContent, res.Code)
+
+		values := a.metrics.Values("apush01", "push_bulk", t0, t0.Add(time.Hour))
+		if len(values) != 1 || values[0].Value != 1.0 {
+			t.Errorf("Unexpected values: %v", values)
+		}
+		values = a.metrics.Values("other", "push_bulk", t0, t0.Add(time.Hour))
+		if len(values) != 1 || values[0].Value != 2.0 {
+			t.Errorf("Unexpected shared values: %v", values)
+		}
+	})
+	t.Run("PushMetricsNotExists", func(t *testing.T) {
+		body := `[{"agreement_id": "notexists", "key": "push_bulk", "value": 1}]`
+		req, _ := http.NewRequest("POST", "/metrics", strings.NewReader(body))
+		res := request(req)
+		checkStatus(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestProbes(t *testing.T) {
+	t.Run("Health", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+	})
+	t.Run("Ready", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+	})
+	t.Run("NotReady", func(t *testing.T) {
+		a.AddReadinessCheck("monitoring", func() error { return errors.New("unreachable") })
+		defer delete(a.checks, "monitoring")
+
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		res := request(req)
+		checkStatus(t, http.StatusServiceUnavailable, res.Code)
+
+		var body struct {
+			Checks map[string]string `json:"checks"`
+		}
+		json.NewDecoder(res.Body).Decode(&body)
+		if body.Checks["repository"] != "ok" || body.Checks["monitoring"] != "unreachable" {
+			t.Errorf("Unexpected checks: %v", body.Checks)
+		}
+	})
+}
+
+func TestAssessAgreement(t *testing.T) {
+	ag := createAgreement("aassess", p1, c2, "Agreement assess", nil)
+	ag.State = model.STARTED
+	repo.CreateAgreement(&ag)
+	defer repo.DeleteAgreement(&ag)
+
+	assess := func(query string, expected int) assessmentResult {
+		req, _ := http.NewRequest("POST", "/agreements/aassess/assess"+query, nil)
+		res := request(req)
+		checkStatus(t, expected, res.Code)
+
+		var result assessmentResult
+		json.NewDecoder(res.Body).Decode(&result)
+		return result
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		result := assess("?dryRun", http.StatusOK)
+		if !result.DryRun || len(result.Violations) != 1 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		stored, _ := repo.GetAgreement("aassess")
+		if !stored.Assessment.LastExecution.IsZero() {
+			t.Errorf("Expected agreement not persisted in dry run: %+v", stored.Assessment)
+		}
+	})
+	t.Run("Assess", func(t *testing.T) {
+		result := assess("", http.StatusOK)
+		if result.DryRun || len(result.Violations) != 1 || len(result.LastValues["TestGuarantee"]) != 1 {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		stored, _ := repo.GetAgreement("aassess")
+		if !stored.Assessment.LastExecution.Equal(assessNow) {
+			t.Errorf("Expected persisted assessment. Actual: %+v", stored.Assessment)
+		}
+	})
+	t.Run("WrongDryRun", func(t *testing.T) {
+		assess("?dryRun=maybe", http.StatusBadRequest)
+	})
+	t.Run("NotExists", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/agreements/doesnotexist/assess", nil)
+		res := request(req)
+		checkError(t, res, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestReplayAgreement(t *testing.T) {
+	ag := createAgreement("areplay", p1, c2, "Agreement replay", nil)
+	repo.CreateAgreement(&ag)
+	defer repo.DeleteAgreement(&ag)
+
+	period := fmt.Sprintf("from=%s&to=%s&step=1m",
+		assessNow.Add(-3*time.Minute).Format(time.RFC3339), assessNow.Format(time.RFC3339))
+	replay := func(query string, body string, expected int) model.Replay {
+		req, _ := http.NewRequest("POST", "/agreements/areplay/replay?"+query, strings.NewReader(body))
+		res := request(req)
+		checkStatus(t, expected, res.Code)
+
+		var result model.Replay
+		json.NewDecoder(res.Body).Decode(&result)
+		if expected != http.StatusAccepted {
+			return result
+		}
+		if result.Status != model.REPLAYRUNNING {
+			t.Errorf("Unexpected status of the created replay: %s", result.Status)
+		}
+
+		a.replays.Wait()
+		req, _ = http.NewRequest("GET", "/replays/"+result.Id, nil)
+		res = request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&result)
+		if result.Status != model.REPLAYDONE {
+			t.Errorf("Unexpected status of the finished replay: %s", result.Status)
+		}
+		return result
+	}
+
+	var created model.Replay
+	t.Run("Replay", func(t *testing.T) {
+		created = replay(period, "", http.StatusAccepted)
+		if r := created.Results["TestGuarantee"]; r.Evaluated != 3 || r.Failed != 3 || len(created.Violations) != 3 {
+			t.Errorf("Unexpected replay: %+v", created)
+		}
+		stored, _ := repo.GetAgreement("areplay")
+		if stored.State != model.STOPPED || !stored.Assessment.LastExecution.IsZero() {
+			t.Errorf("Replay modified the agreement: %+v", stored)
+		}
+	})
+	t.Run("Guarantees", func(t *testing.T) {
+		result := replay(period, `{"guarantees":[{"name":"low","constraint":"test_value > 1"}]}`, http.StatusAccepted)
+		if r := result.Results["low"]; r.Evaluated != 3 || r.Failed != 0 || r.Compliance != 1 {
+			t.Errorf("Unexpected replay: %+v", result)
+		}
+	})
+	t.Run("WrongPeriod", func(t *testing.T) {
+		replay("from=yesterday", "", http.StatusBadRequest)
+	})
+	t.Run("GetReplays", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/areplay/replays", nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+		var replays model.Replays
+		json.NewDecoder(res.Body).Decode(&replays)
+		if len(replays) != 2 {
+			t.Errorf("Expected 2 replays. Actual: %v", replays)
+		}
+
+		req, _ = http.NewRequest("GET", "/replays/"+created.Id, nil)
+		res = request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+	})
+	t.Run("NotExists", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/agreements/doesnotexist/replay?"+period, nil)
+		res := request(req)
+		checkError(t, res, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestGuaranteeHistory(t *testing.T) {
+	ag := createAgreement("ahistory", p1, c2, "Agreement history", nil)
+	ag.State = model.STARTED
+	repo.CreateAgreement(&ag)
+	defer repo.DeleteAgreement(&ag)
+
+	req, _ := http.NewRequest("POST", "/agreements/ahistory/assess", nil)
+	checkStatus(t, http.StatusOK, request(req).Code)
+
+	from := url.QueryEscape(assessNow.Add(-time.Hour).Format(time.RFC3339))
+	req, _ = http.NewRequest("GET", "/agreements/ahistory/guarantees/TestGuarantee/history?from="+from, nil)
+	res := request(req)
+	checkStatus(t, http.StatusOK, res.Code)
+
+	var history []model.EvaluationRecord
+	json.NewDecoder(res.Body).Decode(&history)
+	if len(history) != 1 || history[0].Passed || history[0].Values["test_value"] != 5.0 {
+		t.Errorf("Unexpected history: %+v", history)
+	}
+
+	req, _ = http.NewRequest("GET", "/agreements/ahistory/guarantees/TestGuarantee/history?from=yesterday", nil)
+	res = request(req)
+	checkError(t, res, http.StatusBadRequest, res.Code)
+
+	req, _ = http.NewRequest("GET", "/agreements/ahistory/guarantees/doesnotexist/history", nil)
+	res = request(req)
+	checkError(t, res, http.StatusNotFound, res.Code)
+
+	req, _ = http.NewRequest("GET", "/agreements/doesnotexist/guarantees/TestGuarantee/history", nil)
+	res = request(req)
+	checkError(t, res, http.StatusNotFound, res.Code)
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	ag := createAgreement("amaintenance", p1, c2, "Agreement maintenance", nil)
+	ag.State = model.STARTED
+	repo.CreateAgreement(&ag)
+	defer repo.DeleteAgreement(&ag)
+
+	window := model.MaintenanceWindow{
+		AgreementId: ag.Id,
+		Start:       assessNow.Add(-time.Hour),
+		End:         assessNow.Add(time.Hour),
+	}
+	body, _ := json.Marshal(window)
+	req, _ := http.NewRequest("POST", "/maintenance-windows", bytes.NewBuffer(body))
+	res := request(req)
+	checkStatus(t, http.StatusCreated, res.Code)
+	json.NewDecoder(res.Body).Decode(&window)
+	if window.Id == "" {
+		t.Fatalf("Expected assigned id: %+v", window)
+	}
+
+	req, _ = http.NewRequest("GET", "/maintenance-windows/"+window.Id, nil)
+	checkStatus(t, http.StatusOK, request(req).Code)
+
+	req, _ = http.NewRequest("POST", "/agreements/amaintenance/assess?dryRun", nil)
+	res = request(req)
+	checkStatus(t, http.StatusOK, res.Code)
+	var result assessmentResult
+	json.NewDecoder(res.Body).Decode(&result)
+	if len(result.Violations) != 0 {
+		t.Errorf("Expected no violations in maintenance window: %+v", result)
+	}
+
+	window.Start = assessNow.Add(time.Hour)
+	window.End = assessNow.Add(2 * time.Hour)
+	body, _ = json.Marshal(window)
+	req, _ = http.NewRequest("PUT", "/maintenance-windows/"+window.Id, bytes.NewBuffer(body))
+	checkStatus(t, http.StatusOK, request(req).Code)
+
+	req, _ = http.NewRequest("POST", "/agreements/amaintenance/assess?dryRun", nil)
+	res = request(req)
+	json.NewDecoder(res.Body).Decode(&result)
+	if len(result.Violations) != 1 {
+		t.Errorf("Expected violation out of maintenance window: %+v", result)
+	}
+
+	window.End = window.Start
+	body, _ = json.Marshal(window)
+	req, _ = http.NewRequest("PUT", "/maintenance-windows/"+window.Id, bytes.NewBuffer(body))
+	res = request(req)
+	checkError(t, res, http.StatusBadRequest, res.Code)
+
+	req, _ = http.NewRequest("DELETE", "/maintenance-windows/"+window.Id, nil)
+	checkStatus(t, http.StatusNoContent, request(req).Code)
+
+	req, _ = http.NewRequest("GET", "/maintenance-windows/"+window.Id, nil)
+	res = request(req)
+	checkError(t, res, http.StatusNotFound, res.Code)
+}
+
+func TestCalendars(t *testing.T) {
+	day := assessNow.Add(-time.Second).UTC().Format("2006-01-02")
+	model.RegisterCalendar(model.Calendar{Name: "holiday", Holidays: []model.Holiday{{Date: day}}})
+
+	ag := createAgreement("acalendar", p1, c2, "Agreement calendar", nil)
+	ag.State = model.STARTED
+	ag.Details.Guarantees[0].Calendar = "notdefined"
+	body, _ := json.Marshal(ag)
+	req, _ := http.NewRequest("POST", "/agreements", bytes.NewBuffer(body))
+	res := request(req)
+	checkError(t, res, http.StatusBadRequest, res.Code)
+
+	ag.Details.Guarantees[0].Calendar = "holiday"
+	body, _ = json.Marshal(ag)
+	req, _ = http.NewRequest("POST", "/agreements", bytes.NewBuffer(body))
+	checkStatus(t, http.StatusCreated, request(req).Code)
+	defer repo.DeleteAgreement(&ag)
+
+	req, _ = http.NewRequest("POST", "/agreements/acalendar/assess?dryRun", nil)
+	res = request(req)
+	checkStatus(t, http.StatusOK, res.Code)
+	var result assessmentResult
+	json.NewDecoder(res.Body).Decode(&result)
+	if len(result.Violations) != 0 {
+		t.Errorf("Expected no violations out of the calendar: %+v", result)
+	}
+
+	req, _ = http.NewRequest("GET", "/calendars/holiday", nil)
+	res = request(req)
+	checkStatus(t, http.StatusOK, res.Code)
+	var calendar model.Calendar
+	json.NewDecoder(res.Body).Decode(&calendar)
+	if len(calendar.Holidays) != 1 {
+		t.Errorf("Unexpected calendar: %+v", calendar)
+	}
+
+	req, _ = http.NewRequest("GET", "/calendars/notdefined", nil)
+	res = request(req)
+	checkError(t, res, http.StatusNotFound, res.Code)
+}
+
+func TestReports(t *testing.T) {
+	ag := createAgreement("areport", p1, c2, "Agreement report", nil)
+	ag.State = model.STARTED
+	repo.CreateAgreement(&ag)
+	defer repo.DeleteAgreement(&ag)
+
+	req, _ := http.NewRequest("POST", "/agreements/areport/assess", nil)
+	checkStatus(t, http.StatusOK, request(req).Code)
+	period := assessNow.UTC().Format("2006-01")
+
+	t.Run("Agreement", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/areport/report?period="+period, nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+
+		var report reports.AgreementReport
+		json.NewDecoder(res.Body).Decode(&report)
+		if len(report.Guarantees) != 1 {
+			t.Fatalf("Unexpected report: %+v", report)
+		}
+		if gt := report.Guarantees[0]; gt.Evaluated != 1 || gt.Violations != 1 || gt.Compliance != 0 {
+			t.Errorf("Unexpected report: %+v", gt)
+		}
+	})
+	t.Run("CSV", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/areport/report?format=csv", nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+		if ct := res.Header().Get("Content-Type"); ct != "text/csv" {
+			t.Errorf("Unexpected content type: %s", ct)
+		}
+		if lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n"); len(lines) != 2 {
+			t.Errorf("Unexpected CSV: %v", lines)
+		}
+	})
+	t.Run("Provider", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/providers/p01/report?period="+period, nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+
+		var report reports.ProviderReport
+		json.NewDecoder(res.Body).Decode(&report)
+		if report.Provider.Id != "p01" || len(report.Agreements) == 0 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+	})
+	t.Run("Statement", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/areport/statement?period="+period, nil)
+		res := request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+		if body := res.Body.String(); !strings.Contains(body, "Agreement report") {
+			t.Errorf("Unexpected statement: %s", body)
+		}
+
+		req, _ = http.NewRequest("GET", "/agreements/areport/statement?format=pdf", nil)
+		res = request(req)
+		checkStatus(t, http.StatusOK, res.Code)
+		if ct := res.Header().Get("Content-Type"); ct != "application/pdf" {
+			t.Errorf("Unexpected content type: %s", ct)
+		}
+
+		req, _ = http.NewRequest("GET", "/agreements/areport/statement?format=doc", nil)
+		res = request(req)
+		checkError(t, res, http.StatusBadRequest, res.Code)
+	})
+	t.Run("WrongPeriod", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/agreements/areport/report?period=september", nil)
+		res := request(req)
+		checkError(t, res, http.StatusBadRequest, res.Code)
+	})
+	t.Run("NotExists", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/providers/doesnotexist/report", nil)
+		res := request(req)
+		checkError(t, res, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestScheduler(t *testing.T) {
+	a.AddScheduler(assessment.NewScheduler(assessment.Config{Repo: repo}, time.Hour))
+
+	post := func(path string, expected int) assessment.SchedulerStatus {
+		req, _ := http.NewRequest("POST", path, nil)
+		res := request(req)
+		checkStatus(t, expected, res.Code)
+
+		var status assessment.SchedulerStatus
+		json.NewDecoder(res.Body).Decode(&status)
+		return status
+	}
+
+	if status := post("/admin/scheduler/pause", http.StatusOK); !status.Paused {
+		t.Errorf("Expected paused scheduler: %+v", status)
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/scheduler", nil)
+	res := request(req)
+	checkStatus(t, http.StatusOK, res.Code)
+	var status assessment.SchedulerStatus
+	json.NewDecoder(res.Body).Decode(&status)
+	if !status.Paused || status.Period != "1h0m0s" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	if status := post("/admin/scheduler/resume", http.StatusOK); status.Paused {
+		t.Errorf("Expected resumed scheduler: %+v", status)
+	}
+	post("/admin/scheduler/run", http.StatusAccepted)
+}
+
+func TestTelemetry(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/providers", nil)
+	request(req)
+
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	res := request(req)
+	checkStatus(t, http.StatusOK, res.Code)
+	if body := res.Body.String(); !strings.Contains(body, `slalite_http_requests_total{method="GET",route="/providers",code="200"}`) {
+		t.Errorf("Expected HTTP request stats in metrics: %s", body)
+	}
+}
+
 func request(req *http.Request) *httptest.ResponseRecorder {
 	rr := httptest.NewRecorder()
 	a.Router.ServeHTTP(rr, req)
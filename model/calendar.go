@@ -0,0 +1,305 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calendar is the time when the guarantee terms that reference it (see
+// Guarantee.Calendar) apply: the weekly windows in a timezone, except the holidays.
+// If there are no windows, the calendar is active every day but the holidays.
+// swagger:model
+type Calendar struct {
+	Name     string         `json:"name"`
+	Timezone string         `json:"timezone,omitempty"`
+	Windows  []WeeklyWindow `json:"windows,omitempty"`
+	Holidays []Holiday      `json:"holidays,omitempty"`
+}
+
+// WeeklyWindow is a daily time window [Start, End) in some days of the week.
+//
+// Days are names of weekdays ("mon", "monday") or ranges ("mon-fri"). Start and
+// End are local times "15:04"; End may be "24:00".
+type WeeklyWindow struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Holiday is a day ("2006-01-02") where a calendar is not active. If Yearly, it is
+// repeated every year on the same month and day.
+type Holiday struct {
+	Date   string `json:"date"`
+	Name   string `json:"name,omitempty"`
+	Yearly bool   `json:"yearly,omitempty"`
+}
+
+const dateLayout = "2006-01-02"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Validate checks the timezone, the windows and the holidays of a calendar
+func (c *Calendar) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("Calendar.Name cannot be empty")
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("Calendar['%s'].Timezone: %s", c.Name, err.Error())
+	}
+	for i, w := range c.Windows {
+		if _, err := w.weekdays(); err != nil {
+			return fmt.Errorf("Calendar['%s'].Windows[%d]: %s", c.Name, i, err.Error())
+		}
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return fmt.Errorf("Calendar['%s'].Windows[%d].Start: %s", c.Name, i, err.Error())
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return fmt.Errorf("Calendar['%s'].Windows[%d].End: %s", c.Name, i, err.Error())
+		}
+		if start >= end {
+			return fmt.Errorf("Calendar['%s'].Windows[%d].Start must be before End", c.Name, i)
+		}
+	}
+	for _, h := range c.Holidays {
+		if _, err := time.Parse(dateLayout, h.Date); err != nil {
+			return fmt.Errorf("Calendar['%s'].Holidays: %s is not a valid date", c.Name, h.Date)
+		}
+	}
+	return nil
+}
+
+// IsActive returns if t is in a window of the calendar and it is not a holiday,
+// in the local time of the calendar timezone
+func (c *Calendar) IsActive(t time.Time) bool {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		/* validated on registration */
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	date := local.Format(dateLayout)
+	for _, h := range c.Holidays {
+		if h.Date == date || (h.Yearly && len(h.Date) == len(date) && h.Date[4:] == date[4:]) {
+			return false
+		}
+	}
+	if len(c.Windows) == 0 {
+		return true
+	}
+	clock := local.Hour()*60 + local.Minute()
+	for _, w := range c.Windows {
+		days, _ := w.weekdays()
+		start, _ := parseClock(w.Start)
+		end, _ := parseClock(w.End)
+		if days[local.Weekday()] && clock >= start && clock < end {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdays returns the days of the week of a window
+func (w *WeeklyWindow) weekdays() (map[time.Weekday]bool, error) {
+	if len(w.Days) == 0 {
+		return nil, fmt.Errorf("Days cannot be empty")
+	}
+	result := make(map[time.Weekday]bool)
+	for _, d := range w.Days {
+		bounds := strings.SplitN(strings.ToLower(strings.TrimSpace(d)), "-", 2)
+		first, ok := weekdays[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a valid day", d)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdays[bounds[1]]; !ok {
+				return nil, fmt.Errorf("'%s' is not a valid day", d)
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			result[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseClock returns the minutes since midnight of a time "15:04" (up to "24:00")
+func parseClock(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a valid time", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ParseICal returns the holidays of the events of an iCalendar (RFC 5545) file.
+// An event spanning several days is a holiday for each day; the only supported
+// recurrence is FREQ=YEARLY.
+func ParseICal(r io.Reader) ([]Holiday, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Holiday, 0)
+	var event map[string]string
+	for _, line := range lines {
+		sep := strings.Index(line, ":")
+		if sep < 0 {
+			continue
+		}
+		name := strings.ToUpper(strings.SplitN(line[:sep], ";", 2)[0])
+		value := line[sep+1:]
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = make(map[string]string)
+		case name == "END" && value == "VEVENT":
+			holidays, err := eventHolidays(event)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, holidays...)
+			event = nil
+		case event != nil:
+			event[name] = value
+		}
+	}
+	return result, nil
+}
+
+// unfoldICal returns the logical lines of an iCalendar file, where the lines
+// starting with a space or a tab continue the previous one
+func unfoldICal(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+		} else if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// eventHolidays returns the holidays of the properties of a VEVENT
+func eventHolidays(event map[string]string) ([]Holiday, error) {
+	start, err := parseICalDate(event["DTSTART"])
+	if err != nil {
+		return nil, fmt.Errorf("event '%s': DTSTART: %s", event["SUMMARY"], err.Error())
+	}
+	/* DTEND is exclusive; a DATE-TIME end includes its day unless it is midnight */
+	end := start.AddDate(0, 0, 1)
+	if dtend, ok := event["DTEND"]; ok {
+		if end, err = parseICalDate(dtend); err != nil {
+			return nil, fmt.Errorf("event '%s': DTEND: %s", event["SUMMARY"], err.Error())
+		}
+		if len(dtend) > 8 && !strings.HasPrefix(dtend[8:], "T000000") {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !end.After(start) {
+			end = start.AddDate(0, 0, 1)
+		}
+	}
+	yearly := false
+	if rule, ok := event["RRULE"]; ok {
+		if !strings.EqualFold(rule, "FREQ=YEARLY") {
+			return nil, fmt.Errorf("event '%s': RRULE '%s' not supported", event["SUMMARY"], rule)
+		}
+		yearly = true
+	}
+	name := strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(event["SUMMARY"])
+
+	result := make([]Holiday, 0, 1)
+	for day := start; day.Before(end) && len(result) < 366; day = day.AddDate(0, 0, 1) {
+		result = append(result, Holiday{Date: day.Format(dateLayout), Name: name, Yearly: yearly})
+	}
+	return result, nil
+}
+
+// parseICalDate returns the day of an iCalendar DATE (20060102) or DATE-TIME
+// (20060102T150405[Z]) value, as written in the value
+func parseICalDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("'%s' is not a valid date", value)
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is not a valid date", value)
+	}
+	return t, nil
+}
+
+var calendars = struct {
+	sync.RWMutex
+	m map[string]*Calendar
+}{m: make(map[string]*Calendar)}
+
+// RegisterCalendar validates a calendar and registers it with its name, so that the
+// guarantee terms can reference it. A calendar with the same name is replaced.
+func RegisterCalendar(c Calendar) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	calendars.Lock()
+	defer calendars.Unlock()
+	calendars.m[c.Name] = &c
+	return nil
+}
+
+// GetCalendar returns the calendar registered with a name
+func GetCalendar(name string) (*Calendar, bool) {
+	calendars.RLock()
+	defer calendars.RUnlock()
+	c, ok := calendars.m[name]
+	return c, ok
+}
+
+// GetCalendars returns the registered calendars sorted by name
+func GetCalendars() []Calendar {
+	calendars.RLock()
+	defer calendars.RUnlock()
+	result := make([]Calendar, 0, len(calendars.m))
+	for _, c := range calendars.m {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
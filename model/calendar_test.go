@@ -0,0 +1,125 @@
+package model
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarIsActive(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("No timezone database: %v", err)
+	}
+	c := Calendar{
+		Name:     "support",
+		Timezone: "Europe/Madrid",
+		Windows: []WeeklyWindow{
+			{Days: []string{"mon-fri"}, Start: "08:00", End: "18:00"},
+			{Days: []string{"Saturday"}, Start: "10:00", End: "24:00"},
+		},
+		Holidays: []Holiday{{Date: "2026-10-12"}, {Date: "2025-01-01", Yearly: true}},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		expected bool
+	}{
+		{"Weekday", time.Date(2026, 10, 14, 8, 0, 0, 0, madrid), true},
+		{"WeekdayEnd", time.Date(2026, 10, 14, 18, 0, 0, 0, madrid), false},
+		{"WeekdayNight", time.Date(2026, 10, 14, 7, 59, 0, 0, madrid), false},
+		/* 16:30 UTC is 18:30 in Madrid (CEST) */
+		{"WeekdayUTC", time.Date(2026, 10, 14, 16, 30, 0, 0, time.UTC), false},
+		{"Saturday", time.Date(2026, 10, 17, 23, 59, 0, 0, madrid), true},
+		{"Sunday", time.Date(2026, 10, 18, 12, 0, 0, 0, madrid), false},
+		{"Holiday", time.Date(2026, 10, 12, 12, 0, 0, 0, madrid), false},
+		{"YearlyHoliday", time.Date(2027, 1, 1, 12, 0, 0, 0, madrid), false},
+	}
+	for _, test := range tests {
+		if actual := c.IsActive(test.t); actual != test.expected {
+			t.Errorf("%s: expected %v. Actual: %v", test.name, test.expected, actual)
+		}
+	}
+
+	if !(&Calendar{Name: "holidays"}).IsActive(time.Date(2026, 10, 18, 3, 0, 0, 0, madrid)) {
+		t.Errorf("Expected calendar without windows active")
+	}
+}
+
+func TestCalendarValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar Calendar
+	}{
+		{"NoName", Calendar{}},
+		{"Timezone", Calendar{Name: "c", Timezone: "Mars/Olympus"}},
+		{"NoDays", Calendar{Name: "c", Windows: []WeeklyWindow{{Start: "08:00", End: "18:00"}}}},
+		{"Day", Calendar{Name: "c", Windows: []WeeklyWindow{{Days: []string{"mon-fry"}, Start: "08:00", End: "18:00"}}}},
+		{"Start", Calendar{Name: "c", Windows: []WeeklyWindow{{Days: []string{"mon"}, Start: "8am", End: "18:00"}}}},
+		{"StartAfterEnd", Calendar{Name: "c", Windows: []WeeklyWindow{{Days: []string{"mon"}, Start: "18:00", End: "08:00"}}}},
+		{"Holiday", Calendar{Name: "c", Holidays: []Holiday{{Date: "12/10/2026"}}}},
+	}
+	for _, test := range tests {
+		if err := test.calendar.Validate(); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestParseICal(t *testing.T) {
+	f, err := os.Open("testdata/holidays.ics")
+	if err != nil {
+		t.Fatalf("Error opening file: %v", err)
+	}
+	defer f.Close()
+
+	holidays, err := ParseICal(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Holiday{
+		{Date: "2026-10-12", Name: "Fiesta Nacional de España"},
+		{Date: "2026-12-24", Name: "Christmas Eve, Christmas"},
+		{Date: "2026-12-25", Name: "Christmas Eve, Christmas"},
+		{Date: "2025-01-01", Name: "New Year Day", Yearly: true},
+	}
+	if !reflect.DeepEqual(holidays, expected) {
+		t.Errorf("Unexpected holidays: %+v", holidays)
+	}
+
+	_, err = ParseICal(strings.NewReader("BEGIN:VEVENT\nDTSTART:20260101\nRRULE:FREQ=MONTHLY\nEND:VEVENT\n"))
+	if err == nil {
+		t.Errorf("Expected error on unsupported recurrence")
+	}
+}
+
+func TestRegisterCalendar(t *testing.T) {
+	if err := RegisterCalendar(Calendar{Name: "registered", Timezone: "Mars/Olympus"}); err == nil {
+		t.Errorf("Expected error registering invalid calendar")
+	}
+	if err := RegisterCalendar(Calendar{Name: "registered"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := GetCalendar("registered"); !ok {
+		t.Errorf("Expected registered calendar")
+	}
+
+	val := NewDefaultValidator(false, true)
+	gt := Guarantee{Name: "gt", Constraint: "m > 0", Calendar: "notregistered"}
+	if errs := gt.Validate(val, CREATE); len(errs) != 1 {
+		t.Errorf("Expected error on undefined calendar. Actual: %v", errs)
+	}
+	if errs := gt.Validate(val, UPDATE); len(errs) != 0 {
+		t.Errorf("Unexpected errors on update: %v", errs)
+	}
+	gt.Calendar = "registered"
+	if errs := gt.Validate(val, CREATE); len(errs) != 0 {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+}
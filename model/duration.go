@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var isoDurationRegexp = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ISODuration is an ISO 8601 duration (e.g. P1M, P1Y2M, P30D, PT12H).
+// The date part is calendar based: P1M added to January 31st is March 3rd
+// (or 2nd in leap years), as in time.AddDate.
+type ISODuration struct {
+	Years  int
+	Months int
+	Days   int
+	Time   time.Duration
+}
+
+// ParseISODuration parses an ISO 8601 duration with integer values. Fractions
+// and negative durations are not supported.
+func ParseISODuration(s string) (ISODuration, error) {
+	var d ISODuration
+
+	m := isoDurationRegexp.FindStringSubmatch(s)
+	if m == nil || s == "P" || s[len(s)-1] == 'T' {
+		return d, fmt.Errorf("%s is not a valid ISO 8601 duration", s)
+	}
+	values := make([]int, len(m))
+	for i := 1; i < len(m); i++ {
+		if m[i] != "" {
+			values[i], _ = strconv.Atoi(m[i])
+		}
+	}
+	d.Years = values[1]
+	d.Months = values[2]
+	d.Days = 7*values[3] + values[4]
+	d.Time = time.Duration(values[5])*time.Hour +
+		time.Duration(values[6])*time.Minute +
+		time.Duration(values[7])*time.Second
+	return d, nil
+}
+
+// AddTo returns t plus the duration
+func (d ISODuration) AddTo(t time.Time) time.Time {
+	return t.AddDate(d.Years, d.Months, d.Days).Add(d.Time)
+}
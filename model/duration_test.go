@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseISODuration(t *testing.T) {
+	start := time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)
+	valid := map[string]time.Time{
+		"P1M":       time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+		"P1Y2M":     time.Date(2027, 11, 15, 12, 0, 0, 0, time.UTC),
+		"P2W":       time.Date(2026, 9, 29, 12, 0, 0, 0, time.UTC),
+		"P1DT12H":   time.Date(2026, 9, 17, 0, 0, 0, 0, time.UTC),
+		"PT1H30M5S": time.Date(2026, 9, 15, 13, 30, 5, 0, time.UTC),
+	}
+	for s, expected := range valid {
+		d, err := ParseISODuration(s)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %s: %v", s, err)
+			continue
+		}
+		if actual := d.AddTo(start); !actual.Equal(expected) {
+			t.Errorf("Unexpected %s + %s. Expected: %v; Actual: %v", start, s, expected, actual)
+		}
+	}
+
+	for _, s := range []string{"", "P", "PT", "1M", "P1.5M", "P-1D", "P1H", "month"} {
+		if _, err := ParseISODuration(s); err == nil {
+			t.Errorf("Expected error parsing %s", s)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// Recurrence is the repetition of a maintenance window
+type Recurrence string
+
+const (
+	// ONCE is a one-off maintenance window
+	ONCE Recurrence = ""
+
+	// DAILY repeats a maintenance window every day
+	DAILY Recurrence = "daily"
+
+	// WEEKLY repeats a maintenance window every week
+	WEEKLY Recurrence = "weekly"
+
+	// MONTHLY repeats a maintenance window every month, on the same day of the
+	// month as Start (normalized as in time.Date if the month is shorter)
+	MONTHLY Recurrence = "monthly"
+)
+
+// maxWindowDurations are the maximum durations of a recurring window, so that
+// an occurrence ends before the next one starts
+var maxWindowDurations = map[Recurrence]time.Duration{
+	DAILY:   24 * time.Hour,
+	WEEKLY:  7 * 24 * time.Hour,
+	MONTHLY: 28 * 24 * time.Hour,
+}
+
+// MaintenanceWindow is a scheduled period where the point sets of the guarantee
+// terms in its scope that fail their constraint are excluded from the assessment:
+// they do not raise violations nor count as failed.
+//
+// The scope is an agreement (AgreementId), the agreements of a provider (ProviderId)
+// or every agreement if both are empty.
+//
+// The window is [Start, End). If Recurrence is set, it is repeated at the same local
+// time in Timezone (an IANA name, UTC if empty), so that it follows the daylight
+// saving time. If Until is set, there are no occurrences starting after it.
+// swagger:model
+type MaintenanceWindow struct {
+	Id          string     `json:"id" bson:"_id"`
+	Description string     `json:"description,omitempty"`
+	AgreementId string     `json:"agreement_id,omitempty"`
+	ProviderId  string     `json:"provider_id,omitempty"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Recurrence  Recurrence `json:"recurrence,omitempty"`
+	Timezone    string     `json:"timezone,omitempty"`
+	Until       *time.Time `json:"until,omitempty"`
+}
+
+// MaintenanceWindows is the type of an slice of MaintenanceWindow
+// swagger:model
+type MaintenanceWindows []MaintenanceWindow
+
+// GetId returns the Id of a maintenance window
+func (w *MaintenanceWindow) GetId() string {
+	return w.Id
+}
+
+// Validate validates the consistency of a MaintenanceWindow entity
+func (w *MaintenanceWindow) Validate(val Validator, mode ValidationMode) []error {
+	return val.ValidateMaintenanceWindow(w, mode)
+}
+
+// Applies returns if the agreement is in the scope of the window
+func (w *MaintenanceWindow) Applies(a *Agreement) bool {
+	if w.AgreementId != "" && w.AgreementId != a.Id {
+		return false
+	}
+	if w.ProviderId != "" && w.ProviderId != a.Details.Provider.Id {
+		return false
+	}
+	return true
+}
+
+// Contains returns if t is in an occurrence of the window
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	if t.Before(w.Start) {
+		return false
+	}
+	if w.Recurrence == ONCE {
+		return t.Before(w.End)
+	}
+
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		/* validated on creation */
+		loc = time.UTC
+	}
+	start := w.Start.In(loc)
+	local := t.In(loc)
+	var n int
+	switch w.Recurrence {
+	case DAILY:
+		n = int(local.Sub(start) / (24 * time.Hour))
+	case WEEKLY:
+		n = int(local.Sub(start) / (7 * 24 * time.Hour))
+	case MONTHLY:
+		n = (local.Year()-start.Year())*12 + int(local.Month()-start.Month())
+	default:
+		return false
+	}
+
+	/* n is an estimation (days are not 24h long when daylight saving time changes) */
+	duration := w.End.Sub(w.Start)
+	for i := n - 1; i <= n+1; i++ {
+		if i < 0 {
+			continue
+		}
+		occurrence := w.occurrence(start, i)
+		if w.Until != nil && occurrence.After(*w.Until) {
+			continue
+		}
+		if !t.Before(occurrence) && t.Before(occurrence.Add(duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrence returns the start of the i-th occurrence of a recurring window.
+// A monthly window starting on a day that a month does not have (e.g. the 31st)
+// occurs on the last day of that month.
+func (w *MaintenanceWindow) occurrence(start time.Time, i int) time.Time {
+	year, month, day := start.Date()
+	switch w.Recurrence {
+	case DAILY:
+		day += i
+	case WEEKLY:
+		day += 7 * i
+	case MONTHLY:
+		month += time.Month(i)
+		/* day 0 of the next month is the last day of month */
+		if last := time.Date(year, month+1, 0, 0, 0, 0, 0, start.Location()).Day(); day > last {
+			day = last
+		}
+	}
+	return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+}
+
+// Excludes returns if the point sets of an agreement at t are in a maintenance window
+func (ws MaintenanceWindows) Excludes(a *Agreement, t time.Time) bool {
+	for i := range ws {
+		if ws[i].Applies(a) && ws[i].Contains(t) {
+			return true
+		}
+	}
+	return false
+}
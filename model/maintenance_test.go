@@ -0,0 +1,106 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("No timezone database: %v", err)
+	}
+	/* Saturdays from 02:00 to 04:00 in Madrid (CEST, UTC+2, until October 25th) */
+	start := time.Date(2026, 10, 3, 2, 0, 0, 0, madrid)
+	until := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	/* a month day that not every month has */
+	endOfMonth := time.Date(2027, 1, 31, 2, 0, 0, 0, madrid)
+
+	tests := []struct {
+		name     string
+		window   MaintenanceWindow
+		t        time.Time
+		expected bool
+	}{
+		{"OnceIn", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour)}, start.Add(time.Hour), true},
+		{"OnceEnd", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour)}, start.Add(2 * time.Hour), false},
+		{"OnceBefore", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour)}, start.Add(-time.Second), false},
+		{"OnceNextWeek", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour)}, start.AddDate(0, 0, 7), false},
+		{"WeeklyIn", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: WEEKLY, Timezone: "Europe/Madrid"},
+			time.Date(2026, 10, 10, 3, 0, 0, 0, madrid), true},
+		{"WeeklyOtherDay", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: WEEKLY, Timezone: "Europe/Madrid"},
+			time.Date(2026, 10, 11, 3, 0, 0, 0, madrid), false},
+		/* CET (UTC+1): 02:30 local is 01:30 UTC */
+		{"WeeklyAfterDST", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: WEEKLY, Timezone: "Europe/Madrid"},
+			time.Date(2026, 10, 31, 1, 30, 0, 0, time.UTC), true},
+		{"WeeklyAfterDSTInUTC", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: WEEKLY},
+			time.Date(2026, 10, 31, 1, 30, 0, 0, time.UTC), true},
+		{"WeeklyAfterDSTInUTCEnd", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: WEEKLY},
+			time.Date(2026, 10, 31, 2, 30, 0, 0, time.UTC), false},
+		{"WeeklyUntil", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: WEEKLY, Until: &until},
+			time.Date(2027, 1, 2, 3, 0, 0, 0, madrid), false},
+		{"DailyIn", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: DAILY, Timezone: "Europe/Madrid"},
+			time.Date(2026, 11, 17, 2, 30, 0, 0, madrid), true},
+		{"DailyOut", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: DAILY, Timezone: "Europe/Madrid"},
+			time.Date(2026, 11, 17, 4, 30, 0, 0, madrid), false},
+		{"MonthlyIn", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: MONTHLY, Timezone: "Europe/Madrid"},
+			time.Date(2027, 2, 3, 3, 59, 0, 0, madrid), true},
+		{"MonthlyOut", MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour), Recurrence: MONTHLY, Timezone: "Europe/Madrid"},
+			time.Date(2027, 2, 4, 3, 0, 0, 0, madrid), false},
+		{"MonthlyShortMonth", MaintenanceWindow{Start: endOfMonth, End: endOfMonth.Add(2 * time.Hour), Recurrence: MONTHLY, Timezone: "Europe/Madrid"},
+			time.Date(2027, 2, 28, 3, 0, 0, 0, madrid), true},
+		{"MonthlyShortMonthNoRollover", MaintenanceWindow{Start: endOfMonth, End: endOfMonth.Add(2 * time.Hour), Recurrence: MONTHLY, Timezone: "Europe/Madrid"},
+			time.Date(2027, 3, 3, 3, 0, 0, 0, madrid), false},
+		{"MonthlyLongMonth", MaintenanceWindow{Start: endOfMonth, End: endOfMonth.Add(2 * time.Hour), Recurrence: MONTHLY, Timezone: "Europe/Madrid"},
+			time.Date(2027, 3, 31, 3, 0, 0, 0, madrid), true},
+		{"MonthlyThirtyDays", MaintenanceWindow{Start: endOfMonth, End: endOfMonth.Add(2 * time.Hour), Recurrence: MONTHLY, Timezone: "Europe/Madrid"},
+			time.Date(2027, 4, 30, 3, 0, 0, 0, madrid), true},
+	}
+	for _, test := range tests {
+		if actual := test.window.Contains(test.t); actual != test.expected {
+			t.Errorf("%s: unexpected Contains(%v). Expected: %v; Actual: %v", test.name, test.t, test.expected, actual)
+		}
+	}
+}
+
+func TestMaintenanceWindowsExcludes(t *testing.T) {
+	now := time.Now()
+	a := Agreement{Id: "a01", Details: Details{Provider: Provider{Id: "p01"}}}
+	window := MaintenanceWindow{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}
+
+	global := window
+	agreement := window
+	agreement.AgreementId = "a01"
+	provider := window
+	provider.ProviderId = "p01"
+	other := window
+	other.AgreementId = "a02"
+
+	for _, w := range []MaintenanceWindow{global, agreement, provider} {
+		if !(MaintenanceWindows{other, w}).Excludes(&a, now) {
+			t.Errorf("Expected exclusion by window %+v", w)
+		}
+	}
+	if (MaintenanceWindows{other}).Excludes(&a, now) {
+		t.Error("Unexpected exclusion by window of other agreement")
+	}
+	if (MaintenanceWindows{global}).Excludes(&a, now.Add(2*time.Hour)) {
+		t.Error("Unexpected exclusion out of window")
+	}
+}
+
+func TestMaintenanceWindowValidation(t *testing.T) {
+	now := time.Now()
+	until := now.Add(-time.Hour)
+	w := MaintenanceWindow{Id: "w", Start: now, End: now.Add(time.Hour), Recurrence: DAILY, Timezone: "Europe/Madrid"}
+	checkNumber(t, &w, 0)
+
+	w = MaintenanceWindow{Id: "w", Start: now, End: now}
+	checkNumber(t, &w, 1)
+
+	w = MaintenanceWindow{Id: "w", Start: now, End: now.Add(25 * time.Hour), Recurrence: DAILY}
+	checkNumber(t, &w, 1)
+
+	w = MaintenanceWindow{Id: "w", Start: now, End: now.Add(time.Hour), Recurrence: "yearly", Timezone: "Mars/Olympus", Until: &until}
+	checkNumber(t, &w, 3)
+}
@@ -107,6 +107,69 @@ const (
 	AVERAGE AggregationType = "average"
 )
 
+// InterpolationMethod is the type of the supported methods to align the values
+// of the variables of a guarantee term (see Interpolation)
+type InterpolationMethod string
+
+const (
+	// CONSTANT uses the last known value of a variable
+	CONSTANT InterpolationMethod = "constant"
+	// LINEAR interpolates linearly between the last known value and the next value of a variable
+	LINEAR InterpolationMethod = "linear"
+	// STRICT needs a value of every variable with exactly the same timestamp
+	STRICT InterpolationMethod = "strict"
+)
+
+// MissingDataPolicy is the type of the policies applied when a guarantee term
+// has no values to be evaluated (see MissingData)
+type MissingDataPolicy string
+
+const (
+	// IGNORE considers the guarantee term fulfilled
+	IGNORE MissingDataPolicy = "ignore"
+	// VIOLATE considers the guarantee term violated
+	VIOLATE MissingDataPolicy = "violation"
+	// WARN considers the guarantee term fulfilled, but publishes an event
+	WARN MissingDataPolicy = "warning"
+	// LASTVALUE evaluates the last known values again, up to a maximum age
+	LASTVALUE MissingDataPolicy = "last_value"
+)
+
+// GuaranteeStatus is the type of the status of the last evaluation of a guarantee term
+type GuaranteeStatus string
+
+const (
+	// OK means that the guarantee term was evaluated with values
+	OK GuaranteeStatus = "ok"
+	// MONITORINGERROR means that the values could not be retrieved from monitoring
+	MONITORINGERROR GuaranteeStatus = "monitoring_error"
+	// NODATA means that the monitoring returned no values
+	NODATA GuaranteeStatus = "no_data"
+	// ERROR means that the guarantee term could not be evaluated (e.g. its calendar is not defined)
+	ERROR GuaranteeStatus = "error"
+)
+
+// ReplayStatus is the type of the status of a replay
+type ReplayStatus string
+
+const (
+	// REPLAYRUNNING is the status of a replay that is being evaluated
+	REPLAYRUNNING ReplayStatus = "running"
+	// REPLAYDONE is the status of a replay with all its steps evaluated
+	REPLAYDONE ReplayStatus = "done"
+)
+
+// IncidentState is the type of possible states of an incident
+type IncidentState string
+
+const (
+	// OPEN is the state of an incident whose guarantee term is still being violated
+	OPEN IncidentState = "open"
+
+	// CLOSED is the state of an incident whose guarantee term has been fulfilled again
+	CLOSED IncidentState = "closed"
+)
+
 // States is the list of possible states of an agreement/template
 var States = [...]State{STOPPED, STARTED, TERMINATED}
 
@@ -193,8 +256,19 @@ type Assessment struct {
 	FirstExecution time.Time `json:"first_execution"`
 	LastExecution  time.Time `json:"last_execution"`
 	MonitoringURL  string    `json:"monitoring_url,omitempty"`
+	// MonitoringSource is the name of the default monitoring source of the variables
+	// of the agreement. If empty, the default source of the adapter is used.
+	MonitoringSource string `json:"monitoring_source,omitempty"`
+	// Interpolation is the default interpolation of the variables of the agreement.
+	Interpolation *Interpolation `json:"interpolation,omitempty"`
 	// Guarantees may be nil. Use Assessment.SetGuarantee to create if needed.
 	Guarantees map[string]AssessmentGuarantee `json:"guarantees,omitempty"`
+	// Activation is the time the agreement became effective (see Agreement.Activate).
+	// It is nil if the agreement has not been activated yet.
+	Activation *time.Time `json:"activation,omitempty"`
+	// Expired is the time of the assessment that terminated the agreement because
+	// its expiration date had passed. It is nil if the agreement has not expired.
+	Expired *time.Time `json:"expired,omitempty"`
 }
 
 // AssessmentGuarantee contain the assessment information for a guarantee term
@@ -205,6 +279,13 @@ type AssessmentGuarantee struct {
 	LastExecution  time.Time  `json:"last_execution"`
 	LastValues     LastValues `json:"last_values,omitempty"`
 	LastViolation  *Violation `json:"last_violation,omitempty"`
+	// NoDataSince is the first of the last consecutive executions without values.
+	// It is nil if the last execution had values.
+	NoDataSince *time.Time `json:"no_data_since,omitempty"`
+	// Status is the status of the last execution, and Error the monitoring
+	// error if Status is MONITORINGERROR, or the evaluation error if Status is ERROR.
+	Status GuaranteeStatus `json:"status,omitempty"`
+	Error  string          `json:"error,omitempty"`
 }
 
 // LastValues contain last values of variables in guarantee terms
@@ -213,6 +294,11 @@ type AssessmentGuarantee struct {
 type LastValues map[string]MetricValue
 
 // Details is the struct that represents the "contract" signed by the client
+//
+// Start is the time the agreement becomes effective. Before it, the agreement
+// is not evaluated; when it comes, a STOPPED agreement that has never been
+// activated is started. Duration is an ISO 8601 duration (e.g. P1M): if set and
+// Expiration is not, the agreement expires at its activation plus Duration.
 // swagger:model
 type Details struct {
 	Id         string      `json:"id"`
@@ -221,7 +307,9 @@ type Details struct {
 	Provider   Provider    `json:"provider"`
 	Client     Client      `json:"client"`
 	Creation   time.Time   `json:"creation"`
+	Start      *time.Time  `json:"start,omitempty"`
 	Expiration *time.Time  `json:"expiration,omitempty"`
+	Duration   string      `json:"duration,omitempty"`
 	Variables  []Variable  `json:"variables,omitempty"`
 	Guarantees []Guarantee `json:"guarantees"`
 }
@@ -232,6 +320,26 @@ type Variable struct {
 	Name        string       `json:"name"`
 	Metric      string       `json:"metric"`
 	Aggregation *Aggregation `json:"aggregation,omitempty"`
+	// Source is the name of the monitoring source of the metric. If empty, the
+	// agreement source (Assessment.MonitoringSource) is used.
+	Source string `json:"source,omitempty"`
+	// Interpolation overrides the agreement interpolation (Assessment.Interpolation)
+	Interpolation *Interpolation `json:"interpolation,omitempty"`
+}
+
+// Interpolation sets how the values of the variables of a guarantee term are
+// grouped in point sets to evaluate the constraint.
+//
+// A variable value is in a point set if its time is within Tolerance seconds of
+// the point set time (0 means the default tolerance, 0.1s). If a variable has
+// no value in the tolerance, its value is obtained with the Method (CONSTANT by
+// default). The last known value of a variable is not used if it is older than
+// Staleness seconds (0 means no limit).
+// swagger:model
+type Interpolation struct {
+	Method    InterpolationMethod `json:"method,omitempty"`
+	Tolerance float64             `json:"tolerance,omitempty"`
+	Staleness float64             `json:"staleness,omitempty"`
 }
 
 // Aggregation gives aggregation information of a variable.
@@ -253,6 +361,23 @@ type Guarantee struct {
 	Schedule   Schedule     `json:"schedule,omitempty"`
 	Warning    string       `json:"warning,omitempty"`
 	Penalties  []PenaltyDef `json:"penalties,omitempty"`
+	// OnMissingData is the policy when there are no values to evaluate the term.
+	// If nil, the IGNORE policy is applied.
+	OnMissingData *MissingData `json:"on_missing_data,omitempty"`
+	// Calendar is the name of a registered calendar (see RegisterCalendar). If set,
+	// only the values in the active time of the calendar are evaluated.
+	Calendar string `json:"calendar,omitempty"`
+}
+
+// MissingData sets the assessment of a guarantee term when the monitoring
+// returns no values (e.g. the monitoring is down or the series are absent).
+//
+// MaxAge is the maximum age in seconds of the last known values in the
+// LASTVALUE policy (0 means no limit). When they are older, the term is violated.
+// swagger:model
+type MissingData struct {
+	Policy MissingDataPolicy `json:"policy"`
+	MaxAge int               `json:"max_age,omitempty"`
 }
 
 // Scope is the resources a guarantee term applies on
@@ -290,6 +415,149 @@ type Violation struct {
 	Datetime    time.Time     `json:"datetime"`
 	Constraint  string        `json:"constraint"`
 	Values      []MetricValue `json:"values"`
+	// NoData is set when the violation is raised because there were no values (see MissingData)
+	NoData bool `json:"no_data,omitempty"`
+}
+
+// Incident groups the consecutive violations of a guarantee term.
+//
+// An incident is opened by the first violation of a guarantee term, and it is
+// closed (End is set) the first time the guarantee term is fulfilled again.
+// swagger:model
+type Incident struct {
+	Id            string                  `json:"id" bson:"_id"`
+	AgreementId   string                  `json:"agreement_id"`
+	Guarantee     string                  `json:"guarantee"`
+	Constraint    string                  `json:"constraint"`
+	State         IncidentState           `json:"state"`
+	Start         time.Time               `json:"start"`
+	End           *time.Time              `json:"end,omitempty"`
+	LastViolation time.Time               `json:"last_violation"`
+	Count         int                     `json:"count"`
+	Peak          map[string]IncidentPeak `json:"peak,omitempty"`
+}
+
+// IncidentPeak contains the extreme values of a variable during an incident.
+// Only numeric values are considered.
+// swagger:model
+type IncidentPeak struct {
+	Min MetricValue `json:"min"`
+	Max MetricValue `json:"max"`
+}
+
+// Replay is the evaluation of an agreement over a past period, with the
+// violations that would have been raised. It is stored apart from the live
+// assessment of the agreement, which is not modified by a replay.
+// swagger:model
+type Replay struct {
+	Id          string    `json:"id" bson:"_id"`
+	AgreementId string    `json:"agreement_id"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	// Step is the evaluation period, in seconds
+	Step    int       `json:"step"`
+	Created time.Time `json:"created"`
+	// Status is running until all the steps are evaluated; the results are
+	// complete when the status is done
+	Status ReplayStatus `json:"status"`
+	// Guarantees are the guarantee terms evaluated in the replay; they may differ
+	// from the guarantee terms of the agreement
+	Guarantees []Guarantee                `json:"guarantees"`
+	Results    map[string]ReplayGuarantee `json:"results"`
+	Violations []Violation                `json:"violations"`
+}
+
+// ReplayGuarantee is the result of a guarantee term in a replay
+// swagger:model
+type ReplayGuarantee struct {
+	// Evaluated is the number of point sets evaluated
+	Evaluated int `json:"evaluated"`
+	// Failed is the number of point sets that did not fulfill the constraint
+	Failed int `json:"failed"`
+	// Excluded is the number of point sets that did not fulfill the constraint
+	// in a maintenance window. They are not counted as failed.
+	Excluded int `json:"excluded"`
+	// NoData is the number of steps without values
+	NoData int `json:"no_data"`
+	// Errors is the number of steps where monitoring failed
+	Errors int `json:"errors"`
+	// Compliance is the ratio of evaluated point sets that fulfilled the
+	// constraint. It is 1 if no point set was evaluated.
+	Compliance float64 `json:"compliance"`
+}
+
+// DailyStats are the evaluation counters of a guarantee term in a day (UTC).
+// They are updated on each assessment and used to build compliance reports.
+// swagger:model
+type DailyStats struct {
+	Id          string    `json:"id" bson:"_id"`
+	AgreementId string    `json:"agreement_id"`
+	Guarantee   string    `json:"guarantee"`
+	Date        time.Time `json:"date"`
+	// Evaluated is the number of point sets evaluated
+	Evaluated int `json:"evaluated"`
+	// Failed is the number of point sets that did not fulfill the constraint
+	Failed int `json:"failed"`
+	// Excluded is the number of point sets that did not fulfill the constraint
+	// in a maintenance window. They are not counted as failed.
+	Excluded int `json:"excluded"`
+	// NoData is the number of evaluations without values
+	NoData int `json:"no_data"`
+	// Errors is the number of evaluations where monitoring failed
+	Errors int `json:"errors"`
+	// Violations is the number of violations raised
+	Violations int `json:"violations"`
+}
+
+// NewDailyStats returns empty stats of a guarantee term in the day of t (UTC)
+func NewDailyStats(agreementID string, guarantee string, t time.Time) DailyStats {
+	date := t.UTC().Truncate(24 * time.Hour)
+	return DailyStats{
+		Id:          fmt.Sprintf("%s/%s/%s", agreementID, guarantee, date.Format("2006-01-02")),
+		AgreementId: agreementID,
+		Guarantee:   guarantee,
+		Date:        date,
+	}
+}
+
+// EvaluationRecord is a point of the evaluation history of a guarantee term.
+// If the history is downsampled, a record summarizes the evaluations in a time
+// slot of the resolution; otherwise, there is a record per evaluated point set.
+// swagger:model
+type EvaluationRecord struct {
+	Id          string `json:"id" bson:"_id"`
+	AgreementId string `json:"agreement_id"`
+	Guarantee   string `json:"guarantee"`
+	// Datetime is the time of the point set, or the start of the slot if downsampled
+	Datetime time.Time `json:"datetime"`
+	// Values are the values of the variables in the last point set of the record
+	Values map[string]interface{} `json:"values"`
+	// Passed is true if all the point sets fulfilled the constraint or were excluded
+	Passed bool `json:"passed"`
+	// Evaluated is the number of point sets evaluated
+	Evaluated int `json:"evaluated"`
+	// Failed is the number of point sets that did not fulfill the constraint
+	Failed int `json:"failed"`
+	// Excluded is the number of point sets that did not fulfill the constraint
+	// in a maintenance window. They are not counted as failed.
+	Excluded int `json:"excluded"`
+}
+
+// NewEvaluationRecord returns an empty record of a guarantee term in the slot
+// of t. If resolution is not positive, the slot is t.
+func NewEvaluationRecord(agreementID string, guarantee string, t time.Time, resolution time.Duration) EvaluationRecord {
+	t = t.UTC()
+	if resolution > 0 {
+		t = t.Truncate(resolution)
+	}
+	return EvaluationRecord{
+		Id:          fmt.Sprintf("%s/%s/%d", agreementID, guarantee, t.UnixNano()),
+		AgreementId: agreementID,
+		Guarantee:   guarantee,
+		Datetime:    t,
+		Values:      make(map[string]interface{}),
+		Passed:      true,
+	}
 }
 
 // Penalty is generated when a guarantee term is violated is the term has
@@ -338,6 +606,28 @@ func (a *Agreement) IsStopped() bool {
 	return a.State == STOPPED
 }
 
+// Activate sets the activation time of the agreement to t and, if the agreement
+// has a Duration and no Expiration, its Expiration relative to t.
+func (a *Agreement) Activate(t time.Time) error {
+	a.Assessment.Activation = &t
+	if a.Details.Duration == "" || a.Details.Expiration != nil {
+		return nil
+	}
+	d, err := ParseISODuration(a.Details.Duration)
+	if err != nil {
+		return err
+	}
+	expiration := d.AddTo(t)
+	a.Details.Expiration = &expiration
+	return nil
+}
+
+// IsEffective returns if the agreement has started to be effective at t,
+// i.e., it has no Start or its Start is not after t.
+func (a *Agreement) IsEffective(t time.Time) bool {
+	return a.Details.Start == nil || !a.Details.Start.After(t)
+}
+
 // IsValidTransition returns if the transition to newState is valid
 func (a *Agreement) IsValidTransition(newState State) bool {
 	return a.State != TERMINATED
@@ -410,6 +700,105 @@ func (v *Violation) Validate(val Validator, mode ValidationMode) []error {
 	return val.ValidateViolation(v, mode)
 }
 
+// GetId returns the Id of an incident
+func (i *Incident) GetId() string {
+	return i.Id
+}
+
+// Validate validates the consistency of an Incident entity
+func (i *Incident) Validate(val Validator, mode ValidationMode) []error {
+	return val.ValidateIncident(i, mode)
+}
+
+// GetId returns the Id of daily stats
+func (s *DailyStats) GetId() string {
+	return s.Id
+}
+
+// GetId returns the Id of an evaluation record
+func (e *EvaluationRecord) GetId() string {
+	return e.Id
+}
+
+// GetId returns the Id of a replay
+func (r *Replay) GetId() string {
+	return r.Id
+}
+
+// Validate validates the consistency of a Replay entity
+func (r *Replay) Validate(val Validator, mode ValidationMode) []error {
+	return val.ValidateReplay(r, mode)
+}
+
+// IsOpen is true if the incident state is OPEN
+func (i *Incident) IsOpen() bool {
+	return i.State == OPEN
+}
+
+// AddViolation adds a violation to the incident, updating the last violation time,
+// the number of violations and the peak values.
+func (i *Incident) AddViolation(v Violation) {
+	if i.Count == 0 || v.Datetime.Before(i.Start) {
+		i.Start = v.Datetime
+	}
+	if v.Datetime.After(i.LastViolation) {
+		i.LastViolation = v.Datetime
+	}
+	i.Count++
+	if i.Peak == nil {
+		i.Peak = make(map[string]IncidentPeak)
+	}
+	for _, m := range v.Values {
+		value, ok := toFloat(m.Value)
+		if !ok {
+			continue
+		}
+		peak, exists := i.Peak[m.Key]
+		if !exists {
+			peak = IncidentPeak{Min: m, Max: m}
+		} else {
+			if min, _ := toFloat(peak.Min.Value); value < min {
+				peak.Min = m
+			}
+			if max, _ := toFloat(peak.Max.Value); value > max {
+				peak.Max = m
+			}
+		}
+		i.Peak[m.Key] = peak
+	}
+}
+
+// Close closes the incident at time end
+func (i *Incident) Close(end time.Time) {
+	i.State = CLOSED
+	i.End = &end
+}
+
+// Duration returns the duration of the incident. If the incident is open, it
+// is the time elapsed from the start to now.
+func (i *Incident) Duration(now time.Time) time.Duration {
+	if i.End != nil {
+		return i.End.Sub(i.Start)
+	}
+	return now.Sub(i.Start)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
 // Normalize returns an always valid state: any different value from contained in States is STOPPED.
 func (s State) Normalize() State {
 	return normalizeState(s)
@@ -426,3 +815,15 @@ type Agreements []Agreement
 // Templates is the type of an slice of Template
 // swagger:model
 type Templates []Template
+
+// Incidents is the type of an slice of Incident
+// swagger:model
+type Incidents []Incident
+
+// Violations is the type of an slice of Violation
+// swagger:model
+type Violations []Violation
+
+// Replays is the type of an slice of Replay
+// swagger:model
+type Replays []Replay
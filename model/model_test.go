@@ -62,6 +62,15 @@ func TestClient(t *testing.T) {
 func TestAssessment(t *testing.T) {
 	a := Assessment{FirstExecution: time.Now(), LastExecution: time.Now()}
 	checkNumber(t, &a, 0)
+
+	a.Interpolation = &Interpolation{Method: LINEAR, Tolerance: 1, Staleness: 60}
+	checkNumber(t, &a, 0)
+
+	a.Interpolation = &Interpolation{Method: "cubic"}
+	checkNumber(t, &a, 1)
+
+	a.Interpolation = &Interpolation{Method: STRICT, Tolerance: -1}
+	checkNumber(t, &a, 1)
 }
 
 func TestGuarantee(t *testing.T) {
@@ -74,6 +83,11 @@ func TestGuarantee(t *testing.T) {
 	g = Guarantee{Name: "name", Constraint: ""}
 	checkNumber(t, &g, 1)
 
+	g = Guarantee{Name: "name", Constraint: "a LT 10", OnMissingData: &MissingData{Policy: LASTVALUE, MaxAge: 60}}
+	checkNumber(t, &g, 0)
+
+	g.OnMissingData = &MissingData{Policy: "retry", MaxAge: -1}
+	checkNumber(t, &g, 2)
 }
 
 func TestDetails(t *testing.T) {
@@ -102,6 +116,56 @@ func TestDetails(t *testing.T) {
 		},
 	}
 	checkNumber(t, &at, 2)
+
+	at = Details{
+		Id:       "id",
+		Name:     "name",
+		Provider: pr,
+		Client:   cl,
+		Variables: []Variable{
+			{Name: "v", Metric: "m", Interpolation: &Interpolation{Method: "cubic"}},
+		},
+	}
+	checkNumber(t, &at, 1)
+
+	start := time.Now()
+	at = Details{Id: "id", Name: "name", Provider: pr, Client: cl, Start: &start, Duration: "P1M"}
+	checkNumber(t, &at, 0)
+
+	at = Details{Id: "id", Name: "name", Provider: pr, Client: cl, Duration: "1 month"}
+	checkNumber(t, &at, 1)
+
+	at = Details{Id: "id", Name: "name", Provider: pr, Client: cl, Start: &start, Expiration: &start}
+	checkNumber(t, &at, 1)
+}
+
+func TestActivate(t *testing.T) {
+	start := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	a := Agreement{Details: Details{Duration: "P1M"}}
+	if err := a.Activate(start); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !a.Assessment.Activation.Equal(start) {
+		t.Errorf("Unexpected activation: %v", a.Assessment.Activation)
+	}
+	if expected := start.AddDate(0, 1, 0); a.Details.Expiration == nil || !a.Details.Expiration.Equal(expected) {
+		t.Errorf("Unexpected expiration. Expected: %v; Actual: %v", expected, a.Details.Expiration)
+	}
+
+	expiration := start.Add(time.Hour)
+	a = Agreement{Details: Details{Duration: "P1M", Expiration: &expiration}}
+	a.Activate(start)
+	if !a.Details.Expiration.Equal(expiration) {
+		t.Errorf("Expected expiration unchanged. Actual: %v", a.Details.Expiration)
+	}
+
+	if !a.IsEffective(start) {
+		t.Error("Expected agreement without start effective")
+	}
+	a.Details.Start = &expiration
+	if a.IsEffective(start) || !a.IsEffective(expiration) {
+		t.Error("Unexpected IsEffective")
+	}
 }
 
 func TestAgreement(t *testing.T) {
@@ -355,6 +419,9 @@ func TestViolation(t *testing.T) {
 	if v.GetId() != v.Id {
 		t.Errorf("Violation.Id and Violation.GetId() do not match")
 	}
+
+	v.NoData = true
+	checkNumber(t, &v, 5)
 }
 
 func TestViolationSerialization(t *testing.T) {
@@ -404,6 +471,39 @@ func TestMetricValue(t *testing.T) {
 	}
 }
 
+func TestServerIDsWithExternalIDs(t *testing.T) {
+	val := NewDefaultValidator(true, false)
+	now := time.Now()
+
+	i := Incident{Id: "i01", AgreementId: "a01", Guarantee: "gt", Start: now, State: OPEN}
+	if errs := i.Validate(val, CREATE); len(errs) != 0 {
+		t.Errorf("Unexpected errors validating incident: %v", errs)
+	}
+	i.Id = ""
+	if errs := i.Validate(val, CREATE); len(errs) != 1 {
+		t.Errorf("Expected error validating incident without Id: %v", errs)
+	}
+
+	v := Violation{Id: "v01", AgreementId: "a01", Guarantee: "gt", Datetime: now, Constraint: "m > 0",
+		Values: []MetricValue{{Key: "m", Value: 0, DateTime: now}}}
+	if errs := v.Validate(val, CREATE); len(errs) != 0 {
+		t.Errorf("Unexpected errors validating violation: %v", errs)
+	}
+	v.Id = ""
+	if errs := v.Validate(val, CREATE); len(errs) != 1 {
+		t.Errorf("Expected error validating violation without Id: %v", errs)
+	}
+
+	r := Replay{Id: "r01", AgreementId: "a01", From: now, To: now.Add(time.Hour), Step: 60}
+	if errs := r.Validate(val, CREATE); len(errs) != 0 {
+		t.Errorf("Unexpected errors validating replay: %v", errs)
+	}
+	r.Id = ""
+	if errs := r.Validate(val, CREATE); len(errs) != 1 {
+		t.Errorf("Expected error validating replay without Id: %v", errs)
+	}
+}
+
 func checkNumber(t *testing.T, v Validable, expected int) {
 	if errs := v.Validate(val, CREATE); len(errs) != expected {
 		t.Errorf("Error validating %s%v. Errors = %v; Expected: %d", reflect.TypeOf(v), v, errs, expected)
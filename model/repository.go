@@ -16,6 +16,8 @@ limitations under the License.
 
 package model
 
+import "time"
+
 const (
 	// UnixConfigPath is the default configuration path in *ix platforms.
 	UnixConfigPath = "/etc/slalite"
@@ -137,6 +139,22 @@ type IRepository interface {
 	 */
 	GetViolation(id string) (*Violation, error)
 
+	/*
+	 * DeleteViolation deletes from the repository the Violation whose id is v.Id.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the Violation does not exist.
+	 */
+	DeleteViolation(v *Violation) error
+
+	/*
+	 * GetViolationsByAgreement returns the violations of an agreement raised in
+	 * the interval [from, to), sorted by time.
+	 *
+	 * error != nil on error;
+	 */
+	GetViolationsByAgreement(agreementID string, from time.Time, to time.Time) (Violations, error)
+
 	/*
 	 * UpdateAgreementState changes the state of an Agreement.
 	 *
@@ -147,4 +165,145 @@ type IRepository interface {
 	 * (it is recommended to check a.IsValidTransition before UpdateAgreementState)
 	 */
 	UpdateAgreementState(id string, newState State) (*Agreement, error)
+
+	/*
+	 * CreateIncident stores a new Incident.
+	 *
+	 * error != nil on error;
+	 * error is ErrAlreadyExist if the Incident already exists
+	 */
+	CreateIncident(i *Incident) (*Incident, error)
+
+	/*
+	 * UpdateIncident updates the information of an already saved incident.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the Incident does not exist
+	 */
+	UpdateIncident(i *Incident) (*Incident, error)
+
+	/*
+	 * GetIncident returns the Incident identified by id.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the Incident is not found
+	 */
+	GetIncident(id string) (*Incident, error)
+
+	/*
+	 * GetIncidentsByAgreement returns the incidents of an agreement sorted by start
+	 * time. If states is not empty, only the incidents in one of the states are returned.
+	 *
+	 * error != nil on error;
+	 */
+	GetIncidentsByAgreement(agreementID string, states ...IncidentState) (Incidents, error)
+
+	/*
+	 * CreateReplay stores a new Replay.
+	 *
+	 * error != nil on error;
+	 * error is ErrAlreadyExist if the Replay already exists
+	 */
+	CreateReplay(r *Replay) (*Replay, error)
+
+	/*
+	 * UpdateReplay updates the information of an already saved replay.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the Replay does not exist
+	 */
+	UpdateReplay(r *Replay) (*Replay, error)
+
+	/*
+	 * GetReplay returns the Replay identified by id.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the Replay is not found
+	 */
+	GetReplay(id string) (*Replay, error)
+
+	/*
+	 * GetReplaysByAgreement returns the replays of an agreement sorted by creation time.
+	 *
+	 * error != nil on error;
+	 */
+	GetReplaysByAgreement(agreementID string) (Replays, error)
+
+	/*
+	 * SaveDailyStats stores daily stats, replacing the stats with the same id.
+	 *
+	 * error != nil on error;
+	 */
+	SaveDailyStats(s *DailyStats) (*DailyStats, error)
+
+	/*
+	 * GetDailyStats returns the daily stats of an agreement whose date is in the
+	 * interval [from, to), sorted by date.
+	 *
+	 * error != nil on error;
+	 */
+	GetDailyStats(agreementID string, from time.Time, to time.Time) ([]DailyStats, error)
+
+	/*
+	 * SaveEvaluationRecord stores an evaluation record, replacing the record with the same id.
+	 *
+	 * error != nil on error;
+	 */
+	SaveEvaluationRecord(e *EvaluationRecord) (*EvaluationRecord, error)
+
+	/*
+	 * GetEvaluationHistory returns the evaluation records of a guarantee term of an
+	 * agreement whose datetime is in the interval [from, to), sorted by datetime.
+	 *
+	 * error != nil on error;
+	 */
+	GetEvaluationHistory(agreementID string, guarantee string, from time.Time, to time.Time) ([]EvaluationRecord, error)
+
+	/*
+	 * DeleteEvaluationHistory removes the evaluation records of an agreement
+	 * whose datetime is before the given time.
+	 *
+	 * error != nil on error;
+	 */
+	DeleteEvaluationHistory(agreementID string, before time.Time) error
+
+	/*
+	 * GetAllMaintenanceWindows returns the list of maintenance windows.
+	 *
+	 * The list is empty when there are no maintenance windows;
+	 * error != nil on error
+	 */
+	GetAllMaintenanceWindows() (MaintenanceWindows, error)
+
+	/*
+	 * GetMaintenanceWindow returns the MaintenanceWindow identified by id.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the MaintenanceWindow is not found
+	 */
+	GetMaintenanceWindow(id string) (*MaintenanceWindow, error)
+
+	/*
+	 * CreateMaintenanceWindow stores a new MaintenanceWindow.
+	 *
+	 * error != nil on error;
+	 * error is ErrAlreadyExist if the MaintenanceWindow already exists
+	 */
+	CreateMaintenanceWindow(w *MaintenanceWindow) (*MaintenanceWindow, error)
+
+	/*
+	 * UpdateMaintenanceWindow replaces an already saved MaintenanceWindow.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the MaintenanceWindow does not exist
+	 */
+	UpdateMaintenanceWindow(w *MaintenanceWindow) (*MaintenanceWindow, error)
+
+	/*
+	 * DeleteMaintenanceWindow deletes the MaintenanceWindow whose id is w.Id.
+	 *
+	 * error != nil on error;
+	 * error is ErrNotFound if the MaintenanceWindow does not exist
+	 */
+	DeleteMaintenanceWindow(w *MaintenanceWindow) error
 }
@@ -19,6 +19,7 @@ package model
 import (
 	"fmt"
 	"net/url"
+	"time"
 )
 
 /*
@@ -35,6 +36,9 @@ type Validator interface {
 	ValidateDetails(t *Details, mode ValidationMode) []error
 	ValidateGuarantee(g *Guarantee, mode ValidationMode) []error
 	ValidateViolation(v *Violation, mode ValidationMode) []error
+	ValidateIncident(i *Incident, mode ValidationMode) []error
+	ValidateReplay(r *Replay, mode ValidationMode) []error
+	ValidateMaintenanceWindow(w *MaintenanceWindow, mode ValidationMode) []error
 }
 
 // ValidationMode is the type of possible validations
@@ -143,7 +147,7 @@ func (val DefaultValidator) ValidateAssessment(as *Assessment, mode ValidationMo
 			return []error{err}
 		}
 	}
-	return []error{}
+	return checkInterpolation(as.Interpolation, "Assessment.Interpolation", []error{})
 }
 
 // ValidateDetails implements model.Validator.ValidateDetails
@@ -165,6 +169,17 @@ func (val DefaultValidator) ValidateDetails(t *Details, mode ValidationMode) []e
 			result = append(result, e)
 		}
 	}
+	for _, v := range t.Variables {
+		result = checkInterpolation(v.Interpolation, fmt.Sprintf("Variable['%s'].Interpolation", v.Name), result)
+	}
+	if t.Duration != "" {
+		if _, err := ParseISODuration(t.Duration); err != nil {
+			result = append(result, fmt.Errorf("Details.Duration: %s", err.Error()))
+		}
+	}
+	if t.Start != nil && t.Expiration != nil && !t.Start.Before(*t.Expiration) {
+		result = append(result, fmt.Errorf("Details.Start must be before Details.Expiration"))
+	}
 	return result
 }
 
@@ -172,13 +187,14 @@ func (val DefaultValidator) ValidateDetails(t *Details, mode ValidationMode) []e
 func (val DefaultValidator) ValidateViolation(v *Violation, mode ValidationMode) []error {
 	result := make([]error, 0)
 
-	result = checkEmpty(mode == CREATE && val.externalIDs, v.Id, "Violation.Id", result)
+	/* violations are created by the assessment with their Id, even with external IDs */
+	result = checkNotEmpty(v.Id, "Violation.Id", result)
 	result = checkNotEmpty(v.AgreementId, "Violation.AgreementId", result)
 	result = checkNotEmpty(v.Guarantee, "Violation.Guarantee", result)
 	if v.Datetime.IsZero() {
 		result = append(result, fmt.Errorf("%v is not a valid date", v.Datetime))
 	}
-	if v.Values == nil || len(v.Values) == 0 {
+	if !v.NoData && (v.Values == nil || len(v.Values) == 0) {
 		result = append(result, fmt.Errorf("Violation.Values cannot be empty"))
 	}
 	result = checkNotEmpty(v.Constraint, "Violation.Constraint", result)
@@ -186,15 +202,105 @@ func (val DefaultValidator) ValidateViolation(v *Violation, mode ValidationMode)
 	return result
 }
 
+// ValidateIncident implements model.Validator.ValidateIncident
+func (val DefaultValidator) ValidateIncident(i *Incident, mode ValidationMode) []error {
+	result := make([]error, 0)
+
+	/* incidents are created by the assessment with their Id, even with external IDs */
+	result = checkNotEmpty(i.Id, "Incident.Id", result)
+	result = checkNotEmpty(i.AgreementId, "Incident.AgreementId", result)
+	result = checkNotEmpty(i.Guarantee, "Incident.Guarantee", result)
+	if i.Start.IsZero() {
+		result = append(result, fmt.Errorf("%v is not a valid date", i.Start))
+	}
+	if i.State != OPEN && i.State != CLOSED {
+		result = append(result, fmt.Errorf("Incident.State '%s' is not valid", i.State))
+	}
+	if i.State == CLOSED && i.End == nil {
+		result = append(result, fmt.Errorf("Incident.End cannot be empty on a closed incident"))
+	}
+	return result
+}
+
+// ValidateMaintenanceWindow implements model.Validator.ValidateMaintenanceWindow
+func (val DefaultValidator) ValidateMaintenanceWindow(w *MaintenanceWindow, mode ValidationMode) []error {
+	result := make([]error, 0)
+
+	result = checkEmpty(mode == CREATE && val.externalIDs, w.Id, "MaintenanceWindow.Id", result)
+	if !w.Start.Before(w.End) {
+		result = append(result, fmt.Errorf("MaintenanceWindow.Start must be before MaintenanceWindow.End"))
+	}
+	if w.Recurrence != ONCE {
+		max, ok := maxWindowDurations[w.Recurrence]
+		if !ok {
+			result = append(result, fmt.Errorf("'%s' is not a valid value for MaintenanceWindow.Recurrence", w.Recurrence))
+		} else if w.End.Sub(w.Start) > max {
+			result = append(result, fmt.Errorf("MaintenanceWindow cannot last more than %v if %s", max, w.Recurrence))
+		}
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		result = append(result, fmt.Errorf("MaintenanceWindow.Timezone: %s", err.Error()))
+	}
+	if w.Until != nil && w.Until.Before(w.Start) {
+		result = append(result, fmt.Errorf("MaintenanceWindow.Until cannot be before MaintenanceWindow.Start"))
+	}
+	return result
+}
+
+// ValidateReplay implements model.Validator.ValidateReplay
+func (val DefaultValidator) ValidateReplay(r *Replay, mode ValidationMode) []error {
+	result := make([]error, 0)
+
+	/* replays are created by the server with their Id, even with external IDs */
+	result = checkNotEmpty(r.Id, "Replay.Id", result)
+	result = checkNotEmpty(r.AgreementId, "Replay.AgreementId", result)
+	if !r.From.Before(r.To) {
+		result = append(result, fmt.Errorf("Replay.From must be before Replay.To"))
+	}
+	if r.Step <= 0 {
+		result = append(result, fmt.Errorf("Replay.Step must be positive"))
+	}
+	return result
+}
+
 // ValidateGuarantee implements model.Validator.ValidateGuarantee
 func (val DefaultValidator) ValidateGuarantee(g *Guarantee, mode ValidationMode) []error {
 	result := make([]error, 0)
 	result = checkNotEmpty(g.Name, "Guarantee.Name", result)
 	result = checkNotEmpty(g.Constraint, fmt.Sprintf("Guarantee['%s'].Constraint", g.Name), result)
+	if m := g.OnMissingData; m != nil {
+		switch m.Policy {
+		case IGNORE, VIOLATE, WARN, LASTVALUE:
+		default:
+			result = append(result, fmt.Errorf("Guarantee['%s'].OnMissingData.Policy '%s' is not valid", g.Name, m.Policy))
+		}
+		if m.MaxAge < 0 {
+			result = append(result, fmt.Errorf("Guarantee['%s'].OnMissingData.MaxAge cannot be negative", g.Name))
+		}
+	}
+	/* on update, a removed calendar must not prevent saving the assessment */
+	if _, ok := GetCalendar(g.Calendar); mode == CREATE && g.Calendar != "" && !ok {
+		result = append(result, fmt.Errorf("Guarantee['%s'].Calendar '%s' is not defined", g.Name, g.Calendar))
+	}
 
 	return result
 }
 
+func checkInterpolation(i *Interpolation, description string, current []error) []error {
+	if i == nil {
+		return current
+	}
+	switch i.Method {
+	case "", CONSTANT, LINEAR, STRICT:
+	default:
+		current = append(current, fmt.Errorf("%s.Method '%s' is not valid", description, i.Method))
+	}
+	if i.Tolerance < 0 || i.Staleness < 0 {
+		current = append(current, fmt.Errorf("%s cannot have negative times", description))
+	}
+	return current
+}
+
 func checkNotEmpty(field string, description string, current []error) []error {
 	if field == "" {
 		current = append(current, fmt.Errorf("%s is empty", description))
@@ -0,0 +1,51 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+/*
+This file selects the repositories, monitoring adapters, retrievers and notifiers built in
+SLALite. Each package registers itself in the registry on import.
+
+To add a custom implementation, create a new file in this package with a blank
+import of the implementation package.
+*/
+
+import (
+	// Repositories
+	_ "SLALite/repositories/memrepository"
+	_ "SLALite/repositories/mongodb"
+
+	// Adapters and retrievers
+	_ "SLALite/assessment/monitor/genericadapter"
+	_ "SLALite/assessment/monitor/graphite"
+	_ "SLALite/assessment/monitor/httpjson"
+	_ "SLALite/assessment/monitor/influxdb"
+	_ "SLALite/assessment/monitor/prometheus"
+	_ "SLALite/assessment/monitor/push"
+	_ "SLALite/assessment/monitor/remotewrite"
+	_ "SLALite/assessment/monitor/static"
+
+	// Notifiers
+	_ "SLALite/assessment/notifier/kafkanotifier"
+	_ "SLALite/assessment/notifier/lognotifier"
+	_ "SLALite/assessment/notifier/natsnotifier"
+	_ "SLALite/assessment/notifier/rabbitnotifier"
+	_ "SLALite/assessment/notifier/rest"
+	_ "SLALite/assessment/notifier/slacknotifier"
+	_ "SLALite/assessment/notifier/smtpnotifier"
+	_ "SLALite/assessment/notifier/webhooknotifier"
+)
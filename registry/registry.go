@@ -0,0 +1,198 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package registry keeps the repositories, monitoring adapters and notifiers
+that can be selected by name in the configuration (settings `repository`,
+`adapter` and `notifier`).
+
+Each implementation registers a factory in the init function of its package,
+together with the description of the settings it reads:
+
+	func init() {
+		notifier.Register(Name, New,
+			registry.Property{Name: URLPropertyName, Description: "URL to post to"})
+	}
+
+This package only depends on the model, so that it can be imported by any
+package. The typed functions to register and build adapters, retrievers and notifiers
+are in the monitor, genericadapter and notifier packages.
+
+The main package selects the implementations to build in by importing their
+packages (see plugins.go); a custom implementation is added with a blank import
+of its package in a new file of the main package.
+*/
+package registry
+
+import (
+	"SLALite/model"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Kind is the kind of a registered component
+type Kind string
+
+const (
+	// RepositoryKind is the kind of repositories (setting `repository`)
+	RepositoryKind Kind = "repository"
+
+	// AdapterKind is the kind of monitoring adapters (setting `adapter`)
+	AdapterKind Kind = "adapter"
+
+	// NotifierKind is the kind of notifiers (setting `notifier`)
+	NotifierKind Kind = "notifier"
+
+	// RetrieverKind is the kind of monitoring retrievers (setting `type` of a source)
+	RetrieverKind Kind = "retriever"
+)
+
+// Property describes a configuration setting read by a component.
+//
+// If Default is not nil, it is set as default value of the setting before
+// building the component.
+type Property struct {
+	Name        string
+	Default     interface{}
+	Description string
+}
+
+// Schema is the list of settings read by a component
+type Schema []Property
+
+// RepositoryFactory builds a repository. The config may be nil if the repository
+// settings are not in the main configuration file.
+type RepositoryFactory func(config *viper.Viper) (model.IRepository, error)
+
+type entry struct {
+	factory interface{}
+	schema  Schema
+}
+
+var (
+	mu      sync.RWMutex
+	entries = map[Kind]map[string]entry{
+		RepositoryKind: {},
+		AdapterKind:    {},
+		NotifierKind:   {},
+		RetrieverKind:  {},
+	}
+)
+
+// RegisterRepository registers a repository factory with the name used in the `repository` setting
+func RegisterRepository(name string, f RepositoryFactory, schema ...Property) {
+	Register(RepositoryKind, name, f, schema...)
+}
+
+/*
+Register registers a factory of a kind of component. The factory is returned
+as is by Lookup, so the registering and the building packages must agree on
+its type. Prefer the typed functions (RegisterRepository, monitor.Register,
+notifier.Register).
+
+It panics if the name is already registered, as this is a programming error.
+*/
+func Register(kind Kind, name string, factory interface{}, schema ...Property) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := entries[kind][name]; ok {
+		panic(fmt.Sprintf("registry: %s '%s' registered twice", kind, name))
+	}
+	entries[kind][name] = entry{factory: factory, schema: schema}
+}
+
+// NewRepository builds the repository registered with a name
+func NewRepository(name string, config *viper.Viper) (model.IRepository, error) {
+	f, err := Lookup(RepositoryKind, name, config)
+	if err != nil {
+		return nil, err
+	}
+	return f.(RepositoryFactory)(config)
+}
+
+// Lookup returns the factory registered with a name, after setting the defaults
+// of its schema in config (if not nil).
+//
+// The returned error lists the valid names if the name is not registered.
+func Lookup(kind Kind, name string, config *viper.Viper) (interface{}, error) {
+	mu.RLock()
+	e, ok := entries[kind][name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown %s '%s'. Valid values: %s",
+			kind, name, strings.Join(Names(kind), ", "))
+	}
+	if config != nil {
+		for _, p := range e.schema {
+			if p.Default != nil {
+				config.SetDefault(p.Name, p.Default)
+			}
+		}
+	}
+	return e.factory, nil
+}
+
+// Names returns the sorted names registered for a kind
+func Names(kind Kind) []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make([]string, 0, len(entries[kind]))
+	for name := range entries[kind] {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// GetSchema returns the settings read by the component registered with a name
+func GetSchema(kind Kind, name string) (Schema, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	e, ok := entries[kind][name]
+	return e.schema, ok
+}
+
+// Describe returns a human readable list of the registered components of
+// every kind and their settings
+func Describe() string {
+	b := new(strings.Builder)
+	for _, kind := range []Kind{RepositoryKind, AdapterKind, RetrieverKind, NotifierKind} {
+		fmt.Fprintf(b, "%s:\n", kind)
+		for _, name := range Names(kind) {
+			fmt.Fprintf(b, "  %s\n", name)
+			schema, _ := GetSchema(kind, name)
+			for _, p := range schema {
+				fmt.Fprintf(b, "    %s", p.Name)
+				if p.Default != nil {
+					fmt.Fprintf(b, " (default: %v)", p.Default)
+				}
+				if p.Description != "" {
+					fmt.Fprintf(b, ". %s", p.Description)
+				}
+				fmt.Fprintln(b)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"SLALite/model"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+type testFactory func(config *viper.Viper) string
+
+func init() {
+	Register(AdapterKind, "test", testFactory(func(config *viper.Viper) string {
+		return config.GetString("testUrl")
+	}), Property{Name: "testUrl", Default: "http://localhost", Description: "Test URL"})
+	RegisterRepository("test", func(config *viper.Viper) (model.IRepository, error) {
+		return nil, errors.New("cannot connect")
+	})
+}
+
+func TestLookup(t *testing.T) {
+	config := viper.New()
+	f, err := Lookup(AdapterKind, "test", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if url := f.(testFactory)(config); url != "http://localhost" {
+		t.Errorf("Default value not applied: %s", url)
+	}
+
+	config.Set("testUrl", "http://example.com")
+	if url := f.(testFactory)(config); url != "http://example.com" {
+		t.Errorf("Config value not applied: %s", url)
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	_, err := Lookup(AdapterKind, "tset", viper.New())
+	if err == nil || !strings.Contains(err.Error(), "Valid values: test") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := Lookup(NotifierKind, "test", nil); err == nil {
+		t.Error("Expected error on unknown notifier")
+	}
+}
+
+func TestNewRepository(t *testing.T) {
+	if _, err := NewRepository("test", nil); err == nil || err.Error() != "cannot connect" {
+		t.Errorf("Expected error of the factory. Actual: %v", err)
+	}
+	if _, err := NewRepository("notexists", nil); err == nil {
+		t.Error("Expected error on unknown repository")
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic registering a name twice")
+		}
+	}()
+	Register(AdapterKind, "test", nil)
+}
+
+func TestDescribe(t *testing.T) {
+	s := Describe()
+	if !strings.Contains(s, "testUrl (default: http://localhost). Test URL") {
+		t.Errorf("Unexpected description: %s", s)
+	}
+}
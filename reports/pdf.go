@@ -0,0 +1,156 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+pdfDocument is a minimal PDF writer of text documents, with the standard
+Helvetica fonts and A4 pages. Long lines are wrapped, and a new page is added
+when a page is full.
+*/
+type pdfDocument struct {
+	pages [][]pdfLine
+}
+
+type pdfLine struct {
+	text string
+	bold bool
+}
+
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 50
+	pdfFontSize   = 9
+	pdfLeading    = 13
+	pdfLineChars  = 105
+	pdfPageLines  = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+func newPDFDocument() *pdfDocument {
+	return &pdfDocument{pages: [][]pdfLine{{}}}
+}
+
+// heading adds a bold line, preceded by an empty line if not at the top of the page
+func (d *pdfDocument) heading(text string) {
+	if len(d.pages[len(d.pages)-1]) > 0 {
+		d.add(pdfLine{})
+	}
+	d.add(pdfLine{text: text, bold: true})
+}
+
+// text adds a line, wrapped at pdfLineChars
+func (d *pdfDocument) text(text string) {
+	for len(text) > pdfLineChars {
+		cut := strings.LastIndex(text[:pdfLineChars], " ")
+		if cut <= 0 {
+			cut = pdfLineChars
+		}
+		d.add(pdfLine{text: text[:cut]})
+		text = "    " + strings.TrimLeft(text[cut:], " ")
+	}
+	d.add(pdfLine{text: text})
+}
+
+func (d *pdfDocument) add(line pdfLine) {
+	page := d.pages[len(d.pages)-1]
+	if len(page) == pdfPageLines {
+		d.pages = append(d.pages, []pdfLine{})
+	}
+	d.pages[len(d.pages)-1] = append(d.pages[len(d.pages)-1], line)
+}
+
+/*
+write writes the document. The objects are:
+1 catalog, 2 page tree, 3 regular font, 4 bold font, and a page object
+followed by its content stream for each page.
+*/
+func (d *pdfDocument) write(w io.Writer) error {
+	buf := new(bytes.Buffer)
+	offsets := make([]int, 0)
+	object := func(content string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		content := pageContent(page)
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func pageContent(lines []pdfLine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BT\n%d TL\n%d %d Td\n", pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range lines {
+		font := "F1"
+		if line.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&b, "/%s %d Tf\n(%s) '\n", font, pdfFontSize, pdfEscape(line.text))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+// pdfEscape escapes a string for a PDF literal string. The characters out of
+// Latin-1 are replaced by '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		case r > 127:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
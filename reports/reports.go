@@ -0,0 +1,265 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package reports builds the SLA compliance reports of agreements and providers
+for a calendar period.
+
+The reports are built from the daily stats and incidents stored by the
+assessment, so they only cover the periods when the agreements were assessed.
+*/
+package reports
+
+import (
+	"SLALite/model"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a calendar period in UTC: a year ("2026"), a month ("2026-09") or a day ("2026-09-15")
+type Period struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Penalty is the sum of the penalties of a guarantee term with the same type and unit
+type Penalty struct {
+	Type  string `json:"type"`
+	Unit  string `json:"unit"`
+	Count int    `json:"count"`
+	// Total is Count times the value of the penalty, if the value is numeric
+	Total float64 `json:"total"`
+}
+
+// GuaranteeReport is the report of a guarantee term in a period
+type GuaranteeReport struct {
+	Guarantee  string `json:"guarantee"`
+	Constraint string `json:"constraint"`
+	Evaluated  int    `json:"evaluated"`
+	Failed     int    `json:"failed"`
+	Excluded   int    `json:"excluded"`
+	NoData     int    `json:"no_data"`
+	Errors     int    `json:"errors"`
+	Violations int    `json:"violations"`
+	// Compliance is the percentage of evaluated point sets that fulfilled the
+	// constraint or were excluded. It is 100 if no point set was evaluated.
+	Compliance float64 `json:"compliance"`
+	Incidents  int     `json:"incidents"`
+	// IncidentDuration is the time in seconds with open incidents during the period
+	IncidentDuration float64   `json:"incident_duration"`
+	Penalties        []Penalty `json:"penalties"`
+}
+
+// AgreementReport is the report of an agreement in a period
+type AgreementReport struct {
+	AgreementId string            `json:"agreement_id"`
+	Name        string            `json:"name"`
+	Provider    model.Provider    `json:"provider"`
+	Client      model.Client      `json:"client"`
+	Period      Period            `json:"period"`
+	Guarantees  []GuaranteeReport `json:"guarantees"`
+}
+
+// ProviderReport is the report of the agreements of a provider in a period
+type ProviderReport struct {
+	Provider   model.Provider    `json:"provider"`
+	Period     Period            `json:"period"`
+	Agreements []AgreementReport `json:"agreements"`
+}
+
+// ParsePeriod parses a period name: a year ("2026"), a month ("2026-09") or a day ("2026-09-15")
+func ParsePeriod(name string) (Period, error) {
+	layouts := []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006", 1, 0, 0},
+		{"2006-01", 0, 1, 0},
+		{"2006-01-02", 0, 0, 1},
+	}
+	for _, l := range layouts {
+		if len(name) != len(l.layout) {
+			continue
+		}
+		from, err := time.Parse(l.layout, name)
+		if err != nil {
+			break
+		}
+		return Period{Name: name, From: from, To: from.AddDate(l.years, l.months, l.days)}, nil
+	}
+	return Period{}, fmt.Errorf("invalid period '%s'. Expected YYYY, YYYY-MM or YYYY-MM-DD", name)
+}
+
+// MonthOf returns the month period that contains t
+func MonthOf(t time.Time) Period {
+	t = t.UTC()
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Period{Name: from.Format("2006-01"), From: from, To: from.AddDate(0, 1, 0)}
+}
+
+// BuildAgreementReport builds the report of an agreement in a period. The
+// incidents still open are considered to end at now.
+func BuildAgreementReport(repo model.IRepository, a *model.Agreement, period Period, now time.Time) (*AgreementReport, error) {
+	stats, err := repo.GetDailyStats(a.Id, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := repo.GetIncidentsByAgreement(a.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AgreementReport{
+		AgreementId: a.Id,
+		Name:        a.Name,
+		Provider:    a.Details.Provider,
+		Client:      a.Details.Client,
+		Period:      period,
+		Guarantees:  make([]GuaranteeReport, 0, len(a.Details.Guarantees)),
+	}
+	for _, gt := range a.Details.Guarantees {
+		gtReport := GuaranteeReport{
+			Guarantee:  gt.Name,
+			Constraint: gt.Constraint,
+		}
+		for _, s := range stats {
+			if s.Guarantee == gt.Name {
+				gtReport.Evaluated += s.Evaluated
+				gtReport.Failed += s.Failed
+				gtReport.Excluded += s.Excluded
+				gtReport.NoData += s.NoData
+				gtReport.Errors += s.Errors
+				gtReport.Violations += s.Violations
+			}
+		}
+		gtReport.Compliance = 100
+		if gtReport.Evaluated > 0 {
+			gtReport.Compliance = 100 * float64(gtReport.Evaluated-gtReport.Failed) / float64(gtReport.Evaluated)
+		}
+		for _, i := range incidents {
+			if i.Guarantee != gt.Name {
+				continue
+			}
+			if d := overlap(i, period, now); d > 0 {
+				gtReport.Incidents++
+				gtReport.IncidentDuration += d.Seconds()
+			}
+		}
+		gtReport.Penalties = penalties(gt.Penalties, gtReport.Violations)
+		result.Guarantees = append(result.Guarantees, gtReport)
+	}
+	return result, nil
+}
+
+// BuildProviderReport builds the report of the agreements of a provider in a period
+func BuildProviderReport(repo model.IRepository, p *model.Provider, period Period, now time.Time) (*ProviderReport, error) {
+	agreements, err := repo.GetAllAgreements()
+	if err != nil {
+		return nil, err
+	}
+	result := &ProviderReport{
+		Provider:   *p,
+		Period:     period,
+		Agreements: make([]AgreementReport, 0),
+	}
+	for i := range agreements {
+		a := &agreements[i]
+		if a.Details.Provider.Id != p.Id {
+			continue
+		}
+		report, err := BuildAgreementReport(repo, a, period, now)
+		if err != nil {
+			return nil, err
+		}
+		result.Agreements = append(result.Agreements, *report)
+	}
+	return result, nil
+}
+
+// overlap returns the duration of an incident inside a period
+func overlap(i model.Incident, period Period, now time.Time) time.Duration {
+	start := i.Start
+	if start.Before(period.From) {
+		start = period.From
+	}
+	end := now
+	if i.End != nil {
+		end = *i.End
+	}
+	if end.After(period.To) {
+		end = period.To
+	}
+	return end.Sub(start)
+}
+
+func penalties(defs []model.PenaltyDef, violations int) []Penalty {
+	result := make([]Penalty, 0, len(defs))
+	for _, def := range defs {
+		p := Penalty{Type: def.Type, Unit: def.Unit, Count: violations}
+		if value, err := strconv.ParseFloat(def.Value, 64); err == nil {
+			p.Total = value * float64(violations)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+var csvHeader = []string{
+	"agreement_id", "agreement_name", "provider", "client", "period", "guarantee",
+	"evaluated", "failed", "no_data", "errors", "violations", "compliance",
+	"incidents", "incident_duration", "penalties", "excluded",
+}
+
+// WriteCSV writes the reports as CSV, with a line per guarantee term
+func WriteCSV(w io.Writer, reports ...AgreementReport) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range reports {
+		for _, gt := range r.Guarantees {
+			penalties := make([]string, 0, len(gt.Penalties))
+			for _, p := range gt.Penalties {
+				penalties = append(penalties, fmt.Sprintf("%s %g %s", p.Type, p.Total, p.Unit))
+			}
+			record := []string{
+				r.AgreementId, r.Name, r.Provider.Id, r.Client.Id, r.Period.Name, gt.Guarantee,
+				strconv.Itoa(gt.Evaluated),
+				strconv.Itoa(gt.Failed),
+				strconv.Itoa(gt.NoData),
+				strconv.Itoa(gt.Errors),
+				strconv.Itoa(gt.Violations),
+				strconv.FormatFloat(gt.Compliance, 'f', 3, 64),
+				strconv.Itoa(gt.Incidents),
+				strconv.FormatFloat(gt.IncidentDuration, 'f', 0, 64),
+				strings.Join(penalties, "; "),
+				strconv.Itoa(gt.Excluded),
+			}
+			if err := out.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
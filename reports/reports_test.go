@@ -0,0 +1,128 @@
+package reports
+
+import (
+	"SLALite/model"
+	"SLALite/repositories/memrepository"
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	cases := []struct {
+		name string
+		from time.Time
+		to   time.Time
+	}{
+		{"2026", date(2026, 1, 1), date(2027, 1, 1)},
+		{"2026-09", date(2026, 9, 1), date(2026, 10, 1)},
+		{"2026-12", date(2026, 12, 1), date(2027, 1, 1)},
+		{"2026-09-15", date(2026, 9, 15), date(2026, 9, 16)},
+	}
+	for _, c := range cases {
+		p, err := ParsePeriod(c.name)
+		if err != nil || !p.From.Equal(c.from) || !p.To.Equal(c.to) {
+			t.Errorf("Unexpected period of %s: %+v (%v)", c.name, p, err)
+		}
+	}
+	for _, name := range []string{"", "2026-9", "2026-13", "09-2026", "last month"} {
+		if _, err := ParsePeriod(name); err == nil {
+			t.Errorf("Expected error parsing %s", name)
+		}
+	}
+	if p := MonthOf(time.Date(2026, 9, 30, 23, 0, 0, 0, time.UTC)); p.Name != "2026-09" {
+		t.Errorf("Unexpected month: %+v", p)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	repo, _ := memrepository.New(nil)
+	p := model.Provider{Id: "p01", Name: "Provider 01"}
+	a := model.Agreement{
+		Id:   "a01",
+		Name: "Agreement 01",
+		Details: model.Details{
+			Provider: p,
+			Client:   model.Client{Id: "c01", Name: "Client 01"},
+			Guarantees: []model.Guarantee{
+				{
+					Name:       "gt1",
+					Constraint: "m > 10",
+					Penalties:  []model.PenaltyDef{{Type: "discount", Value: "2.5", Unit: "%"}},
+				},
+				{Name: "gt2", Constraint: "n > 10"},
+			},
+		},
+	}
+	repo.CreateProvider(&p)
+	repo.CreateAgreement(&a)
+	repo.CreateAgreement(&model.Agreement{Id: "a02", Details: model.Details{Provider: model.Provider{Id: "p02"}}})
+
+	for _, s := range []model.DailyStats{
+		stats("gt1", date(2026, 8, 31), 100, 50, 2),
+		stats("gt1", date(2026, 9, 1), 100, 10, 2),
+		stats("gt1", date(2026, 9, 30), 100, 0, 0),
+		stats("gt2", date(2026, 9, 2), 0, 0, 0),
+	} {
+		s := s
+		repo.SaveDailyStats(&s)
+	}
+	end := date(2026, 9, 2)
+	repo.CreateIncident(&model.Incident{Id: "i01", AgreementId: "a01", Guarantee: "gt1",
+		Start: date(2026, 8, 31), End: &end})
+	repo.CreateIncident(&model.Incident{Id: "i02", AgreementId: "a01", Guarantee: "gt1",
+		Start: date(2026, 9, 30)})
+
+	period, _ := ParsePeriod("2026-09")
+	now := date(2026, 9, 30).Add(time.Hour)
+	report, err := BuildAgreementReport(repo, &a, period, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.Guarantees) != 2 {
+		t.Fatalf("Expected 2 guarantees. Actual: %v", report.Guarantees)
+	}
+	gt1 := report.Guarantees[0]
+	if gt1.Evaluated != 200 || gt1.Failed != 10 || gt1.Violations != 2 || gt1.Compliance != 95 {
+		t.Errorf("Unexpected report of gt1: %+v", gt1)
+	}
+	if gt1.Incidents != 2 || gt1.IncidentDuration != (25*time.Hour).Seconds() {
+		t.Errorf("Unexpected incidents of gt1: %+v", gt1)
+	}
+	if len(gt1.Penalties) != 1 || gt1.Penalties[0].Total != 5 {
+		t.Errorf("Unexpected penalties of gt1: %+v", gt1.Penalties)
+	}
+	if gt2 := report.Guarantees[1]; gt2.Compliance != 100 {
+		t.Errorf("Unexpected report of gt2: %+v", gt2)
+	}
+
+	preport, err := BuildProviderReport(repo, &p, period, now)
+	if err != nil || len(preport.Agreements) != 1 {
+		t.Errorf("Unexpected provider report: %+v (%v)", preport, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteCSV(buf, *report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("Unexpected CSV: %v (%v)", records, err)
+	}
+	if r := records[1]; r[0] != "a01" || r[5] != "gt1" || r[11] != "95.000" || r[14] != "discount 5 %" {
+		t.Errorf("Unexpected CSV record: %v", r)
+	}
+}
+
+func stats(gt string, day time.Time, evaluated, failed, violations int) model.DailyStats {
+	s := model.NewDailyStats("a01", gt, day)
+	s.Evaluated = evaluated
+	s.Failed = failed
+	s.Violations = violations
+	return s
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
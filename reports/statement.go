@@ -0,0 +1,223 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reports
+
+import (
+	"SLALite/model"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// Statement is the formal SLA statement of an agreement in a period: the
+// agreement details, the compliance report and the timeline of violations.
+type Statement struct {
+	Agreement  model.Agreement
+	Report     AgreementReport
+	Violations model.Violations
+	Generated  time.Time
+}
+
+// BuildStatement builds the statement of an agreement in a period
+func BuildStatement(repo model.IRepository, a *model.Agreement, period Period, now time.Time) (*Statement, error) {
+	report, err := BuildAgreementReport(repo, a, period, now)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := repo.GetViolationsByAgreement(a.Id, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	return &Statement{
+		Agreement:  *a,
+		Report:     *report,
+		Violations: violations,
+		Generated:  now,
+	}, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"datetime": formatTime,
+	"duration": formatDuration,
+	"percent":  formatPercent,
+	"values":   formatValues,
+	"penalty":  formatPenaltyDef,
+	"owed":     formatPenalty,
+}
+
+var statementTemplate = template.Must(template.New("statement").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>SLA statement {{.Agreement.Name}} {{.Report.Period.Name}}</title>
+<style>
+body { font-family: sans-serif; font-size: 10pt; margin: 2em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 4px 6px; text-align: left; }
+th { background: #eee; }
+.violated { color: #b00; }
+</style>
+</head>
+<body>
+<h1>SLA statement</h1>
+<p>Agreement <b>{{.Agreement.Name}}</b> ({{.Agreement.Id}})<br>
+Period {{.Report.Period.Name}}: {{datetime .Report.Period.From}} - {{datetime .Report.Period.To}}<br>
+Generated {{datetime .Generated}}</p>
+
+<h2>Parties</h2>
+<table>
+<tr><th>Provider</th><td>{{.Agreement.Details.Provider.Name}} ({{.Agreement.Details.Provider.Id}})</td></tr>
+<tr><th>Client</th><td>{{.Agreement.Details.Client.Name}} ({{.Agreement.Details.Client.Id}})</td></tr>
+<tr><th>Creation</th><td>{{datetime .Agreement.Details.Creation}}</td></tr>
+{{- with .Agreement.Details.Expiration}}
+<tr><th>Expiration</th><td>{{datetime .}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Guarantees</h2>
+<table>
+<tr><th>Guarantee</th><th>Constraint</th><th>Penalties</th></tr>
+{{- range .Agreement.Details.Guarantees}}
+<tr><td>{{.Name}}</td><td>{{.Constraint}}</td><td>{{range .Penalties}}{{penalty .}}<br>{{end}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Compliance</h2>
+<table>
+<tr><th>Guarantee</th><th>Evaluated</th><th>Failed</th><th>Excluded</th><th>Compliance</th><th>Violations</th><th>Incidents</th><th>Incident duration</th><th>No data</th><th>Errors</th></tr>
+{{- range .Report.Guarantees}}
+<tr{{if .Violations}} class="violated"{{end}}><td>{{.Guarantee}}</td><td>{{.Evaluated}}</td><td>{{.Failed}}</td><td>{{.Excluded}}</td><td>{{percent .Compliance}}</td><td>{{.Violations}}</td><td>{{.Incidents}}</td><td>{{duration .IncidentDuration}}</td><td>{{.NoData}}</td><td>{{.Errors}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Penalties</h2>
+<table>
+<tr><th>Guarantee</th><th>Penalty</th><th>Violations</th><th>Owed</th></tr>
+{{- range .Report.Guarantees}}{{$gt := .Guarantee}}
+{{- range .Penalties}}
+<tr><td>{{$gt}}</td><td>{{.Type}}</td><td>{{.Count}}</td><td>{{owed .}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+
+<h2>Violations</h2>
+{{- if .Violations}}
+<table>
+<tr><th>Date</th><th>Guarantee</th><th>Values</th></tr>
+{{- range .Violations}}
+<tr><td>{{datetime .Datetime}}</td><td>{{.Guarantee}}</td><td>{{if .NoData}}No monitoring data{{else}}{{values .Values}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No violations in the period.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the statement as an HTML document
+func WriteHTML(w io.Writer, s *Statement) error {
+	return statementTemplate.Execute(w, s)
+}
+
+// WritePDF writes the statement as a PDF document
+func WritePDF(w io.Writer, s *Statement) error {
+	doc := newPDFDocument()
+	a := s.Agreement
+	r := s.Report
+
+	doc.heading("SLA statement")
+	doc.text(fmt.Sprintf("Agreement %s (%s)", a.Name, a.Id))
+	doc.text(fmt.Sprintf("Period %s: %s - %s", r.Period.Name, formatTime(r.Period.From), formatTime(r.Period.To)))
+	doc.text("Generated " + formatTime(s.Generated))
+
+	doc.heading("Parties")
+	doc.text(fmt.Sprintf("Provider: %s (%s)", a.Details.Provider.Name, a.Details.Provider.Id))
+	doc.text(fmt.Sprintf("Client: %s (%s)", a.Details.Client.Name, a.Details.Client.Id))
+	doc.text("Creation: " + formatTime(a.Details.Creation))
+	if a.Details.Expiration != nil {
+		doc.text("Expiration: " + formatTime(*a.Details.Expiration))
+	}
+
+	doc.heading("Guarantees")
+	for _, gt := range a.Details.Guarantees {
+		doc.text(fmt.Sprintf("%s: %s", gt.Name, gt.Constraint))
+		for _, p := range gt.Penalties {
+			doc.text("    Penalty: " + formatPenaltyDef(p))
+		}
+	}
+
+	doc.heading("Compliance")
+	for _, gt := range r.Guarantees {
+		doc.text(fmt.Sprintf("%s: %s compliance (%d of %d failed). %d violations, %d incidents (%s). No data: %d. Errors: %d",
+			gt.Guarantee, formatPercent(gt.Compliance), gt.Failed, gt.Evaluated, gt.Violations,
+			gt.Incidents, formatDuration(gt.IncidentDuration), gt.NoData, gt.Errors))
+		if gt.Excluded > 0 {
+			doc.text(fmt.Sprintf("    Excluded in maintenance windows: %d", gt.Excluded))
+		}
+	}
+
+	doc.heading("Penalties")
+	for _, gt := range r.Guarantees {
+		for _, p := range gt.Penalties {
+			doc.text(fmt.Sprintf("%s: %s, %d violations. Owed: %s", gt.Guarantee, p.Type, p.Count, formatPenalty(p)))
+		}
+	}
+
+	doc.heading("Violations")
+	if len(s.Violations) == 0 {
+		doc.text("No violations in the period.")
+	}
+	for _, v := range s.Violations {
+		values := formatValues(v.Values)
+		if v.NoData {
+			values = "No monitoring data"
+		}
+		doc.text(fmt.Sprintf("%s  %s  %s", formatTime(v.Datetime), v.Guarantee, values))
+	}
+	return doc.write(w)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+func formatDuration(seconds float64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func formatPercent(p float64) string {
+	return fmt.Sprintf("%.3f%%", p)
+}
+
+func formatValues(values []model.MetricValue) string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, fmt.Sprintf("%s=%v", v.Key, v.Value))
+	}
+	return strings.Join(result, ", ")
+}
+
+func formatPenaltyDef(p model.PenaltyDef) string {
+	return fmt.Sprintf("%s %s %s per violation", p.Type, p.Value, p.Unit)
+}
+
+func formatPenalty(p Penalty) string {
+	return fmt.Sprintf("%g %s", p.Total, p.Unit)
+}
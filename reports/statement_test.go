@@ -0,0 +1,108 @@
+package reports
+
+import (
+	"SLALite/model"
+	"SLALite/repositories/memrepository"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatement(t *testing.T) {
+	repo, _ := memrepository.New(nil)
+	a := model.Agreement{
+		Id:   "a01",
+		Name: "Agreement <01>",
+		Details: model.Details{
+			Provider: model.Provider{Id: "p01", Name: "Provider (01)"},
+			Client:   model.Client{Id: "c01", Name: "Client 01"},
+			Guarantees: []model.Guarantee{
+				{
+					Name:       "gt1",
+					Constraint: "m > 10",
+					Penalties:  []model.PenaltyDef{{Type: "discount", Value: "10", Unit: "EUR"}},
+				},
+			},
+		},
+	}
+	repo.CreateAgreement(&a)
+	for i := 0; i < 150; i++ {
+		repo.CreateViolation(&model.Violation{
+			Id:          strconv.Itoa(i),
+			AgreementId: a.Id,
+			Guarantee:   "gt1",
+			Datetime:    date(2026, 9, 1).Add(time.Duration(i) * time.Hour),
+			Constraint:  "m > 10",
+			Values:      []model.MetricValue{{Key: "m", Value: i % 10}},
+		})
+	}
+	s := model.NewDailyStats(a.Id, "gt1", date(2026, 9, 1))
+	s.Evaluated, s.Failed, s.Violations = 1000, 150, 150
+	repo.SaveDailyStats(&s)
+
+	period, _ := ParsePeriod("2026-09")
+	statement, err := BuildStatement(repo, &a, period, date(2026, 10, 1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(statement.Violations) != 150 {
+		t.Errorf("Expected 150 violations. Actual: %d", len(statement.Violations))
+	}
+
+	t.Run("HTML", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		if err := WriteHTML(buf, statement); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		html := buf.String()
+		for _, expected := range []string{
+			"Agreement &lt;01&gt;",
+			"discount 10 EUR per violation",
+			"85.000%",
+			"1500 EUR",
+			"2026-09-01 05:00:00 UTC",
+		} {
+			if !strings.Contains(html, expected) {
+				t.Errorf("Expected %q in statement", expected)
+			}
+		}
+	})
+	t.Run("PDF", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		if err := WritePDF(buf, statement); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		pdf := buf.String()
+		if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+			t.Fatalf("Unexpected PDF: %s", pdf)
+		}
+		if !strings.Contains(pdf, `(Provider: Provider \(01\) \(p01\)) '`) {
+			t.Errorf("Expected escaped provider in PDF")
+		}
+		if !strings.Contains(pdf, "/Count 3") {
+			t.Errorf("Expected 3 pages in PDF")
+		}
+		checkXref(t, pdf)
+	})
+}
+
+// checkXref checks that the offsets in the xref table point to their objects
+func checkXref(t *testing.T, pdf string) {
+	start, _ := strconv.Atoi(regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(pdf)[1])
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllStringSubmatch(pdf[start:], -1)
+	for i, offset := range offsets {
+		n, _ := strconv.Atoi(offset[1])
+		if expected := strconv.Itoa(i+1) + " 0 obj"; !strings.HasPrefix(pdf[n:], expected) {
+			t.Errorf("Wrong offset of object %d: %d", i+1, n)
+		}
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	if s := pdfEscape(`a(b)\c ñ €`); s != `a\(b\)\\c \361 ?` {
+		t.Errorf("Unexpected escaped string: %s", s)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lifecycle provides a repository decorator that publishes the lifecycle
+// events of the agreements (creation, state transitions and deletion) after
+// they have been successfully handled by the decorated repository. A transition
+// to TERMINATED of an agreement that the assessment found expired (see
+// model.Assessment.Expired) is an AgreementExpired event, at the assessment time.
+//
+// Usage:
+//
+//	repo, err := mongodb.New(config)
+//	repo, _ = validation.New(repo, validator)
+//	repo, _ = lifecycle.New(repo, bus)
+package lifecycle
+
+import (
+	"SLALite/events"
+	"SLALite/model"
+)
+
+type repository struct {
+	model.IRepository
+	publisher events.Publisher
+}
+
+// New returns an IRepository that publishes agreement lifecycle events to publisher.
+func New(backend model.IRepository, publisher events.Publisher) (model.IRepository, error) {
+	return repository{
+		IRepository: backend,
+		publisher:   publisher,
+	}, nil
+}
+
+// CreateAgreement persists an agreement and publishes an AgreementCreated event.
+func (r repository) CreateAgreement(agreement *model.Agreement) (*model.Agreement, error) {
+	created, err := r.IRepository.CreateAgreement(agreement)
+	if err == nil {
+		r.publisher.Publish(events.NewAgreementEvent(events.AgreementCreated, created))
+	}
+	return created, err
+}
+
+// UpdateAgreement updates an agreement, publishing an event if the state has changed.
+func (r repository) UpdateAgreement(agreement *model.Agreement) (*model.Agreement, error) {
+	from := r.currentState(agreement.Id)
+
+	updated, err := r.IRepository.UpdateAgreement(agreement)
+	if err == nil {
+		r.publishTransition(updated, from)
+	}
+	return updated, err
+}
+
+// UpdateAgreementState changes the state of an agreement, publishing an event if the state has changed.
+func (r repository) UpdateAgreementState(id string, newState model.State) (*model.Agreement, error) {
+	from := r.currentState(id)
+
+	updated, err := r.IRepository.UpdateAgreementState(id, newState)
+	if err == nil {
+		r.publishTransition(updated, from)
+	}
+	return updated, err
+}
+
+// DeleteAgreement deletes an agreement and publishes an AgreementDeleted event.
+func (r repository) DeleteAgreement(agreement *model.Agreement) error {
+	deleted, errGet := r.IRepository.GetAgreement(agreement.Id)
+
+	err := r.IRepository.DeleteAgreement(agreement)
+	if err == nil {
+		if errGet != nil {
+			deleted = agreement
+		}
+		r.publisher.Publish(events.NewAgreementEvent(events.AgreementDeleted, deleted))
+	}
+	return err
+}
+
+func (r repository) currentState(id string) model.State {
+	current, err := r.IRepository.GetAgreement(id)
+	if err != nil || current == nil {
+		return ""
+	}
+	return current.State
+}
+
+func (r repository) publishTransition(a *model.Agreement, from model.State) {
+	if a == nil || from == "" {
+		return
+	}
+	if e, ok := events.NewTransitionEvent(a, from, a.State); ok {
+		if e.Type == events.AgreementTerminated && a.Assessment.Expired != nil {
+			e.Type = events.AgreementExpired
+			e.Datetime = *a.Assessment.Expired
+		}
+		r.publisher.Publish(e)
+	}
+}
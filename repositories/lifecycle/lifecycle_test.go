@@ -0,0 +1,119 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle
+
+import (
+	"SLALite/events"
+	"SLALite/model"
+	"SLALite/repositories/memrepository"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	received []events.Event
+}
+
+func (r *recorder) Publish(e events.Event) {
+	r.received = append(r.received, e)
+}
+
+func (r *recorder) types() []events.Type {
+	result := make([]events.Type, 0, len(r.received))
+	for _, e := range r.received {
+		result = append(result, e.Type)
+	}
+	return result
+}
+
+func TestLifecycle(t *testing.T) {
+	backend, _ := memrepository.New(nil)
+	rec := &recorder{}
+	repo, _ := New(backend, rec)
+
+	a := model.Agreement{Id: "a01", Name: "a01", State: model.STOPPED}
+
+	repo.CreateAgreement(&a)
+	repo.UpdateAgreementState(a.Id, model.STARTED)
+	repo.UpdateAgreementState(a.Id, model.STARTED)
+
+	a.State = model.TERMINATED
+	repo.UpdateAgreement(&a)
+	repo.DeleteAgreement(&a)
+
+	/* errors must not publish events */
+	repo.DeleteAgreement(&a)
+	repo.UpdateAgreementState(a.Id, model.STOPPED)
+	repo.CreateAgreement(&model.Agreement{Id: "a02"})
+	repo.CreateAgreement(&model.Agreement{Id: "a02"})
+
+	expected := []events.Type{
+		events.AgreementCreated,
+		events.AgreementStarted,
+		events.AgreementTerminated,
+		events.AgreementDeleted,
+		events.AgreementCreated,
+	}
+	actual := rec.types()
+	if len(expected) != len(actual) {
+		t.Fatalf("Expected: %v; Actual: %v", expected, actual)
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Errorf("Expected: %v; Actual: %v", expected, actual)
+			break
+		}
+	}
+
+	started := rec.received[1]
+	if started.FromState != model.STOPPED || started.ToState != model.STARTED {
+		t.Errorf("Unexpected transition: %v", started)
+	}
+	if deleted := rec.received[3]; deleted.Agreement == nil || deleted.Agreement.State != model.TERMINATED {
+		t.Errorf("Unexpected deleted agreement: %v", deleted.Agreement)
+	}
+}
+
+func TestLifecycleExpired(t *testing.T) {
+	backend, _ := memrepository.New(nil)
+	rec := &recorder{}
+	repo, _ := New(backend, rec)
+
+	expiration := time.Now().Add(-time.Hour)
+	a := model.Agreement{Id: "a01", Name: "a01", State: model.STARTED, Details: model.Details{Expiration: &expiration}}
+	repo.CreateAgreement(&a)
+	/* a manual termination after the expiration date is not an expiration */
+	a.State = model.TERMINATED
+	repo.UpdateAgreement(&a)
+
+	b := model.Agreement{Id: "a02", Name: "a02", State: model.STARTED, Details: model.Details{Expiration: &expiration}}
+	repo.CreateAgreement(&b)
+	assessed := expiration.Add(time.Minute)
+	b.State = model.TERMINATED
+	b.Assessment.Expired = &assessed
+	repo.UpdateAgreement(&b)
+
+	expected := []events.Type{events.AgreementCreated, events.AgreementTerminated,
+		events.AgreementCreated, events.AgreementExpired}
+	if actual := rec.types(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %v. Actual: %v", expected, actual)
+	}
+	if e := rec.received[3]; !e.Datetime.Equal(assessed) {
+		t.Errorf("Expected the expired event at the assessment time. Actual: %v", e.Datetime)
+	}
+}
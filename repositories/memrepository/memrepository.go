@@ -22,10 +22,24 @@ package memrepository
 
 import (
 	"SLALite/model"
+	"SLALite/registry"
+	"sort"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+const (
+	// Name is the unique identifier of this repository
+	Name = "memory"
+)
+
+func init() {
+	registry.RegisterRepository(Name, func(config *viper.Viper) (model.IRepository, error) {
+		return New(config)
+	})
+}
+
 // MemRepository is a repository in memory
 type MemRepository struct {
 	providers  map[string]model.Provider
@@ -33,6 +47,11 @@ type MemRepository struct {
 	violations map[string]model.Violation
 	penalties  map[string]model.Penalty
 	templates  map[string]model.Template
+	incidents  map[string]model.Incident
+	replays    map[string]model.Replay
+	stats      map[string]model.DailyStats
+	history    map[string]model.EvaluationRecord
+	windows    map[string]model.MaintenanceWindow
 }
 
 // NewMemRepository creates a MemRepository with an initial state set by the parameters
@@ -62,6 +81,11 @@ func NewMemRepository(providers map[string]model.Provider, agreements map[string
 		violations: violations,
 		penalties:  penalties,
 		templates:  templates,
+		incidents:  make(map[string]model.Incident),
+		replays:    make(map[string]model.Replay),
+		stats:      make(map[string]model.DailyStats),
+		history:    make(map[string]model.EvaluationRecord),
+		windows:    make(map[string]model.MaintenanceWindow),
 	}
 	return r
 }
@@ -294,6 +318,20 @@ func (r MemRepository) GetViolation(id string) (*model.Violation, error) {
 	return &item, err
 }
 
+/*
+DeleteViolation deletes from the repository the Violation whose id is v.Id.
+
+error != nil on error;
+error is ErrNotFound if the Violation does not exist.
+*/
+func (r MemRepository) DeleteViolation(v *model.Violation) error {
+	if _, ok := r.violations[v.Id]; !ok {
+		return model.ErrNotFound
+	}
+	delete(r.violations, v.Id)
+	return nil
+}
+
 /*
 UpdateAgreementState transits the state of the agreement
 */
@@ -370,3 +408,314 @@ func (r MemRepository) CreateTemplate(template *model.Template) (*model.Template
 	}
 	return template, err
 }
+
+/*
+CreateIncident stores a new Incident.
+
+error != nil on error;
+error is ErrAlreadyExist if the Incident already exists
+*/
+func (r MemRepository) CreateIncident(i *model.Incident) (*model.Incident, error) {
+	var err error
+
+	if _, ok := r.incidents[i.Id]; ok {
+		err = model.ErrAlreadyExist
+	} else {
+		r.incidents[i.Id] = *i
+	}
+	return i, err
+}
+
+/*
+UpdateIncident updates the information of an already saved incident.
+
+error != nil on error;
+error is ErrNotFound if the Incident does not exist
+*/
+func (r MemRepository) UpdateIncident(i *model.Incident) (*model.Incident, error) {
+	var err error
+
+	if _, ok := r.incidents[i.Id]; !ok {
+		err = model.ErrNotFound
+	} else {
+		r.incidents[i.Id] = *i
+	}
+	return i, err
+}
+
+/*
+GetIncident returns the Incident identified by id.
+
+error != nil on error;
+error is ErrNotFound if the Incident is not found
+*/
+func (r MemRepository) GetIncident(id string) (*model.Incident, error) {
+	var err error
+
+	item, ok := r.incidents[id]
+	if !ok {
+		err = model.ErrNotFound
+	}
+	return &item, err
+}
+
+/*
+GetIncidentsByAgreement returns the incidents of an agreement sorted by start time.
+
+If states is not empty, only the incidents in one of the states are returned.
+*/
+func (r MemRepository) GetIncidentsByAgreement(agreementID string, states ...model.IncidentState) (model.Incidents, error) {
+	result := make(model.Incidents, 0)
+
+	for _, i := range r.incidents {
+		if i.AgreementId == agreementID && matchesIncidentState(i, states) {
+			result = append(result, i)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Start.Before(result[b].Start)
+	})
+	return result, nil
+}
+
+/*
+CreateReplay stores a new Replay.
+
+error != nil on error;
+error is ErrAlreadyExist if the Replay already exists
+*/
+func (r MemRepository) CreateReplay(replay *model.Replay) (*model.Replay, error) {
+	var err error
+
+	if _, ok := r.replays[replay.Id]; ok {
+		err = model.ErrAlreadyExist
+	} else {
+		r.replays[replay.Id] = *replay
+	}
+	return replay, err
+}
+
+/*
+UpdateReplay updates the information of an already saved replay.
+
+error != nil on error;
+error is ErrNotFound if the Replay does not exist
+*/
+func (r MemRepository) UpdateReplay(replay *model.Replay) (*model.Replay, error) {
+	var err error
+
+	if _, ok := r.replays[replay.Id]; !ok {
+		err = model.ErrNotFound
+	} else {
+		r.replays[replay.Id] = *replay
+	}
+	return replay, err
+}
+
+/*
+GetReplay returns the Replay identified by id.
+
+error != nil on error;
+error is ErrNotFound if the Replay is not found
+*/
+func (r MemRepository) GetReplay(id string) (*model.Replay, error) {
+	var err error
+
+	item, ok := r.replays[id]
+	if !ok {
+		err = model.ErrNotFound
+	}
+	return &item, err
+}
+
+/*
+GetReplaysByAgreement returns the replays of an agreement sorted by creation time.
+*/
+func (r MemRepository) GetReplaysByAgreement(agreementID string) (model.Replays, error) {
+	result := make(model.Replays, 0)
+
+	for _, replay := range r.replays {
+		if replay.AgreementId == agreementID {
+			result = append(result, replay)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Created.Before(result[b].Created)
+	})
+	return result, nil
+}
+
+/*
+GetViolationsByAgreement returns the violations of an agreement raised in the
+interval [from, to), sorted by time.
+*/
+func (r MemRepository) GetViolationsByAgreement(agreementID string, from time.Time, to time.Time) (model.Violations, error) {
+	result := make(model.Violations, 0)
+
+	for _, v := range r.violations {
+		if v.AgreementId == agreementID && inInterval(v.Datetime, from, to) {
+			result = append(result, v)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Datetime.Before(result[b].Datetime)
+	})
+	return result, nil
+}
+
+/*
+SaveDailyStats stores daily stats, replacing the stats with the same id.
+*/
+func (r MemRepository) SaveDailyStats(s *model.DailyStats) (*model.DailyStats, error) {
+	r.stats[s.Id] = *s
+	return s, nil
+}
+
+/*
+GetDailyStats returns the daily stats of an agreement whose date is in the
+interval [from, to), sorted by date.
+*/
+func (r MemRepository) GetDailyStats(agreementID string, from time.Time, to time.Time) ([]model.DailyStats, error) {
+	result := make([]model.DailyStats, 0)
+
+	for _, s := range r.stats {
+		if s.AgreementId == agreementID && inInterval(s.Date, from, to) {
+			result = append(result, s)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Date.Before(result[b].Date)
+	})
+	return result, nil
+}
+
+/*
+SaveEvaluationRecord stores an evaluation record, replacing the record with the same id.
+*/
+func (r MemRepository) SaveEvaluationRecord(e *model.EvaluationRecord) (*model.EvaluationRecord, error) {
+	r.history[e.Id] = *e
+	return e, nil
+}
+
+/*
+GetEvaluationHistory returns the evaluation records of a guarantee term of an
+agreement whose datetime is in the interval [from, to), sorted by datetime.
+*/
+func (r MemRepository) GetEvaluationHistory(agreementID string, guarantee string, from time.Time, to time.Time) ([]model.EvaluationRecord, error) {
+	result := make([]model.EvaluationRecord, 0)
+
+	for _, e := range r.history {
+		if e.AgreementId == agreementID && e.Guarantee == guarantee && inInterval(e.Datetime, from, to) {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Datetime.Before(result[b].Datetime)
+	})
+	return result, nil
+}
+
+/*
+DeleteEvaluationHistory removes the evaluation records of an agreement
+whose datetime is before the given time.
+*/
+func (r MemRepository) DeleteEvaluationHistory(agreementID string, before time.Time) error {
+	for id, e := range r.history {
+		if e.AgreementId == agreementID && e.Datetime.Before(before) {
+			delete(r.history, id)
+		}
+	}
+	return nil
+}
+
+/*
+GetAllMaintenanceWindows returns the list of maintenance windows.
+*/
+func (r MemRepository) GetAllMaintenanceWindows() (model.MaintenanceWindows, error) {
+	result := make(model.MaintenanceWindows, 0, len(r.windows))
+
+	for _, value := range r.windows {
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+/*
+GetMaintenanceWindow returns the MaintenanceWindow identified by id.
+
+error != nil on error;
+error is ErrNotFound if the MaintenanceWindow is not found
+*/
+func (r MemRepository) GetMaintenanceWindow(id string) (*model.MaintenanceWindow, error) {
+	var err error
+
+	item, ok := r.windows[id]
+	if !ok {
+		err = model.ErrNotFound
+	}
+	return &item, err
+}
+
+/*
+CreateMaintenanceWindow stores a new MaintenanceWindow.
+
+error != nil on error;
+error is ErrAlreadyExist if the MaintenanceWindow already exists
+*/
+func (r MemRepository) CreateMaintenanceWindow(w *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+	var err error
+
+	if _, ok := r.windows[w.Id]; ok {
+		err = model.ErrAlreadyExist
+	} else {
+		r.windows[w.Id] = *w
+	}
+	return w, err
+}
+
+/*
+UpdateMaintenanceWindow replaces an already saved MaintenanceWindow.
+
+error != nil on error;
+error is ErrNotFound if the MaintenanceWindow does not exist
+*/
+func (r MemRepository) UpdateMaintenanceWindow(w *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+	var err error
+
+	if _, ok := r.windows[w.Id]; !ok {
+		err = model.ErrNotFound
+	} else {
+		r.windows[w.Id] = *w
+	}
+	return w, err
+}
+
+/*
+DeleteMaintenanceWindow deletes the MaintenanceWindow whose id is w.Id.
+
+error != nil on error;
+error is ErrNotFound if the MaintenanceWindow does not exist
+*/
+func (r MemRepository) DeleteMaintenanceWindow(w *model.MaintenanceWindow) error {
+	if _, ok := r.windows[w.Id]; !ok {
+		return model.ErrNotFound
+	}
+	delete(r.windows, w.Id)
+	return nil
+}
+
+func inInterval(t time.Time, from time.Time, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+func matchesIncidentState(i model.Incident, states []model.IncidentState) bool {
+	if len(states) == 0 {
+		return true
+	}
+	for _, state := range states {
+		if i.State == state {
+			return true
+		}
+	}
+	return false
+}
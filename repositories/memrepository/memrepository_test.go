@@ -79,6 +79,31 @@ func TestRepository(t *testing.T) {
 
 	t.Run("GetViolation", ctx.TestGetViolation)
 	t.Run("GetViolationNotExists", ctx.TestGetViolationNotExists)
+	t.Run("GetViolationsByAgreement", ctx.TestGetViolationsByAgreement)
+	t.Run("DeleteViolation", ctx.TestDeleteViolation)
+
+	/* Incidents */
+	t.Run("CreateIncident", ctx.TestCreateIncident)
+	t.Run("CreateIncidentExists", ctx.TestCreateIncidentExists)
+	t.Run("GetIncidentNotExists", ctx.TestGetIncidentNotExists)
+	t.Run("UpdateIncident", ctx.TestUpdateIncident)
+	t.Run("UpdateIncidentNotExists", ctx.TestUpdateIncidentNotExists)
+	t.Run("GetIncidentsByAgreement", ctx.TestGetIncidentsByAgreement)
+
+	/* Replays */
+	t.Run("CreateReplay", ctx.TestCreateReplay)
+	t.Run("CreateReplayExists", ctx.TestCreateReplayExists)
+	t.Run("UpdateReplay", ctx.TestUpdateReplay)
+	t.Run("UpdateReplayNotExists", ctx.TestUpdateReplayNotExists)
+	t.Run("GetReplayNotExists", ctx.TestGetReplayNotExists)
+	t.Run("GetReplaysByAgreement", ctx.TestGetReplaysByAgreement)
+
+	/* Stats */
+	t.Run("SaveDailyStats", ctx.TestSaveDailyStats)
+	t.Run("EvaluationHistory", ctx.TestEvaluationHistory)
+
+	/* Maintenance windows */
+	t.Run("MaintenanceWindows", ctx.TestMaintenanceWindows)
 
 	/* Templates */
 	t.Run("CreateTemplate", ctx.TestCreateTemplate)
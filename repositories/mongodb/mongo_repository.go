@@ -21,6 +21,8 @@ package mongodb
 
 import (
 	"SLALite/model"
+	"SLALite/registry"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -38,6 +40,11 @@ const (
 	agreementCollectionName string = "Agreements"
 	templateCollectionName  string = "Templates"
 	violationCollectionName string = "Violations"
+	incidentCollectionName  string = "Incidents"
+	replayCollectionName    string = "Replays"
+	statsCollectionName     string = "DailyStats"
+	historyCollectionName   string = "EvaluationHistory"
+	windowCollectionName    string = "MaintenanceWindows"
 
 	mongoConfigName string = "mongodb.yml"
 
@@ -46,6 +53,15 @@ const (
 	clearOnBoot   string = "clear_on_boot"
 )
 
+func init() {
+	registry.RegisterRepository(Name, func(config *viper.Viper) (model.IRepository, error) {
+		return New(config)
+	},
+		registry.Property{Name: connectionURL, Default: defaultURL, Description: "MongoDB host"},
+		registry.Property{Name: mongoDatabase, Default: repositoryDbName, Description: "MongoDB database name"},
+		registry.Property{Name: clearOnBoot, Default: false, Description: "Clears the database on startup"})
+}
+
 //Repository contains the repository persistence implementation based on MongoDB
 type Repository struct {
 	session  *mgo.Session
@@ -123,6 +139,11 @@ func (r Repository) getList(collection string, query, result interface{}) (inter
 	return result, err
 }
 
+func (r Repository) getSortedList(collection string, query interface{}, sort string, result interface{}) (interface{}, error) {
+	err := r.database.C(collection).Find(query).Sort(sort).All(result)
+	return result, err
+}
+
 func (r Repository) getAll(collection string, result interface{}) (interface{}, error) {
 	return r.getList(collection, bson.M{}, result)
 }
@@ -291,6 +312,31 @@ func (r Repository) GetViolation(id string) (*model.Violation, error) {
 	return res.(*model.Violation), err
 }
 
+/*
+DeleteViolation deletes from the repository the Violation whose id is v.Id.
+
+error != nil on error;
+error is ErrNotFound if the Violation does not exist.
+*/
+func (r Repository) DeleteViolation(v *model.Violation) error {
+	return r.delete(violationCollectionName, v.Id)
+}
+
+/*
+GetViolationsByAgreement returns the violations of an agreement raised in the
+interval [from, to), sorted by time.
+*/
+func (r Repository) GetViolationsByAgreement(agreementID string, from time.Time, to time.Time) (model.Violations, error) {
+	output := new(model.Violations)
+
+	query := bson.M{
+		"agreementid": agreementID,
+		"datetime":    bson.M{"$gte": from, "$lt": to},
+	}
+	result, err := r.getSortedList(violationCollectionName, query, "datetime", output)
+	return *((result).(*model.Violations)), err
+}
+
 /*
 UpdateAgreementState transits the state of the agreement
 */
@@ -338,3 +384,207 @@ func (r Repository) CreateTemplate(template *model.Template) (*model.Template, e
 	res, err := r.create(templateCollectionName, template)
 	return res.(*model.Template), err
 }
+
+/*
+CreateIncident stores a new Incident.
+
+error != nil on error;
+error is ErrAlreadyExist if the Incident already exists
+*/
+func (r Repository) CreateIncident(i *model.Incident) (*model.Incident, error) {
+	res, err := r.create(incidentCollectionName, i)
+	return res.(*model.Incident), err
+}
+
+/*
+UpdateIncident updates the information of an already saved incident.
+
+error != nil on error;
+error is ErrNotFound if the Incident does not exist
+*/
+func (r Repository) UpdateIncident(i *model.Incident) (*model.Incident, error) {
+	err := r.update(incidentCollectionName, i.Id, i)
+	return i, err
+}
+
+/*
+GetIncident returns the Incident identified by id.
+
+error != nil on error;
+error is ErrNotFound if the Incident is not found
+*/
+func (r Repository) GetIncident(id string) (*model.Incident, error) {
+	res, err := r.get(incidentCollectionName, id, new(model.Incident))
+	return res.(*model.Incident), err
+}
+
+/*
+GetIncidentsByAgreement returns the incidents of an agreement sorted by start time.
+
+If states is not empty, only the incidents in one of the states are returned.
+*/
+func (r Repository) GetIncidentsByAgreement(agreementID string, states ...model.IncidentState) (model.Incidents, error) {
+	output := new(model.Incidents)
+
+	query := bson.M{"agreementid": agreementID}
+	if len(states) > 0 {
+		query["state"] = bson.M{"$in": states}
+	}
+	result, err := r.getSortedList(incidentCollectionName, query, "start", output)
+	return *((result).(*model.Incidents)), err
+}
+
+/*
+CreateReplay stores a new Replay.
+
+error != nil on error;
+error is ErrAlreadyExist if the Replay already exists
+*/
+func (r Repository) CreateReplay(replay *model.Replay) (*model.Replay, error) {
+	res, err := r.create(replayCollectionName, replay)
+	return res.(*model.Replay), err
+}
+
+/*
+UpdateReplay updates the information of an already saved replay.
+
+error != nil on error;
+error is ErrNotFound if the Replay does not exist
+*/
+func (r Repository) UpdateReplay(replay *model.Replay) (*model.Replay, error) {
+	err := r.update(replayCollectionName, replay.Id, replay)
+	return replay, err
+}
+
+/*
+GetReplay returns the Replay identified by id.
+
+error != nil on error;
+error is ErrNotFound if the Replay is not found
+*/
+func (r Repository) GetReplay(id string) (*model.Replay, error) {
+	res, err := r.get(replayCollectionName, id, new(model.Replay))
+	return res.(*model.Replay), err
+}
+
+/*
+GetReplaysByAgreement returns the replays of an agreement sorted by creation time.
+*/
+func (r Repository) GetReplaysByAgreement(agreementID string) (model.Replays, error) {
+	output := new(model.Replays)
+
+	query := bson.M{"agreementid": agreementID}
+	result, err := r.getSortedList(replayCollectionName, query, "created", output)
+	return *((result).(*model.Replays)), err
+}
+
+/*
+SaveDailyStats stores daily stats, replacing the stats with the same id.
+*/
+func (r Repository) SaveDailyStats(s *model.DailyStats) (*model.DailyStats, error) {
+	_, err := r.database.C(statsCollectionName).UpsertId(s.Id, s)
+	return s, err
+}
+
+/*
+GetDailyStats returns the daily stats of an agreement whose date is in the
+interval [from, to), sorted by date.
+*/
+func (r Repository) GetDailyStats(agreementID string, from time.Time, to time.Time) ([]model.DailyStats, error) {
+	output := new([]model.DailyStats)
+
+	query := bson.M{
+		"agreementid": agreementID,
+		"date":        bson.M{"$gte": from, "$lt": to},
+	}
+	result, err := r.getSortedList(statsCollectionName, query, "date", output)
+	return *((result).(*[]model.DailyStats)), err
+}
+
+/*
+SaveEvaluationRecord stores an evaluation record, replacing the record with the same id.
+*/
+func (r Repository) SaveEvaluationRecord(e *model.EvaluationRecord) (*model.EvaluationRecord, error) {
+	_, err := r.database.C(historyCollectionName).UpsertId(e.Id, e)
+	return e, err
+}
+
+/*
+GetEvaluationHistory returns the evaluation records of a guarantee term of an
+agreement whose datetime is in the interval [from, to), sorted by datetime.
+*/
+func (r Repository) GetEvaluationHistory(agreementID string, guarantee string, from time.Time, to time.Time) ([]model.EvaluationRecord, error) {
+	output := new([]model.EvaluationRecord)
+
+	query := bson.M{
+		"agreementid": agreementID,
+		"guarantee":   guarantee,
+		"datetime":    bson.M{"$gte": from, "$lt": to},
+	}
+	result, err := r.getSortedList(historyCollectionName, query, "datetime", output)
+	return *((result).(*[]model.EvaluationRecord)), err
+}
+
+/*
+DeleteEvaluationHistory removes the evaluation records of an agreement
+whose datetime is before the given time.
+*/
+func (r Repository) DeleteEvaluationHistory(agreementID string, before time.Time) error {
+	query := bson.M{
+		"agreementid": agreementID,
+		"datetime":    bson.M{"$lt": before},
+	}
+	_, err := r.database.C(historyCollectionName).RemoveAll(query)
+	return err
+}
+
+/*
+GetAllMaintenanceWindows returns the list of maintenance windows.
+*/
+func (r Repository) GetAllMaintenanceWindows() (model.MaintenanceWindows, error) {
+	res, err := r.getAll(windowCollectionName, new(model.MaintenanceWindows))
+	return *((res).(*model.MaintenanceWindows)), err
+}
+
+/*
+GetMaintenanceWindow returns the MaintenanceWindow identified by id.
+
+error != nil on error;
+error is ErrNotFound if the MaintenanceWindow is not found
+*/
+func (r Repository) GetMaintenanceWindow(id string) (*model.MaintenanceWindow, error) {
+	res, err := r.get(windowCollectionName, id, new(model.MaintenanceWindow))
+	return res.(*model.MaintenanceWindow), err
+}
+
+/*
+CreateMaintenanceWindow stores a new MaintenanceWindow.
+
+error != nil on error;
+error is ErrAlreadyExist if the MaintenanceWindow already exists
+*/
+func (r Repository) CreateMaintenanceWindow(w *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+	res, err := r.create(windowCollectionName, w)
+	return res.(*model.MaintenanceWindow), err
+}
+
+/*
+UpdateMaintenanceWindow replaces an already saved MaintenanceWindow.
+
+error != nil on error;
+error is ErrNotFound if the MaintenanceWindow does not exist
+*/
+func (r Repository) UpdateMaintenanceWindow(w *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+	err := r.update(windowCollectionName, w.Id, w)
+	return w, err
+}
+
+/*
+DeleteMaintenanceWindow deletes the MaintenanceWindow whose id is w.Id.
+
+error != nil on error;
+error is ErrNotFound if the MaintenanceWindow does not exist
+*/
+func (r Repository) DeleteMaintenanceWindow(w *model.MaintenanceWindow) error {
+	return r.delete(windowCollectionName, w.Id)
+}
@@ -37,6 +37,10 @@ type TestData struct {
 	V01        model.Violation
 	Vnotexists model.Violation
 	T01        model.Template
+	I01        model.Incident
+	Inotexists model.Incident
+	R01        model.Replay
+	Rnotexists model.Replay
 }
 
 // Data contains the data to be used in these tests. It can be overwritten if needed.
@@ -95,6 +99,36 @@ var Data = TestData{
 		Id:   "t01",
 		Name: "Template01",
 	},
+	I01: model.Incident{
+		Id:            "i01",
+		AgreementId:   "a01",
+		Guarantee:     "gt1",
+		Constraint:    "t < 100",
+		State:         model.OPEN,
+		Start:         time.Now(),
+		LastViolation: time.Now(),
+		Count:         1,
+	},
+	Inotexists: model.Incident{
+		Id:          "inotexists",
+		AgreementId: "a01",
+	},
+	R01: model.Replay{
+		Id:          "r01",
+		AgreementId: "a01",
+		From:        time.Now().Add(-time.Hour),
+		To:          time.Now(),
+		Step:        60,
+		Created:     time.Now(),
+		Status:      model.REPLAYRUNNING,
+		Results: map[string]model.ReplayGuarantee{
+			"gt1": model.ReplayGuarantee{Evaluated: 60, Failed: 3, Compliance: 0.95},
+		},
+	},
+	Rnotexists: model.Replay{
+		Id:          "rnotexists",
+		AgreementId: "a01",
+	},
 }
 
 // CheckSetup checks that the entities to be created on this test do not exist in the
@@ -336,6 +370,22 @@ func (r *TestContext) TestGetViolationNotExists(t *testing.T) {
 	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
 }
 
+// TestDeleteViolation executes this test
+func (r *TestContext) TestDeleteViolation(t *testing.T) {
+	v := Data.V01
+	v.Id = "vdelete"
+	_, err := r.Repo.CreateViolation(&v)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+
+	err = r.Repo.DeleteViolation(&v)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	_, err = r.Repo.GetViolation(v.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
+
+	err = r.Repo.DeleteViolation(&v)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
+}
+
 // TestCreateTemplate executes this test
 func (r *TestContext) TestCreateTemplate(t *testing.T) {
 	var tpl *model.Template
@@ -376,6 +426,215 @@ func (r *TestContext) TestGetTemplateNotExists(t *testing.T) {
 	assertEquals(t, "Expected error: %v; actual: %v", model.ErrNotFound, err)
 }
 
+// TestCreateIncident executes this test
+func (r *TestContext) TestCreateIncident(t *testing.T) {
+	Data.I01.AgreementId = Data.A01.Id
+	i, err := r.Repo.CreateIncident(&Data.I01)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	Data.I01 = *i
+
+	i, err = r.Repo.GetIncident(Data.I01.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected incident. Expected: %v; Actual: %v", Data.I01.Id, i.Id)
+}
+
+// TestCreateIncidentExists executes this test
+func (r *TestContext) TestCreateIncidentExists(t *testing.T) {
+	_, err := r.Repo.CreateIncident(&Data.I01)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrAlreadyExist, err)
+}
+
+// TestGetIncidentNotExists executes this test
+func (r *TestContext) TestGetIncidentNotExists(t *testing.T) {
+	_, err := r.Repo.GetIncident(Data.Inotexists.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
+}
+
+// TestUpdateIncident executes this test
+func (r *TestContext) TestUpdateIncident(t *testing.T) {
+	Data.I01.Close(time.Now())
+	_, err := r.Repo.UpdateIncident(&Data.I01)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+
+	i, err := r.Repo.GetIncident(Data.I01.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected state. Expected: %v; Actual: %v", model.CLOSED, i.State)
+}
+
+// TestUpdateIncidentNotExists executes this test
+func (r *TestContext) TestUpdateIncidentNotExists(t *testing.T) {
+	_, err := r.Repo.UpdateIncident(&Data.Inotexists)
+	if err == nil {
+		t.Errorf("Expected error updating non existent incident")
+	}
+}
+
+// TestGetIncidentsByAgreement executes this test
+func (r *TestContext) TestGetIncidentsByAgreement(t *testing.T) {
+	all, err := r.Repo.GetIncidentsByAgreement(Data.I01.AgreementId)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(Incidents). Expected: %d; Actual: %d", 1, len(all))
+
+	open, err := r.Repo.GetIncidentsByAgreement(Data.I01.AgreementId, model.OPEN)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(Incidents). Expected: %d; Actual: %d", 0, len(open))
+
+	closed, err := r.Repo.GetIncidentsByAgreement(Data.I01.AgreementId, model.OPEN, model.CLOSED)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(Incidents). Expected: %d; Actual: %d", 1, len(closed))
+}
+
+// TestGetViolationsByAgreement executes this test
+func (r *TestContext) TestGetViolationsByAgreement(t *testing.T) {
+	from := Data.V01.Datetime.Add(-time.Minute)
+	all, err := r.Repo.GetViolationsByAgreement(Data.V01.AgreementId, from, from.Add(time.Hour))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(Violations). Expected: %d; Actual: %d", 1, len(all))
+
+	none, err := r.Repo.GetViolationsByAgreement(Data.V01.AgreementId, from.Add(time.Hour), from.Add(2*time.Hour))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(Violations). Expected: %d; Actual: %d", 0, len(none))
+}
+
+// TestSaveDailyStats executes this test
+func (r *TestContext) TestSaveDailyStats(t *testing.T) {
+	s := model.NewDailyStats(Data.A01.Id, "gt1", time.Now())
+	s.Evaluated = 10
+	_, err := r.Repo.SaveDailyStats(&s)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+
+	s.Evaluated = 20
+	_, err = r.Repo.SaveDailyStats(&s)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+
+	stats, err := r.Repo.GetDailyStats(Data.A01.Id, s.Date, s.Date.Add(24*time.Hour))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(DailyStats). Expected: %d; Actual: %d", 1, len(stats))
+	assertEquals(t, "Unexpected evaluated. Expected: %d; Actual: %d", 20, stats[0].Evaluated)
+
+	stats, err = r.Repo.GetDailyStats(Data.A01.Id, s.Date.Add(24*time.Hour), s.Date.Add(48*time.Hour))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(DailyStats). Expected: %d; Actual: %d", 0, len(stats))
+}
+
+// TestEvaluationHistory executes this test
+func (r *TestContext) TestEvaluationHistory(t *testing.T) {
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		e := model.NewEvaluationRecord(Data.A01.Id, "gt1", now.Add(time.Duration(-i)*time.Hour), 0)
+		e.Evaluated = 1
+		e.Values["m"] = i
+		_, err := r.Repo.SaveEvaluationRecord(&e)
+		assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	}
+
+	history, err := r.Repo.GetEvaluationHistory(Data.A01.Id, "gt1", now.Add(-24*time.Hour), now.Add(time.Minute))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(EvaluationRecords). Expected: %d; Actual: %d", 3, len(history))
+	assertEquals(t, "Unexpected order. Expected: %v; Actual: %v", true, history[0].Datetime.Before(history[1].Datetime))
+
+	other, err := r.Repo.GetEvaluationHistory(Data.A01.Id, "gt2", now.Add(-24*time.Hour), now.Add(time.Minute))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(EvaluationRecords). Expected: %d; Actual: %d", 0, len(other))
+
+	err = r.Repo.DeleteEvaluationHistory(Data.A01.Id, now.Add(-90*time.Minute))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	history, err = r.Repo.GetEvaluationHistory(Data.A01.Id, "gt1", now.Add(-24*time.Hour), now.Add(time.Minute))
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(EvaluationRecords). Expected: %d; Actual: %d", 2, len(history))
+}
+
+// TestMaintenanceWindows executes this test
+func (r *TestContext) TestMaintenanceWindows(t *testing.T) {
+	now := time.Now()
+	w := model.MaintenanceWindow{
+		Id:          "mw01",
+		AgreementId: Data.A01.Id,
+		Start:       now,
+		End:         now.Add(time.Hour),
+		Recurrence:  model.WEEKLY,
+	}
+	_, err := r.Repo.CreateMaintenanceWindow(&w)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	_, err = r.Repo.CreateMaintenanceWindow(&w)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrAlreadyExist, err)
+
+	all, err := r.Repo.GetAllMaintenanceWindows()
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(MaintenanceWindows). Expected: %d; Actual: %d", 1, len(all))
+
+	w.Description = "Weekly upgrade"
+	_, err = r.Repo.UpdateMaintenanceWindow(&w)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	stored, err := r.Repo.GetMaintenanceWindow(w.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected description. Expected: %v; Actual: %v", w.Description, stored.Description)
+
+	err = r.Repo.DeleteMaintenanceWindow(&w)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	_, err = r.Repo.GetMaintenanceWindow(w.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
+	_, err = r.Repo.UpdateMaintenanceWindow(&w)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
+	err = r.Repo.DeleteMaintenanceWindow(&w)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
+}
+
+// TestCreateReplay executes this test
+func (r *TestContext) TestCreateReplay(t *testing.T) {
+	Data.R01.AgreementId = Data.A01.Id
+	replay, err := r.Repo.CreateReplay(&Data.R01)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	Data.R01 = *replay
+
+	replay, err = r.Repo.GetReplay(Data.R01.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected replay. Expected: %v; Actual: %v", Data.R01.Id, replay.Id)
+	assertEquals(t, "Unexpected compliance. Expected: %v; Actual: %v", 0.95, replay.Results["gt1"].Compliance)
+}
+
+// TestCreateReplayExists executes this test
+func (r *TestContext) TestCreateReplayExists(t *testing.T) {
+	_, err := r.Repo.CreateReplay(&Data.R01)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrAlreadyExist, err)
+}
+
+// TestUpdateReplay executes this test
+func (r *TestContext) TestUpdateReplay(t *testing.T) {
+	Data.R01.Status = model.REPLAYDONE
+	_, err := r.Repo.UpdateReplay(&Data.R01)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+
+	replay, err := r.Repo.GetReplay(Data.R01.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected status. Expected: %v; Actual: %v", model.REPLAYDONE, replay.Status)
+}
+
+// TestUpdateReplayNotExists executes this test
+func (r *TestContext) TestUpdateReplayNotExists(t *testing.T) {
+	_, err := r.Repo.UpdateReplay(&Data.Rnotexists)
+	if err == nil {
+		t.Errorf("Expected error updating non existent replay")
+	}
+}
+
+// TestGetReplayNotExists executes this test
+func (r *TestContext) TestGetReplayNotExists(t *testing.T) {
+	_, err := r.Repo.GetReplay(Data.Rnotexists.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", model.ErrNotFound, err)
+}
+
+// TestGetReplaysByAgreement executes this test
+func (r *TestContext) TestGetReplaysByAgreement(t *testing.T) {
+	all, err := r.Repo.GetReplaysByAgreement(Data.R01.AgreementId)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(Replays). Expected: %d; Actual: %d", 1, len(all))
+
+	none, err := r.Repo.GetReplaysByAgreement(Data.Anotexists.Id)
+	assertEquals(t, "Unexpected error. Expected: %v; Actual: %v", nil, err)
+	assertEquals(t, "Unexpected len(Replays). Expected: %d; Actual: %d", 0, len(none))
+}
+
 /*
  * The functions below are kept to maintain backwards compatibility, but should
  * be removed at some point
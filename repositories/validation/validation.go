@@ -30,6 +30,7 @@ import (
 	"SLALite/model"
 	"bytes"
 	"fmt"
+	"time"
 )
 
 const (
@@ -154,6 +155,11 @@ func (r repository) GetViolation(id string) (*model.Violation, error) {
 	return r.backend.GetViolation(id)
 }
 
+// DeleteViolation deletes a violation from repository.
+func (r repository) DeleteViolation(v *model.Violation) error {
+	return r.backend.DeleteViolation(v)
+}
+
 // UpdateAgreement changes the state of an Agreement.
 func (r repository) UpdateAgreementState(id string, newState model.State) (*model.Agreement, error) {
 	var err error
@@ -190,3 +196,122 @@ func (r repository) CreateTemplate(template *model.Template) (*model.Template, e
 	}
 	return r.backend.CreateTemplate(template)
 }
+
+// CreateIncident validates and persists a new Incident.
+func (r repository) CreateIncident(i *model.Incident) (*model.Incident, error) {
+	if errs := i.Validate(r.val, model.CREATE); len(errs) > 0 {
+		err := newValError(errs)
+		return i, err
+	}
+	return r.backend.CreateIncident(i)
+}
+
+// UpdateIncident validates and updates an Incident.
+func (r repository) UpdateIncident(i *model.Incident) (*model.Incident, error) {
+	if errs := i.Validate(r.val, model.UPDATE); len(errs) > 0 {
+		err := newValError(errs)
+		return i, err
+	}
+	return r.backend.UpdateIncident(i)
+}
+
+// GetIncident returns the Incident identified by id.
+func (r repository) GetIncident(id string) (*model.Incident, error) {
+	return r.backend.GetIncident(id)
+}
+
+// GetIncidentsByAgreement returns the incidents of an agreement.
+func (r repository) GetIncidentsByAgreement(agreementID string, states ...model.IncidentState) (model.Incidents, error) {
+	return r.backend.GetIncidentsByAgreement(agreementID, states...)
+}
+
+// GetViolationsByAgreement returns the violations of an agreement in an interval.
+func (r repository) GetViolationsByAgreement(agreementID string, from time.Time, to time.Time) (model.Violations, error) {
+	return r.backend.GetViolationsByAgreement(agreementID, from, to)
+}
+
+// CreateReplay validates and persists a new Replay.
+func (r repository) CreateReplay(replay *model.Replay) (*model.Replay, error) {
+	if errs := replay.Validate(r.val, model.CREATE); len(errs) > 0 {
+		err := newValError(errs)
+		return replay, err
+	}
+	return r.backend.CreateReplay(replay)
+}
+
+// UpdateReplay validates and updates a Replay.
+func (r repository) UpdateReplay(replay *model.Replay) (*model.Replay, error) {
+	if errs := replay.Validate(r.val, model.UPDATE); len(errs) > 0 {
+		err := newValError(errs)
+		return replay, err
+	}
+	return r.backend.UpdateReplay(replay)
+}
+
+// GetReplay returns the Replay identified by id.
+func (r repository) GetReplay(id string) (*model.Replay, error) {
+	return r.backend.GetReplay(id)
+}
+
+// GetReplaysByAgreement returns the replays of an agreement.
+func (r repository) GetReplaysByAgreement(agreementID string) (model.Replays, error) {
+	return r.backend.GetReplaysByAgreement(agreementID)
+}
+
+// SaveDailyStats persists daily stats.
+func (r repository) SaveDailyStats(s *model.DailyStats) (*model.DailyStats, error) {
+	return r.backend.SaveDailyStats(s)
+}
+
+// GetDailyStats returns the daily stats of an agreement in an interval.
+func (r repository) GetDailyStats(agreementID string, from time.Time, to time.Time) ([]model.DailyStats, error) {
+	return r.backend.GetDailyStats(agreementID, from, to)
+}
+
+// SaveEvaluationRecord persists an evaluation record.
+func (r repository) SaveEvaluationRecord(e *model.EvaluationRecord) (*model.EvaluationRecord, error) {
+	return r.backend.SaveEvaluationRecord(e)
+}
+
+// GetEvaluationHistory returns the evaluation records of a guarantee term in an interval.
+func (r repository) GetEvaluationHistory(agreementID string, guarantee string, from time.Time, to time.Time) ([]model.EvaluationRecord, error) {
+	return r.backend.GetEvaluationHistory(agreementID, guarantee, from, to)
+}
+
+// DeleteEvaluationHistory removes the evaluation records of an agreement before a time.
+func (r repository) DeleteEvaluationHistory(agreementID string, before time.Time) error {
+	return r.backend.DeleteEvaluationHistory(agreementID, before)
+}
+
+// GetAllMaintenanceWindows gets all maintenance windows.
+func (r repository) GetAllMaintenanceWindows() (model.MaintenanceWindows, error) {
+	return r.backend.GetAllMaintenanceWindows()
+}
+
+// GetMaintenanceWindow returns the MaintenanceWindow identified by id.
+func (r repository) GetMaintenanceWindow(id string) (*model.MaintenanceWindow, error) {
+	return r.backend.GetMaintenanceWindow(id)
+}
+
+// CreateMaintenanceWindow validates and persists a MaintenanceWindow.
+func (r repository) CreateMaintenanceWindow(w *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+	if errs := w.Validate(r.val, model.CREATE); len(errs) > 0 {
+		err := newValError(errs)
+		return w, err
+	}
+	return r.backend.CreateMaintenanceWindow(w)
+}
+
+// UpdateMaintenanceWindow validates and updates a MaintenanceWindow.
+func (r repository) UpdateMaintenanceWindow(w *model.MaintenanceWindow) (*model.MaintenanceWindow, error) {
+	if errs := w.Validate(r.val, model.UPDATE); len(errs) > 0 {
+		err := newValError(errs)
+		return w, err
+	}
+	return r.backend.UpdateMaintenanceWindow(w)
+}
+
+// DeleteMaintenanceWindow deletes a MaintenanceWindow from repository.
+func (r repository) DeleteMaintenanceWindow(w *model.MaintenanceWindow) error {
+	return r.backend.DeleteMaintenanceWindow(w)
+}
@@ -119,16 +119,17 @@ func TestRepositoryWithExternalIds(t *testing.T) {
 		Constraint:  "var < 100",
 		Values:      []model.MetricValue{{Key: "var", Value: 101, DateTime: time.Now()}},
 	}
+	/* violations are created with their Id, even with external IDs */
 	vi, err = v.CreateViolation(vi)
-	if err != nil {
-		t.Errorf("No errors expected. Found %v", err)
+	if err == nil {
+		t.Errorf("Errors expected. Found %v", err)
 		return
 	}
 
 	vi.Id = "id"
 	vi, err = v.CreateViolation(vi)
-	if err == nil {
-		t.Errorf("Errors expected. Found %v", err)
+	if err != nil {
+		t.Errorf("No errors expected. Found %v", err)
 		return
 	}
 
@@ -0,0 +1,295 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package retention purges the old violations and terminated agreements from a
+repository, optionally archiving them to gzipped JSON Lines files before.
+
+The limits are set per entity type: a maximum age and a maximum count. The count
+of violations is per agreement; the count of agreements is of terminated agreements.
+A zero limit is not applied.
+
+Usage:
+
+	m := retention.New(config, repo)
+	if m.Enabled() {
+		go m.Run(ctx)
+	}
+*/
+package retention
+
+import (
+	"SLALite/model"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// PeriodPropertyName is the config property name of the period of the purges
+	PeriodPropertyName = "retentionPeriod"
+
+	// ArchivePathPropertyName is the config property name of the directory where
+	// the purged entities are archived. If empty, they are not archived.
+	ArchivePathPropertyName = "retentionArchivePath"
+
+	// ViolationsMaxAgePropertyName is the config property name of the maximum age of violations
+	ViolationsMaxAgePropertyName = "retentionViolationsMaxAge"
+
+	// ViolationsMaxCountPropertyName is the config property name of the maximum number
+	// of violations per agreement
+	ViolationsMaxCountPropertyName = "retentionViolationsMaxCount"
+
+	// AgreementsMaxAgePropertyName is the config property name of the maximum age of
+	// terminated agreements
+	AgreementsMaxAgePropertyName = "retentionAgreementsMaxAge"
+
+	// AgreementsMaxCountPropertyName is the config property name of the maximum number
+	// of terminated agreements
+	AgreementsMaxCountPropertyName = "retentionAgreementsMaxCount"
+
+	defaultPeriod = time.Hour
+)
+
+// Policy sets the limits of an entity type. Zero values are not applied.
+type Policy struct {
+	MaxAge   time.Duration
+	MaxCount int
+}
+
+func (p Policy) enabled() bool {
+	return p.MaxAge > 0 || p.MaxCount > 0
+}
+
+// purged returns the indexes in [0, n) of the entities to purge. The entities
+// are sorted by time, from the oldest.
+func (p Policy) purged(n int, at func(i int) time.Time, now time.Time) []int {
+	result := make([]int, 0)
+	for i := 0; i < n; i++ {
+		if (p.MaxCount > 0 && i < n-p.MaxCount) || (p.MaxAge > 0 && at(i).Before(now.Add(-p.MaxAge))) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// Manager purges the entities of a repository that exceed the retention policies
+type Manager struct {
+	repo   model.IRepository
+	period time.Duration
+
+	// Violations is the policy of violations; MaxCount is per agreement
+	Violations Policy
+
+	// Agreements is the policy of terminated agreements. The violations of a
+	// purged agreement are purged too.
+	Agreements Policy
+
+	// ArchivePath is the directory where purged entities are archived. If empty,
+	// they are not archived.
+	ArchivePath string
+}
+
+// Result contains the number of entities purged
+type Result struct {
+	Violations int `json:"violations"`
+	Agreements int `json:"agreements"`
+}
+
+// New constructs a Manager from a Viper configuration
+func New(config *viper.Viper, repo model.IRepository) *Manager {
+	config.SetDefault(PeriodPropertyName, defaultPeriod)
+
+	m := &Manager{
+		repo:   repo,
+		period: config.GetDuration(PeriodPropertyName),
+		Violations: Policy{
+			MaxAge:   config.GetDuration(ViolationsMaxAgePropertyName),
+			MaxCount: config.GetInt(ViolationsMaxCountPropertyName),
+		},
+		Agreements: Policy{
+			MaxAge:   config.GetDuration(AgreementsMaxAgePropertyName),
+			MaxCount: config.GetInt(AgreementsMaxCountPropertyName),
+		},
+		ArchivePath: config.GetString(ArchivePathPropertyName),
+	}
+	logConfig(m)
+	return m
+}
+
+func logConfig(m *Manager) {
+	log.Infof("Retention configuration\n"+
+		"\tPeriod: %v\n"+
+		"\tViolations: %+v\n"+
+		"\tAgreements: %+v\n"+
+		"\tArchive path: %s\n",
+		m.period, m.Violations, m.Agreements, m.ArchivePath)
+}
+
+// Enabled returns if any policy is set
+func (m *Manager) Enabled() bool {
+	return m.Violations.enabled() || m.Agreements.enabled()
+}
+
+// Run purges the repository every period until ctx is done
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Retention manager stopped")
+			return
+		case now := <-ticker.C:
+			result, err := m.Purge(now)
+			if err != nil {
+				log.Errorf("Error purging repository: %s", err.Error())
+			}
+			log.Infof("Retention purge. %d violations and %d agreements purged", result.Violations, result.Agreements)
+		}
+	}
+}
+
+// Purge archives and deletes the entities that exceed the policies at now.
+//
+// The entities are not deleted if the archive cannot be written.
+func (m *Manager) Purge(now time.Time) (Result, error) {
+	var result Result
+
+	agreements, err := m.repo.GetAllAgreements()
+	if err != nil {
+		return result, err
+	}
+	purgedAgreements := m.purgedAgreements(agreements, now)
+	purgedIDs := make(map[string]bool)
+	for _, a := range purgedAgreements {
+		purgedIDs[a.Id] = true
+	}
+
+	purgedViolations := make([]model.Violation, 0)
+	for _, a := range agreements {
+		violations, err := m.repo.GetViolationsByAgreement(a.Id, time.Time{}, now)
+		if err != nil {
+			return result, err
+		}
+		if purgedIDs[a.Id] {
+			purgedViolations = append(purgedViolations, violations...)
+			continue
+		}
+		if !m.Violations.enabled() {
+			continue
+		}
+		at := func(i int) time.Time { return violations[i].Datetime }
+		for _, i := range m.Violations.purged(len(violations), at, now) {
+			purgedViolations = append(purgedViolations, violations[i])
+		}
+	}
+
+	if m.ArchivePath != "" {
+		if err := m.archive("violations", now, len(purgedViolations), func(i int) interface{} { return purgedViolations[i] }); err != nil {
+			return result, err
+		}
+		if err := m.archive("agreements", now, len(purgedAgreements), func(i int) interface{} { return purgedAgreements[i] }); err != nil {
+			return result, err
+		}
+	}
+
+	for i := range purgedViolations {
+		if err := m.repo.DeleteViolation(&purgedViolations[i]); err != nil {
+			return result, err
+		}
+		result.Violations++
+	}
+	for i := range purgedAgreements {
+		if err := m.repo.DeleteAgreement(&purgedAgreements[i]); err != nil {
+			return result, err
+		}
+		result.Agreements++
+	}
+	return result, nil
+}
+
+// purgedAgreements returns the terminated agreements that exceed the policy
+func (m *Manager) purgedAgreements(agreements model.Agreements, now time.Time) []model.Agreement {
+	result := make([]model.Agreement, 0)
+	if !m.Agreements.enabled() {
+		return result
+	}
+
+	terminated := make([]model.Agreement, 0)
+	for _, a := range agreements {
+		if a.State == model.TERMINATED {
+			terminated = append(terminated, a)
+		}
+	}
+	sort.Slice(terminated, func(i, j int) bool {
+		return terminatedAt(&terminated[i]).Before(terminatedAt(&terminated[j]))
+	})
+	at := func(i int) time.Time { return terminatedAt(&terminated[i]) }
+	for _, i := range m.Agreements.purged(len(terminated), at, now) {
+		result = append(result, terminated[i])
+	}
+	return result
+}
+
+// terminatedAt returns the approximate termination time of an agreement: its
+// last assessment or, if never assessed, its creation.
+func terminatedAt(a *model.Agreement) time.Time {
+	if !a.Assessment.LastExecution.IsZero() {
+		return a.Assessment.LastExecution
+	}
+	return a.Details.Creation
+}
+
+// archive writes n entities to a gzipped JSON Lines file in ArchivePath, named
+// after the entity type and now (e.g. violations-20260915T120000Z.jsonl.gz)
+func (m *Manager) archive(name string, now time.Time, n int, entity func(i int) interface{}) error {
+	if n == 0 {
+		return nil
+	}
+	path := filepath.Join(m.ArchivePath, fmt.Sprintf("%s-%s.jsonl.gz", name, now.UTC().Format("20060102T150405Z")))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	encoder := json.NewEncoder(zw)
+	for i := 0; i < n; i++ {
+		if err = encoder.Encode(entity(i)); err != nil {
+			break
+		}
+	}
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("error archiving %s to %s: %s", name, path, err.Error())
+	}
+	log.Infof("Archived %d %s to %s", n, name, path)
+	return nil
+}
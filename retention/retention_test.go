@@ -0,0 +1,172 @@
+package retention
+
+import (
+	"SLALite/model"
+	"SLALite/repositories/memrepository"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)
+
+func createRepository(t *testing.T) model.IRepository {
+	repo, _ := memrepository.New(nil)
+	agreements := []struct {
+		id    string
+		state model.State
+		end   time.Time
+	}{
+		{"a01", model.STARTED, now},
+		{"a02", model.TERMINATED, now.Add(-48 * time.Hour)},
+		{"a03", model.TERMINATED, now.Add(-time.Hour)},
+		{"a04", model.TERMINATED, now.Add(-2 * time.Hour)},
+	}
+	for _, item := range agreements {
+		a := model.Agreement{Id: item.id, Name: item.id, State: item.state}
+		a.Assessment.LastExecution = item.end
+		if _, err := repo.CreateAgreement(&a); err != nil {
+			t.Fatalf("Error creating agreement: %v", err)
+		}
+		for i := 1; i <= 3; i++ {
+			v := model.Violation{
+				Id:          fmt.Sprintf("%s-v%d", a.Id, i),
+				AgreementId: a.Id,
+				Guarantee:   "gt",
+				Datetime:    now.Add(time.Duration(-i*24) * time.Hour),
+			}
+			if _, err := repo.CreateViolation(&v); err != nil {
+				t.Fatalf("Error creating violation: %v", err)
+			}
+		}
+	}
+	return repo
+}
+
+func TestPurge(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		m := &Manager{repo: createRepository(t)}
+		if m.Enabled() {
+			t.Error("Expected disabled manager")
+		}
+		result, err := m.Purge(now)
+		if err != nil || result != (Result{}) {
+			t.Errorf("Unexpected result: %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("MaxAge", func(t *testing.T) {
+		repo := createRepository(t)
+		m := &Manager{
+			repo:       repo,
+			Violations: Policy{MaxAge: 36 * time.Hour},
+			Agreements: Policy{MaxAge: 24 * time.Hour},
+		}
+		result, err := m.Purge(now)
+		/* a02 and its 3 violations; 2 old violations of a01, a03 and a04 */
+		if err != nil || result != (Result{Violations: 9, Agreements: 1}) {
+			t.Fatalf("Unexpected result: %+v (%v)", result, err)
+		}
+		if _, err := repo.GetAgreement("a02"); err != model.ErrNotFound {
+			t.Errorf("Expected a02 deleted. Actual: %v", err)
+		}
+		if _, err := repo.GetViolation("a01-v1"); err != nil {
+			t.Errorf("Expected a01-v1 kept. Actual: %v", err)
+		}
+		if _, err := repo.GetViolation("a01-v2"); err != model.ErrNotFound {
+			t.Errorf("Expected a01-v2 deleted. Actual: %v", err)
+		}
+	})
+
+	t.Run("MaxCount", func(t *testing.T) {
+		repo := createRepository(t)
+		m := &Manager{
+			repo:       repo,
+			Violations: Policy{MaxCount: 1},
+			Agreements: Policy{MaxCount: 1},
+		}
+		result, err := m.Purge(now)
+		/* a02 and a04 with their violations; 2 violations of a01 and a03 */
+		if err != nil || result != (Result{Violations: 10, Agreements: 2}) {
+			t.Fatalf("Unexpected result: %+v (%v)", result, err)
+		}
+		if _, err := repo.GetAgreement("a03"); err != nil {
+			t.Errorf("Expected a03 kept. Actual: %v", err)
+		}
+		if _, err := repo.GetAgreement("a04"); err != model.ErrNotFound {
+			t.Errorf("Expected a04 deleted. Actual: %v", err)
+		}
+		violations, _ := repo.GetViolationsByAgreement("a01", time.Time{}, now)
+		if len(violations) != 1 || violations[0].Id != "a01-v1" {
+			t.Errorf("Expected newest violation kept. Actual: %v", violations)
+		}
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		dir, _ := ioutil.TempDir("", "retention")
+		defer os.RemoveAll(dir)
+
+		m := &Manager{
+			repo:        createRepository(t),
+			Agreements:  Policy{MaxAge: 24 * time.Hour},
+			ArchivePath: dir,
+		}
+		if _, err := m.Purge(now); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var agreements []model.Agreement
+		readArchive(t, filepath.Join(dir, "agreements-20260915T120000Z.jsonl.gz"), func(line []byte) {
+			var a model.Agreement
+			json.Unmarshal(line, &a)
+			agreements = append(agreements, a)
+		})
+		if len(agreements) != 1 || agreements[0].Id != "a02" {
+			t.Errorf("Unexpected archived agreements: %v", agreements)
+		}
+		count := 0
+		readArchive(t, filepath.Join(dir, "violations-20260915T120000Z.jsonl.gz"), func(line []byte) {
+			count++
+		})
+		if count != 3 {
+			t.Errorf("Expected 3 archived violations. Actual: %d", count)
+		}
+	})
+
+	t.Run("ArchiveError", func(t *testing.T) {
+		repo := createRepository(t)
+		m := &Manager{
+			repo:        repo,
+			Agreements:  Policy{MaxAge: 24 * time.Hour},
+			ArchivePath: "/doesnotexist",
+		}
+		if _, err := m.Purge(now); err == nil {
+			t.Fatal("Expected error")
+		}
+		if _, err := repo.GetAgreement("a02"); err != nil {
+			t.Errorf("Expected a02 kept. Actual: %v", err)
+		}
+	})
+}
+
+func readArchive(t *testing.T, path string, f func(line []byte)) {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Error opening archive: %v", err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Error reading archive: %v", err)
+	}
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		f(scanner.Bytes())
+	}
+}
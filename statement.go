@@ -0,0 +1,86 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"SLALite/model"
+	"SLALite/reports"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// statementCommand is the subcommand that writes the SLA statement of an agreement
+const statementCommand = "statement"
+
+// runStatement executes the statement subcommand with its arguments:
+//
+//	statement [-period 2026-09] [-format html|pdf] [-o file] <agreement id>
+//
+// The statement is written to stdout if no file is set.
+func runStatement(repo model.IRepository, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(statementCommand, flag.ContinueOnError)
+	periodName := flags.String("period", "", "Year (2026), month (2026-09) or day (2026-09-15). Defaults to the current month")
+	format := flags.String("format", "html", "Format of the statement: html or pdf")
+	output := flags.String("o", "", "Output file. Defaults to stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: statement [options] <agreement id>")
+	}
+
+	now := time.Now()
+	period := reports.MonthOf(now)
+	if *periodName != "" {
+		var err error
+		if period, err = reports.ParsePeriod(*periodName); err != nil {
+			return err
+		}
+	}
+	write := reports.WriteHTML
+	switch *format {
+	case "html":
+	case "pdf":
+		write = reports.WritePDF
+	default:
+		return fmt.Errorf("invalid format '%s'", *format)
+	}
+
+	a, err := repo.GetAgreement(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error getting agreement %s: %s", flags.Arg(0), err.Error())
+	}
+	statement, err := reports.BuildStatement(repo, a, period, now)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return write(w, statement)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStatement(t *testing.T) {
+	ag := createAgreement("astatement", p1, c2, "Agreement statement", nil)
+	repo.CreateAgreement(&ag)
+	defer repo.DeleteAgreement(&ag)
+
+	t.Run("HTML", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		if err := runStatement(repo, []string{"-period", "2026-09", ag.Id}, out); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if html := out.String(); !strings.Contains(html, "<h1>SLA statement</h1>") || !strings.Contains(html, ag.Name) {
+			t.Errorf("Unexpected statement: %s", html)
+		}
+	})
+	t.Run("PDF", func(t *testing.T) {
+		dir, _ := ioutil.TempDir("", "statement")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "a01.pdf")
+
+		if err := runStatement(repo, []string{"-format", "pdf", "-o", path, ag.Id}, nil); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		content, _ := ioutil.ReadFile(path)
+		if !bytes.HasPrefix(content, []byte("%PDF-1.4")) {
+			t.Errorf("Unexpected PDF: %s", content)
+		}
+	})
+	t.Run("Errors", func(t *testing.T) {
+		args := [][]string{
+			{},
+			{"-format", "doc", ag.Id},
+			{"-period", "september", ag.Id},
+			{"doesnotexist"},
+		}
+		for _, a := range args {
+			if err := runStatement(repo, a, new(bytes.Buffer)); err == nil {
+				t.Errorf("Expected error with args %v", a)
+			}
+		}
+	})
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+/*
+This file contains the metrics of SLALite, registered in the Default registry.
+*/
+
+var (
+	// AssessmentDuration is the duration of the assessment cycles
+	AssessmentDuration = defaultRegistry.NewHistogram("slalite_assessment_duration_seconds",
+		"Duration of the assessment cycles.", DefaultBuckets)
+
+	// AgreementsEvaluated is the number of evaluations of agreements
+	AgreementsEvaluated = defaultRegistry.NewCounter("slalite_agreements_evaluated_total",
+		"Number of evaluations of agreements.")
+
+	// ViolationsRaised is the number of violations, per guarantee term name. There is
+	// no agreement label, as the number of agreements is not bounded.
+	ViolationsRaised = defaultRegistry.NewCounter("slalite_violations_total",
+		"Number of violations raised.", "guarantee")
+
+	// NotifierFailures is the number of notifications that could not be sent, per notifier
+	NotifierFailures = defaultRegistry.NewCounter("slalite_notifier_failures_total",
+		"Number of notifications that could not be sent.", "notifier")
+
+	// RetrieverDuration is the duration of the retrievals of monitoring values, per retriever
+	RetrieverDuration = defaultRegistry.NewHistogram("slalite_retriever_duration_seconds",
+		"Duration of the retrievals of monitoring values.", DefaultBuckets, "retriever")
+
+	// RetrieverErrors is the number of failed retrievals of monitoring values, per retriever
+	RetrieverErrors = defaultRegistry.NewCounter("slalite_retriever_errors_total",
+		"Number of failed retrievals of monitoring values.", "retriever")
+
+	// HTTPRequests is the number of HTTP requests, per method, route and status code
+	HTTPRequests = defaultRegistry.NewCounter("slalite_http_requests_total",
+		"Number of HTTP requests.", "method", "route", "code")
+
+	// HTTPRequestDuration is the duration of the HTTP requests, per method and route
+	HTTPRequestDuration = defaultRegistry.NewHistogram("slalite_http_request_duration_seconds",
+		"Duration of the HTTP requests.", DefaultBuckets, "method", "route")
+)
@@ -0,0 +1,281 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package telemetry contains the metrics of SLALite itself (e.g. the duration
+of the assessment cycles or the failures of the notifiers), exposed in the
+Prometheus text format.
+
+The metrics are registered in a Registry, which implements http.Handler to
+be scraped. The metrics of SLALite are in the Default registry:
+
+	telemetry.AgreementsEvaluated.Inc()
+	telemetry.RetrieverDuration.Observe(0.25, "prometheus")
+*/
+package telemetry
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the default upper bounds in seconds of the buckets of a duration histogram
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Registry is a set of metrics. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(sb *strings.Builder)
+}
+
+// desc contains the description common to all the metric types
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// Counter is a metric whose value only increases, with a value per label values
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// Histogram samples observations (e.g. durations) in buckets, per label values
+type Histogram struct {
+	desc
+	mu      sync.Mutex
+	buckets []float64
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry of the metrics of SLALite
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		desc:   desc{name: name, help: help, labels: labels},
+		values: make(map[string]float64),
+	}
+	r.register(c)
+	return c
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and label names
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name: name, help: help, labels: labels},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(h)
+	return h
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(r.String()))
+}
+
+// String returns the metrics in the Prometheus text format
+func (r *Registry) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sb strings.Builder
+	for _, m := range r.metrics {
+		m.write(&sb)
+	}
+	return sb.String()
+}
+
+// Inc increments by 1 the counter of the label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the counter of the label values
+func (c *Counter) Add(v float64, labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] += v
+}
+
+// Value returns the value of the counter of the label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *Counter) write(sb *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(sb, "counter")
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(sb, "%s%s %s\n", c.name, key, formatFloat(c.values[key]))
+	}
+}
+
+// Observe adds an observation to the histogram of the label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValue{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			hv.counts[i]++
+		}
+	}
+	hv.sum += v
+	hv.count++
+}
+
+// ObserveSince adds the time elapsed since start, in seconds, to the histogram of the label values
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Count returns the number of observations of the histogram of the label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hv, ok := h.values[key]; ok {
+		return hv.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(sb *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(sb, "histogram")
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hv := h.values[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(sb, "%s_bucket%s %d\n", h.name, withLe(key, formatFloat(upper)), hv.counts[i])
+		}
+		fmt.Fprintf(sb, "%s_bucket%s %d\n", h.name, withLe(key, "+Inf"), hv.count)
+		fmt.Fprintf(sb, "%s_sum%s %s\n", h.name, key, formatFloat(hv.sum))
+		fmt.Fprintf(sb, "%s_count%s %d\n", h.name, key, hv.count)
+	}
+}
+
+func (d desc) header(sb *strings.Builder, typ string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", d.name, d.help)
+	fmt.Fprintf(sb, "# TYPE %s %s\n", d.name, typ)
+}
+
+// key returns the label set of the label values, e.g. {method="GET",code="200"}.
+// Missing label values are empty; extra label values are ignored.
+func (d desc) key(labelValues []string) string {
+	if len(d.labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(d.labels))
+	for i, label := range d.labels {
+		value := ""
+		if i < len(labelValues) {
+			value = labelValues[i]
+		}
+		pairs = append(pairs, label+"="+quoteLabelValue(value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLe adds the le label of a histogram bucket to a label set
+func withLe(key string, le string) string {
+	pair := "le=" + quoteLabelValue(le)
+	if key == "" {
+		return "{" + pair + "}"
+	}
+	return key[:len(key)-1] + "," + pair + "}"
+}
+
+// labelValueEscaper escapes the characters that the text format requires to be escaped in label values
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quoteLabelValue returns a label value quoted as in the text format. Unlike
+// strconv.Quote, the UTF-8 characters are not escaped.
+func quoteLabelValue(value string) string {
+	return `"` + labelValueEscaper.Replace(value) + `"`
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "Test counter.", "method", "code")
+	c.Inc("GET", "200")
+	c.Add(2, "GET", "200")
+	c.Inc("POST", "500")
+
+	if v := c.Value("GET", "200"); v != 3 {
+		t.Errorf("Unexpected value: %v", v)
+	}
+	expected := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{method="GET",code="200"} 3
+test_total{method="POST",code="500"} 1
+`
+	if actual := r.String(); actual != expected {
+		t.Errorf("Unexpected output:\n%s", actual)
+	}
+}
+
+func TestCounterLabelValues(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "Test counter.", "guarantee")
+	c.Inc("latencia en álgebra \"p99\"\nC:\\tmp\t€")
+
+	expected := `test_total{guarantee="latencia en álgebra \"p99\"\nC:\\tmp` + "\t" + `€"} 1`
+	if actual := r.String(); !strings.Contains(actual, expected) {
+		t.Errorf("Unexpected output:\n%s", actual)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("test_seconds", "Test histogram.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(2)
+
+	if n := h.Count(); n != 3 {
+		t.Errorf("Unexpected count: %d", n)
+	}
+	expected := `# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 2.55
+test_seconds_count 3
+`
+	if actual := r.String(); actual != expected {
+		t.Errorf("Unexpected output:\n%s", actual)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewHistogram("test_seconds", "Test histogram.", DefaultBuckets, "retriever").Observe(0.2, "prometheus")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Unexpected content type: %s", rr.Header().Get("Content-Type"))
+	}
+	if body := rr.Body.String(); !strings.Contains(body, `test_seconds_bucket{retriever="prometheus",le="0.25"} 1`) {
+		t.Errorf("Unexpected body: %s", body)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Atos
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"SLALite/model"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// CalendarsPropertyName is the name of the property with the calendars that
+	// guarantee terms can reference, by name. Each calendar has the settings
+	// `timezone`, `windows` (each one with `days`, `start` and `end`) and `holidays`
+	// (paths of iCalendar files).
+	CalendarsPropertyName = "calendars"
+)
+
+/*
+RegisterCalendars registers the calendars defined in the configuration (see
+model.RegisterCalendar), with the holidays of their iCalendar files.
+
+Example:
+
+	calendars:
+	  support:
+	    timezone: Europe/Madrid
+	    windows:
+	    - days: [mon-fri]
+	      start: "08:00"
+	      end: "18:00"
+	    holidays: [/etc/slalite/holidays.ics]
+*/
+func RegisterCalendars(config *viper.Viper) error {
+	for name := range config.GetStringMap(CalendarsPropertyName) {
+		sub := config.Sub(CalendarsPropertyName + "." + name)
+		if sub == nil {
+			return fmt.Errorf("calendar '%s' has no settings", name)
+		}
+		calendar := model.Calendar{
+			Name:     name,
+			Timezone: sub.GetString("timezone"),
+		}
+		if err := sub.UnmarshalKey("windows", &calendar.Windows); err != nil {
+			return fmt.Errorf("calendar '%s': %s", name, err.Error())
+		}
+		for _, path := range sub.GetStringSlice("holidays") {
+			holidays, err := readHolidays(path)
+			if err != nil {
+				return fmt.Errorf("calendar '%s': %s", name, err.Error())
+			}
+			calendar.Holidays = append(calendar.Holidays, holidays...)
+		}
+		if err := model.RegisterCalendar(calendar); err != nil {
+			return err
+		}
+		log.Infof("Calendar %s: %d windows, %d holidays", name, len(calendar.Windows), len(calendar.Holidays))
+	}
+	return nil
+}
+
+// readHolidays reads the holidays of an iCalendar file
+func readHolidays(path string) ([]model.Holiday, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	holidays, err := model.ParseICal(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+	return holidays, nil
+}
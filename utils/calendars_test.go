@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"SLALite/model"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestRegisterCalendars(t *testing.T) {
+	config := viper.New()
+	config.SetConfigType("yaml")
+	config.ReadConfig(bytes.NewBufferString(`
+calendars:
+  support:
+    windows:
+    - days: [mon-fri]
+      start: "08:00"
+      end: "18:00"
+    holidays: [testdata/holidays.ics]
+`))
+	if err := RegisterCalendars(config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	calendar, ok := model.GetCalendar("support")
+	if !ok {
+		t.Fatalf("Expected calendar registered")
+	}
+	if len(calendar.Windows) != 1 || len(calendar.Holidays) != 1 {
+		t.Errorf("Unexpected calendar: %+v", calendar)
+	}
+	if calendar.IsActive(time.Date(2026, 10, 12, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected calendar not active on holiday")
+	}
+
+	config.Set("calendars.support.holidays", []string{"testdata/notexists.ics"})
+	if err := RegisterCalendars(config); err == nil {
+		t.Errorf("Expected error on missing holidays file")
+	}
+}
@@ -37,6 +37,21 @@ const (
 	// DefaultExternalIDs is the default value of externalIDs
 	DefaultExternalIDs bool = false
 
+	// DefaultIncidents is the default value of incidents
+	DefaultIncidents bool = false
+
+	// DefaultHistoryRetention is the default value of historyRetention
+	DefaultHistoryRetention time.Duration = 30 * 24 * time.Hour
+
+	// DefaultHistoryResolution is the default value of historyResolution (no downsampling)
+	DefaultHistoryResolution time.Duration = 0
+
+	// DefaultOnMonitoringError is the default value of onMonitoringError
+	DefaultOnMonitoringError string = "missing_data"
+
+	// DefaultEventsQueueSize is the default value of eventsQueueSize
+	DefaultEventsQueueSize int = 1000
+
 	// CheckPeriodPropertyName is the name of the property CheckPeriod
 	CheckPeriodPropertyName = "checkPeriod"
 
@@ -46,13 +61,33 @@ const (
 	// AdapterTypePropertyName is the name of the property adapter type(prometheus)
 	AdapterTypePropertyName = "adapter"
 
-	// NotifierTypePropertyName is the name of the property notifier type (log/rest/rabbit)
+	// NotifierTypePropertyName is the name of the property notifier type (log/rest/rabbit/smtp/slack/webhook/kafka/nats)
 	NotifierTypePropertyName = "notifier"
 
 	// ExternalIDsPropertyName is a boolean value that indicates if the used repository
 	// auto assigns the ID of entities when they are stored on repository
 	ExternalIDsPropertyName = "externalIDs"
 
+	// IncidentsPropertyName is a boolean value that enables the grouping of
+	// consecutive violations of a guarantee term into incidents
+	IncidentsPropertyName = "incidents"
+
+	// HistoryRetentionPropertyName is the name of the property with the duration
+	// the evaluation history is kept (0 keeps it forever)
+	HistoryRetentionPropertyName = "historyRetention"
+
+	// HistoryResolutionPropertyName is the name of the property with the duration
+	// of the time slots the evaluation history is downsampled to (0 does not downsample)
+	HistoryResolutionPropertyName = "historyResolution"
+
+	// OnMonitoringErrorPropertyName is the name of the property with what is done with
+	// a guarantee term whose values cannot be retrieved (missing_data/violate/skip)
+	OnMonitoringErrorPropertyName = "onMonitoringError"
+
+	// EventsQueueSizePropertyName is the name of the property with the number of
+	// events waiting to be delivered to the notifier
+	EventsQueueSizePropertyName = "eventsQueueSize"
+
 	// SingleFilePropertyName is the name of the property single file
 	// If singlefile is set, all configuration is retrieved from a single file.
 	// If not, configuration may be obtained from several files: e.g. mongodb configuration
@@ -112,3 +112,26 @@ func GetClient(insecure bool) *http.Client {
 
 	return client
 }
+
+/*
+GetTLSConfig returns a *tls.Config that uses the (previously added with
+AddTrustedCAs) trusted CAs, to be used by clients of non HTTP protocols.
+
+If certPath and keyPath are not empty, the client certificate in those files
+is presented to the server. The flag insecure bypasses the SSL verification
+(do not use in production!!)
+*/
+func GetTLSConfig(insecure bool, certPath string, keyPath string) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: insecure,
+		RootCAs:            cas,
+	}
+	if certPath != "" && keyPath != "" {
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -101,3 +101,13 @@ func TestConnectToServer(t *testing.T) {
 func f(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
+
+func TestGetTLSConfig(t *testing.T) {
+	config, err := GetTLSConfig(false, "testdata/cert.pem", "testdata/key.pem")
+	if err != nil || len(config.Certificates) != 1 {
+		t.Errorf("Unexpected TLS config. err=%v", err)
+	}
+	if _, err := GetTLSConfig(false, "testdata/notexists.pem", "testdata/key.pem"); err == nil {
+		t.Error("Expected error on not existing certificate")
+	}
+}